@@ -1,11 +1,18 @@
 package main
 
 import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/app"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/lifecycle"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/middlewares"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slog"
 )
 
 func init() {
@@ -20,28 +27,91 @@ func main() {
 	defer logFile.Close()
 
 	router := gin.New()
+	app.SetUpTrustedProxies(router)
+	router.Use(middlewares.RequestId())
 	router.Use(middlewares.JSONlogger())
 	router.Use(gin.Recovery())
 
 	configurations := cors.DefaultConfig()
 	configurations.AllowAllOrigins = true
 	configurations.AllowCredentials = true
-	configurations.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	configurations.AllowHeaders = []string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "Accept", "Origin", "Cache-Control", "X-Requested-With"}
-	configurations.ExposeHeaders = []string{"Content-Length"}
+	configurations.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	configurations.AllowHeaders = []string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "Accept", "Origin", "Cache-Control", "X-Requested-With", "X-Request-Id"}
+	configurations.ExposeHeaders = []string{"Content-Length", "X-Request-Id"}
 	router.Use(cors.New(configurations))
 
+	lc := lifecycle.New()
+
 	client := config.NewMongoClient()
 	database := client.ConnectToDB()
-	defer client.Disconnect()
+	lc.Register(lifecycle.Storage, "mongo", client.Disconnect)
 
 	conn := config.NewAMQPconnection()
-	defer conn.DisconnectAll()
+	lc.Register(lifecycle.Messaging, "amqp", conn.DisconnectAll)
+	messaging := app.SetUpTopology(conn)
 
 	firebaseClient := config.NewFirebaseClient()
 
-	app.SetUpApp(router, database, conn, firebaseClient)
+	app.SetUpApp(router, database, firebaseClient)
+
+	healthRouter := router.Group("")
+	app.SetUpHealth(healthRouter, client, conn, firebaseClient, lc.IsReady)
+
+	deadLetterRouter := router.Group("/api/admin/dead-letters")
+	app.SetUpDeadLetters(deadLetterRouter, database, conn, firebaseClient)
+
+	outboxRelay := app.SetUpOutboxRelay(database, conn)
+	outboxRelay.Start()
+	lc.Register(lifecycle.Workers, "outbox relay", lifecycle.Blocking(outboxRelay.Stop))
+
+	webhookVerification := app.SetUpWebhookVerification(database)
+	webhookVerification.Start()
+	lc.Register(lifecycle.Workers, "webhook verification", lifecycle.Blocking(webhookVerification.Stop))
+
+	fcmTokenPrune := app.SetUpFCMtokenPrune(database)
+	fcmTokenPrune.Start()
+	lc.Register(lifecycle.Workers, "FCM token prune", lifecycle.Blocking(fcmTokenPrune.Stop))
+
+	fcmTokenErrorConsumer := app.SetUpFCMtokenErrorConsumer(database, conn, messaging)
+	fcmTokenErrorConsumer.Start()
+	lc.Register(lifecycle.Workers, "FCM token error consumer", lifecycle.Blocking(fcmTokenErrorConsumer.Stop))
+
+	export := app.SetUpExportJob(database)
+	export.Start()
+	lc.Register(lifecycle.Workers, "data export", lifecycle.Blocking(export.Stop))
+
+	erasure := app.SetUpErasureJob(database, firebaseClient)
+	erasure.Start()
+	lc.Register(lifecycle.Workers, "erasure", lifecycle.Blocking(erasure.Stop))
+
+	erasureAcknowledgementConsumer := app.SetUpErasureAcknowledgementConsumer(database, conn, messaging, firebaseClient)
+	erasureAcknowledgementConsumer.Start()
+	lc.Register(lifecycle.Workers, "erasure acknowledgement consumer", lifecycle.Blocking(erasureAcknowledgementConsumer.Stop))
+
+	for _, deadLetterConsumer := range app.SetUpDeadLetterConsumers(database, conn, messaging) {
+		deadLetterConsumer.Start()
+		lc.Register(lifecycle.Workers, "dead letter consumer", lifecycle.Blocking(deadLetterConsumer.Stop))
+	}
+
+	server := &http.Server{
+		Addr:              ":" + config.GetEnv("PORT", "8080"),
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	lc.Register(lifecycle.Server, "http server", server.Shutdown)
+
+	go func() {
+		slog.Info("Listening", "addr", server.Addr)
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			lc.Fail("http server", err)
+		}
+	}()
 
-	router.Run()
+	err := lc.Run()
+	if err != nil {
+		logFile.Close()
+		os.Exit(1)
+	}
 
 }
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"os"
+
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/app"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"golang.org/x/exp/slog"
 )
 
 func init() {
@@ -11,10 +16,71 @@ func init() {
 
 func main() {
 
+	migrate := flag.String("migrate", "up", "schema migrations to run: up, down, status or none")
+	target := flag.Int("target", 0, "version to migrate up to (0 for all) or to roll back to (required for down)")
+	declare := flag.String("topology", "apply", "messaging topology of AMQP_TOPOLOGY_FILE, or the built-in one, to declare: apply, migrate to first recreate what the broker has with other arguments, or none")
+	dryRun := flag.Bool("dry-run", false, "report what the migrations and the topology would change without changing anything")
+	flag.Parse()
+
+	if *declare != "apply" && *declare != "migrate" && *declare != "none" {
+		slog.Error("Unknown -topology value", "topology", *declare)
+		os.Exit(2)
+	}
+
 	client := config.NewMongoClient()
 	database := client.ConnectToDB()
-	defer client.Disconnect()
+	defer client.Disconnect(context.Background())
+
+	// migrations run before the indexes, which may rely on the migrated fields
+	migrator := app.SetUpMigrator(database)
+
+	var err error
+	switch *migrate {
+	case "up":
+		err = migrator.Up(*target, *dryRun)
+	case "down":
+		if !isFlagSet("target") {
+			slog.Error("Rolling back needs an explicit -target version")
+			os.Exit(2)
+		}
+		err = migrator.Down(*target, *dryRun)
+	case "status":
+		err = migrator.Status()
+	case "none":
+	default:
+		slog.Error("Unknown -migrate value", "migrate", *migrate)
+		os.Exit(2)
+	}
+	if err != nil {
+		slog.Error("Migration failed", "error", err)
+		client.Disconnect(context.Background())
+		os.Exit(1)
+	}
 
-	app.SetUpRepositoryIndexes(database)
+	if *migrate == "status" {
+		return
+	}
+
+	if !*dryRun {
+		app.SetUpRepositoryIndexes(database)
+	}
+
+	if *declare != "none" {
+		err = app.ApplyTopology(*dryRun, *declare == "migrate")
+		if err != nil {
+			client.Disconnect(context.Background())
+			os.Exit(1)
+		}
+	}
+
+}
 
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/middlewares"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/routes"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/validations"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpAdmin(router *gin.RouterGroup, client *mongo.Client, collection *mongo.Collection, outboxCollection *mongo.Collection, auditCollection *mongo.Collection, firebaseClient config.FirebaseClient) {
+
+	repository := repositories.NewUserRepository(collection)
+	outboxRepository := repositories.NewOutboxRepository(outboxCollection)
+	auditRepository := repositories.NewAuditRepository(auditCollection)
+	transactionRepository := repositories.NewTransactionRepository(client)
+	erasureService := newErasureService(collection.Database(), firebaseClient)
+	service := services.NewAdminService(repository, outboxRepository, auditRepository, transactionRepository, erasureService)
+	controller := controllers.NewAdminController(service)
+	validations.RegisterUserValidations()
+	authorization := middlewares.Authorization(firebaseClient)
+	roleAuthorization := middlewares.RoleAuthorization(routes.GetAccessPolicy())
+	routes.RegisterAdminRoutes(router, authorization, roleAuthorization, controller)
+
+}
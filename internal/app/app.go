@@ -8,12 +8,46 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func SetUpApp(router *gin.Engine, database *mongo.Database, conn config.AMQPconnection, firebaseClient config.FirebaseClient) {
+func SetUpApp(router *gin.Engine, database *mongo.Database, firebaseClient config.FirebaseClient) {
 
 	collection := database.Collection(os.Getenv("USER_COLLECTION"))
+	outboxCollection := database.Collection(os.Getenv("OUTBOX_COLLECTION"))
+	linkStateCollection := database.Collection(os.Getenv("LINK_STATE_COLLECTION"))
+	webhookCollection := database.Collection(os.Getenv("WEBHOOK_COLLECTION"))
+	webhookDeliveryCollection := database.Collection(os.Getenv("WEBHOOK_DELIVERY_COLLECTION"))
+	auditCollection := database.Collection(config.GetEnv("AUDIT_COLLECTION", "audit"))
 	userRouter := router.Group("/api/user")
 
-	SetUpUser(userRouter, collection, conn, firebaseClient)
+	SetUpUser(userRouter, database.Client(), collection, webhookCollection, outboxCollection, auditCollection, firebaseClient)
+
+	webhookRouter := router.Group("/api/user/webhooks")
+	SetUpWebhook(webhookRouter, database.Client(), collection, webhookCollection, webhookDeliveryCollection, outboxCollection, auditCollection, firebaseClient)
+
+	discordRouter := router.Group("/api/user/discord")
+	discordCallbackRouter := router.Group("/api/discord")
+	SetUpDiscord(discordRouter, discordCallbackRouter, database.Client(), collection, linkStateCollection, outboxCollection, auditCollection, firebaseClient)
+
+	telegramRouter := router.Group("/api/user/telegram")
+	telegramInternalRouter := router.Group("/internal/telegram")
+	SetUpTelegram(telegramRouter, telegramInternalRouter, database.Client(), collection, linkStateCollection, outboxCollection, auditCollection, firebaseClient)
+
+	adminRouter := router.Group("/api/admin/users")
+	SetUpAdmin(adminRouter, database.Client(), collection, outboxCollection, auditCollection, firebaseClient)
+
+	erasureRouter := router.Group("/api/user")
+	erasureAdminRouter := router.Group("/api/admin/users")
+	SetUpErasure(erasureRouter, erasureAdminRouter, database, firebaseClient)
+
+	auditRouter := router.Group("/api/user/audit")
+	auditAdminRouter := router.Group("/api/admin/audit")
+	SetUpAudit(auditRouter, auditAdminRouter, auditCollection, firebaseClient)
+
+	exportRouter := router.Group("/api/user/export")
+	exportDownloadRouter := router.Group("/api/export")
+	SetUpExport(exportRouter, exportDownloadRouter, database, firebaseClient)
+
+	internalRouter := router.Group("/internal")
+	SetUpInternal(internalRouter, database.Client(), collection, webhookCollection, outboxCollection, auditCollection)
 
 }
 
@@ -22,4 +56,31 @@ func SetUpRepositoryIndexes(database *mongo.Database) {
 	collection := database.Collection(os.Getenv("USER_COLLECTION"))
 	SetUpUserRepositoryIndexes(collection)
 
+	outboxCollection := database.Collection(os.Getenv("OUTBOX_COLLECTION"))
+	SetUpOutboxRepositoryIndexes(outboxCollection)
+
+	linkStateCollection := database.Collection(os.Getenv("LINK_STATE_COLLECTION"))
+	SetUpLinkStateRepositoryIndexes(linkStateCollection)
+
+	webhookCollection := database.Collection(os.Getenv("WEBHOOK_COLLECTION"))
+	SetUpWebhookRepositoryIndexes(webhookCollection)
+
+	webhookDeliveryCollection := database.Collection(os.Getenv("WEBHOOK_DELIVERY_COLLECTION"))
+	SetUpWebhookDeliveryRepositoryIndexes(webhookDeliveryCollection)
+
+	erasureCollection := database.Collection(config.GetEnv("ERASURE_COLLECTION", "erasures"))
+	SetUpErasureRepositoryIndexes(erasureCollection)
+
+	exportCollection := database.Collection(config.GetEnv("EXPORT_COLLECTION", "exports"))
+	SetUpExportRepositoryIndexes(exportCollection)
+
+	auditCollection := database.Collection(config.GetEnv("AUDIT_COLLECTION", "audit"))
+	SetUpAuditRepositoryIndexes(auditCollection)
+
+	deadLetterCollection := database.Collection(config.GetEnv("DEAD_LETTER_COLLECTION", "dead_letters"))
+	SetUpDeadLetterRepositoryIndexes(deadLetterCollection)
+
+	migrationCollection := database.Collection(config.GetEnv("MIGRATION_COLLECTION", "migrations"))
+	SetUpMigrationRepositoryIndexes(migrationCollection)
+
 }
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/middlewares"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/routes"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpAudit(router *gin.RouterGroup, adminRouter *gin.RouterGroup, collection *mongo.Collection, firebaseClient config.FirebaseClient) {
+
+	repository := repositories.NewAuditRepository(collection)
+	service := services.NewAuditService(repository)
+	controller := controllers.NewAuditController(service)
+	authorization := middlewares.Authorization(firebaseClient)
+	roleAuthorization := middlewares.RoleAuthorization(routes.GetAccessPolicy())
+	routes.RegisterAuditRoutes(router, adminRouter, authorization, roleAuthorization, controller)
+
+}
+
+func SetUpAuditRepositoryIndexes(collection *mongo.Collection) {
+
+	repository := repositories.NewAuditRepositorySetup(collection)
+	repository.MakeUserIdCreatedAtIndex()
+	repository.MakeActorIdCreatedAtIndex()
+
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/consumers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/middlewares"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/producers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/routes"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/topology"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpDeadLetters(router *gin.RouterGroup, database *mongo.Database, conn config.AMQPconnection, firebaseClient config.FirebaseClient) {
+
+	service := newDeadLetterService(database, conn)
+	controller := controllers.NewDeadLetterController(service)
+	authorization := middlewares.Authorization(firebaseClient)
+	roleAuthorization := middlewares.RoleAuthorization(routes.GetAccessPolicy())
+	routes.RegisterDeadLetterRoutes(router, authorization, roleAuthorization, controller)
+
+}
+
+// SetUpDeadLetterConsumers collects every dead-letter queue of the topology
+func SetUpDeadLetterConsumers(database *mongo.Database, conn config.AMQPconnection, messaging *topology.Topology) []consumers.DeadLetterConsumer {
+
+	service := newDeadLetterService(database, conn)
+
+	deadLetterConsumers := []consumers.DeadLetterConsumer{}
+	for _, queue := range messaging.DeadLetterQueues() {
+		deadLetterConsumers = append(deadLetterConsumers, consumers.NewDeadLetterConsumer(conn, messaging, queue, service))
+	}
+
+	return deadLetterConsumers
+
+}
+
+func SetUpDeadLetterRepositoryIndexes(collection *mongo.Collection) {
+
+	repository := repositories.NewDeadLetterRepositorySetup(collection)
+	repository.MakeQueueStatusDeadLetteredAtIndex()
+	repository.MakeUserIdIndex()
+	repository.MakeDeadLetteredAtTTLIndex(config.GetEnvDuration("DEAD_LETTER_RETENTION", 30*24*time.Hour))
+
+}
+
+func newDeadLetterService(database *mongo.Database, conn config.AMQPconnection) services.DeadLetterService {
+
+	repository := repositories.NewDeadLetterRepository(database.Collection(config.GetEnv("DEAD_LETTER_COLLECTION", "dead_letters")))
+	producer := producers.NewDeadLetterProducer(producers.NewPublisher(conn))
+	return services.NewDeadLetterService(repository, producer)
+
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/middlewares"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/routes"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpDiscord(router *gin.RouterGroup, callbackRouter *gin.RouterGroup, client *mongo.Client, collection *mongo.Collection, linkStateCollection *mongo.Collection, outboxCollection *mongo.Collection, auditCollection *mongo.Collection, firebaseClient config.FirebaseClient) {
+
+	repository := repositories.NewUserRepository(collection)
+	linkStateRepository := repositories.NewLinkStateRepository(linkStateCollection)
+	outboxRepository := repositories.NewOutboxRepository(outboxCollection)
+	auditRepository := repositories.NewAuditRepository(auditCollection)
+	transactionRepository := repositories.NewTransactionRepository(client)
+	discordClient := config.NewDiscordOAuthClient()
+	service := services.NewDiscordService(repository, linkStateRepository, outboxRepository, auditRepository, transactionRepository, discordClient)
+	controller := controllers.NewDiscordController(service)
+	authorization := middlewares.Authorization(firebaseClient)
+	roleAuthorization := middlewares.RoleAuthorization(routes.GetAccessPolicy())
+	routes.RegisterDiscordRoutes(router, callbackRouter, authorization, roleAuthorization, controller)
+
+}
+
+func SetUpLinkStateRepositoryIndexes(collection *mongo.Collection) {
+
+	repository := repositories.NewLinkStateRepositorySetup(collection)
+	repository.MakeStateUniqueIndex()
+	repository.MakeExpiresAtTTLIndex()
+
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"os"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/consumers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/jobs"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/middlewares"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/routes"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/topology"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpErasure(router *gin.RouterGroup, adminRouter *gin.RouterGroup, database *mongo.Database, firebaseClient config.FirebaseClient) {
+
+	service := newErasureService(database, firebaseClient)
+	controller := controllers.NewErasureController(service)
+	authorization := middlewares.Authorization(firebaseClient)
+	roleAuthorization := middlewares.RoleAuthorization(routes.GetAccessPolicy())
+	routes.RegisterErasureRoutes(router, adminRouter, authorization, roleAuthorization, controller)
+
+}
+
+func SetUpErasureJob(database *mongo.Database, firebaseClient config.FirebaseClient) jobs.ErasureJob {
+
+	return jobs.NewErasureJob(newErasureService(database, firebaseClient))
+
+}
+
+func SetUpErasureAcknowledgementConsumer(database *mongo.Database, conn config.AMQPconnection, messaging *topology.Topology, firebaseClient config.FirebaseClient) consumers.ErasureAcknowledgementConsumer {
+
+	return consumers.NewErasureAcknowledgementConsumer(conn, messaging, newErasureService(database, firebaseClient))
+
+}
+
+func SetUpErasureRepositoryIndexes(collection *mongo.Collection) {
+
+	repository := repositories.NewErasureRepositorySetup(collection)
+	repository.MakeUserIdRequestedAtIndex()
+	repository.MakeStatusScheduledForIndex()
+
+}
+
+func newErasureService(database *mongo.Database, firebaseClient config.FirebaseClient) services.ErasureService {
+
+	repository := repositories.NewUserRepository(database.Collection(os.Getenv("USER_COLLECTION")))
+	webhookRepository := repositories.NewWebhookRepository(database.Collection(os.Getenv("WEBHOOK_COLLECTION")))
+	webhookDeliveryRepository := repositories.NewWebhookDeliveryRepository(database.Collection(os.Getenv("WEBHOOK_DELIVERY_COLLECTION")))
+	exportRepository := repositories.NewExportRepository(database.Collection(config.GetEnv("EXPORT_COLLECTION", "exports")))
+	erasureRepository := repositories.NewErasureRepository(database.Collection(config.GetEnv("ERASURE_COLLECTION", "erasures")))
+	outboxRepository := repositories.NewOutboxRepository(database.Collection(os.Getenv("OUTBOX_COLLECTION")))
+	auditRepository := repositories.NewAuditRepository(database.Collection(config.GetEnv("AUDIT_COLLECTION", "audit")))
+	deadLetterRepository := repositories.NewDeadLetterRepository(database.Collection(config.GetEnv("DEAD_LETTER_COLLECTION", "dead_letters")))
+	transactionRepository := repositories.NewTransactionRepository(database.Client())
+	return services.NewErasureService(repository, webhookRepository, webhookDeliveryRepository, exportRepository, erasureRepository, outboxRepository, auditRepository, deadLetterRepository, transactionRepository, firebaseClient)
+
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"os"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/jobs"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/middlewares"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/routes"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpExport(router *gin.RouterGroup, downloadRouter *gin.RouterGroup, database *mongo.Database, firebaseClient config.FirebaseClient) {
+
+	service := newExportService(database)
+	controller := controllers.NewExportController(service)
+	authorization := middlewares.Authorization(firebaseClient)
+	roleAuthorization := middlewares.RoleAuthorization(routes.GetAccessPolicy())
+	routes.RegisterExportRoutes(router, downloadRouter, authorization, roleAuthorization, controller)
+
+}
+
+func SetUpExportJob(database *mongo.Database) jobs.ExportJob {
+
+	return jobs.NewExportJob(newExportService(database))
+
+}
+
+func SetUpExportRepositoryIndexes(collection *mongo.Collection) {
+
+	repository := repositories.NewExportRepositorySetup(collection)
+	repository.MakeUserIdRequestedAtIndex()
+	repository.MakeStatusIndex()
+	repository.MakeDownloadTokenIndex()
+	repository.MakeExpiresAtTTLIndex()
+
+}
+
+func newExportService(database *mongo.Database) services.ExportService {
+
+	repository := repositories.NewUserRepository(database.Collection(os.Getenv("USER_COLLECTION")))
+	webhookRepository := repositories.NewWebhookRepository(database.Collection(os.Getenv("WEBHOOK_COLLECTION")))
+	webhookDeliveryRepository := repositories.NewWebhookDeliveryRepository(database.Collection(os.Getenv("WEBHOOK_DELIVERY_COLLECTION")))
+	exportRepository := repositories.NewExportRepository(database.Collection(config.GetEnv("EXPORT_COLLECTION", "exports")))
+	auditRepository := repositories.NewAuditRepository(database.Collection(config.GetEnv("AUDIT_COLLECTION", "audit")))
+	transactionRepository := repositories.NewTransactionRepository(database.Client())
+	return services.NewExportService(repository, webhookRepository, webhookDeliveryRepository, exportRepository, auditRepository, transactionRepository)
+
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"os"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/consumers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/jobs"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/topology"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpFCMtokenPrune(database *mongo.Database) jobs.FCMtokenPruneJob {
+
+	return jobs.NewFCMtokenPruneJob(newUserService(database))
+
+}
+
+func SetUpFCMtokenErrorConsumer(database *mongo.Database, conn config.AMQPconnection, messaging *topology.Topology) consumers.FCMtokenErrorConsumer {
+
+	return consumers.NewFCMtokenErrorConsumer(conn, messaging, newUserService(database))
+
+}
+
+func newUserService(database *mongo.Database) services.UserService {
+
+	repository := repositories.NewUserRepository(database.Collection(os.Getenv("USER_COLLECTION")))
+	webhookRepository := repositories.NewWebhookRepository(database.Collection(os.Getenv("WEBHOOK_COLLECTION")))
+	outboxRepository := repositories.NewOutboxRepository(database.Collection(os.Getenv("OUTBOX_COLLECTION")))
+	auditRepository := repositories.NewAuditRepository(database.Collection(config.GetEnv("AUDIT_COLLECTION", "audit")))
+	erasureRepository := repositories.NewErasureRepository(database.Collection(config.GetEnv("ERASURE_COLLECTION", "erasures")))
+	transactionRepository := repositories.NewTransactionRepository(database.Client())
+	return services.NewUserService(repository, webhookRepository, outboxRepository, auditRepository, erasureRepository, transactionRepository)
+
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/routes"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+func SetUpHealth(router *gin.RouterGroup, client config.MongoClient, conn config.AMQPconnection, firebaseClient config.FirebaseClient, isReady func() bool) {
+
+	service := services.NewHealthService(isReady,
+		services.HealthCheck{Name: "mongo", Check: client.Ping},
+		services.HealthCheck{Name: "amqp", Check: func(ctx context.Context) error {
+			if state := conn.State(); state != constants.AMQPconnected {
+				return fmt.Errorf("connection %s", state)
+			}
+			return nil
+		}},
+		services.HealthCheck{Name: "firebase", Check: firebaseClient.CheckCredentials},
+	)
+	controller := controllers.NewHealthController(service)
+	routes.RegisterHealthRoutes(router, controller)
+
+}
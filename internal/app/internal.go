@@ -0,0 +1,31 @@
+package app
+
+import (
+	"os"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/middlewares"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/routes"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/validations"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpInternal(router *gin.RouterGroup, client *mongo.Client, collection *mongo.Collection, webhookCollection *mongo.Collection, outboxCollection *mongo.Collection, auditCollection *mongo.Collection) {
+
+	repository := repositories.NewUserRepository(collection)
+	webhookRepository := repositories.NewWebhookRepository(webhookCollection)
+	outboxRepository := repositories.NewOutboxRepository(outboxCollection)
+	auditRepository := repositories.NewAuditRepository(auditCollection)
+	erasureRepository := repositories.NewErasureRepository(collection.Database().Collection(config.GetEnv("ERASURE_COLLECTION", "erasures")))
+	transactionRepository := repositories.NewTransactionRepository(client)
+	service := services.NewUserService(repository, webhookRepository, outboxRepository, auditRepository, erasureRepository, transactionRepository)
+	controller := controllers.NewInternalController(service)
+	validations.RegisterUserValidations()
+	authorization := middlewares.ServiceAuthorization(os.Getenv("INTERNAL_API_KEY"))
+	routes.RegisterInternalRoutes(router, authorization, controller)
+
+}
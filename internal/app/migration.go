@@ -0,0 +1,24 @@
+package app
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/migrations"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpMigrator(database *mongo.Database) migrations.Migrator {
+
+	collection := database.Collection(config.GetEnv("MIGRATION_COLLECTION", "migrations"))
+	repository := repositories.NewMigrationRepository(collection)
+	lockRepository := repositories.NewMigrationLockRepository(database.Collection(config.GetEnv("MIGRATION_LOCK_COLLECTION", "migration_locks")))
+	return migrations.NewMigrator(database, repository, lockRepository, migrations.GetMigrations())
+
+}
+
+func SetUpMigrationRepositoryIndexes(collection *mongo.Collection) {
+
+	repository := repositories.NewMigrationRepositorySetup(collection)
+	repository.MakeVersionUniqueIndex()
+
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"os"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/jobs"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/producers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpOutboxRelay(database *mongo.Database, conn config.AMQPconnection) jobs.OutboxRelayJob {
+
+	collection := database.Collection(os.Getenv("OUTBOX_COLLECTION"))
+	repository := repositories.NewOutboxRepository(collection)
+
+	producer := producers.NewOutboxProducer(producers.NewPublisher(conn))
+	return jobs.NewOutboxRelayJob(repository, producer)
+
+}
+
+func SetUpOutboxRepositoryIndexes(collection *mongo.Collection) {
+
+	repository := repositories.NewOutboxRepositorySetup(collection)
+	repository.MakeStatusNextAttemptAtIndex()
+	repository.MakeUserIdIndex()
+	repository.MakePublishedAtTTLIndex(config.GetEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour))
+
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slog"
+)
+
+// SetUpTrustedProxies has the router take the client IP from X-Forwarded-For and X-Real-IP only when the request
+// comes from one of the comma separated addresses or CIDRs of TRUSTED_PROXIES. By default no proxy is trusted,
+// so the IP recorded in the audit trail is the address of the peer and cannot be forged with a header.
+func SetUpTrustedProxies(router *gin.Engine) {
+
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+
+	err := router.SetTrustedProxies(trustedProxies)
+	if err != nil {
+		slog.Error("Invalid TRUSTED_PROXIES", "error", err)
+		panic(err)
+	}
+
+}
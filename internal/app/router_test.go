@@ -0,0 +1,51 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTrustedProxies(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies string
+		remoteAddr     string
+		ip             string
+	}{
+		{"spoofed header without trusted proxies", "", "203.0.113.7:41000", "203.0.113.7"},
+		{"spoofed header from an untrusted peer", "10.0.0.0/8", "203.0.113.7:41000", "203.0.113.7"},
+		{"header from a trusted proxy", "10.0.0.0/8, 192.168.0.1", "10.1.2.3:41000", "198.51.100.20"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", test.trustedProxies)
+
+			router := gin.New()
+			SetUpTrustedProxies(router)
+
+			var ip string
+			router.GET("/", func(c *gin.Context) {
+				ip = utils.GetActor(c).IP
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = test.remoteAddr
+			request.Header.Set("X-Forwarded-For", "198.51.100.20")
+			request.Header.Set("X-Real-IP", "198.51.100.20")
+			router.ServeHTTP(httptest.NewRecorder(), request)
+
+			if ip != test.ip {
+				t.Errorf("got IP %q, want %q", ip, test.ip)
+			}
+		})
+	}
+
+}
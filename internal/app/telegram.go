@@ -0,0 +1,30 @@
+package app
+
+import (
+	"os"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/middlewares"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/routes"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpTelegram(router *gin.RouterGroup, internalRouter *gin.RouterGroup, client *mongo.Client, collection *mongo.Collection, linkStateCollection *mongo.Collection, outboxCollection *mongo.Collection, auditCollection *mongo.Collection, firebaseClient config.FirebaseClient) {
+
+	repository := repositories.NewUserRepository(collection)
+	linkStateRepository := repositories.NewLinkStateRepository(linkStateCollection)
+	outboxRepository := repositories.NewOutboxRepository(outboxCollection)
+	auditRepository := repositories.NewAuditRepository(auditCollection)
+	transactionRepository := repositories.NewTransactionRepository(client)
+	service := services.NewTelegramService(repository, linkStateRepository, outboxRepository, auditRepository, transactionRepository)
+	controller := controllers.NewTelegramController(service)
+	authorization := middlewares.Authorization(firebaseClient)
+	roleAuthorization := middlewares.RoleAuthorization(routes.GetAccessPolicy())
+	serviceAuthorization := middlewares.ServiceAuthorization(os.Getenv("INTERNAL_API_KEY"))
+	routes.RegisterTelegramRoutes(router, internalRouter, authorization, roleAuthorization, serviceAuthorization, controller)
+
+}
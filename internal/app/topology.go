@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/topology"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"golang.org/x/exp/slog"
+)
+
+// SetUpTopology has the connection declare what the broker is missing of the topology of AMQP_TOPOLOGY_FILE,
+// or the built-in one, on every connect. The consumers look up how their queues retry in the topology it returns.
+func SetUpTopology(conn config.AMQPconnection) *topology.Topology {
+
+	t, err := topology.Load(os.Getenv("AMQP_TOPOLOGY_FILE"))
+	if err != nil {
+		panic(err)
+	}
+
+	err = conn.DeclareTopology("messaging", t.Ensure)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+
+}
+
+// ApplyTopology declares the topology on the broker of AMQP_URL.
+// With migrate set the exchanges and queues the broker has with other arguments are deleted and declared again first,
+// see topology.Migrate. With dryRun set it only logs how the broker differs from it, as read from the management API.
+func ApplyTopology(dryRun bool, migrate bool) error {
+
+	t, err := topology.Load(os.Getenv("AMQP_TOPOLOGY_FILE"))
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return diffTopology(t)
+	}
+
+	var current *topology.Topology
+	if migrate {
+		current, err = currentTopology()
+		if err != nil {
+			return err
+		}
+	}
+
+	conn, err := amqp.Dial(os.Getenv("AMQP_URL"))
+	if err != nil {
+		slog.Error("Failed to connect to RabbitMQ", "error", err)
+		return err
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		slog.Error("Failed to open a channel", "error", err)
+		return err
+	}
+	defer ch.Close()
+
+	if migrate {
+		err = t.Migrate(ch, current)
+	} else {
+		err = t.Declare(ch)
+	}
+	if err != nil {
+		slog.Error("Failed to declare topology, an entity the broker has with other arguments is declared again with -topology migrate", "error", err)
+		return err
+	}
+
+	slog.Info("Declared topology", "exchanges", len(t.Exchanges), "queues", len(t.Queues), "bindings", len(t.Bindings))
+	return nil
+
+}
+
+func diffTopology(t *topology.Topology) error {
+
+	current, err := currentTopology()
+	if err != nil {
+		return err
+	}
+
+	changes := topology.Diff(t, current)
+	for _, change := range changes {
+		switch change.Action {
+		case constants.TopologyCreate:
+			slog.Info("Would declare", "kind", change.Kind, "name", change.Name)
+		case constants.TopologyConflict:
+			slog.Warn("Differs from the broker, -topology migrate deletes and declares it again", "kind", change.Kind, "name", change.Name, "details", change.Details)
+		case constants.TopologyUnmanaged:
+			slog.Info("Not in the topology, left as it is", "kind", change.Kind, "name", change.Name)
+		}
+	}
+
+	slog.Info("Compared topology with the broker", "changes", len(changes))
+	return nil
+
+}
+
+// currentTopology reads what the broker of AMQP_URL has declared from its management API
+func currentTopology() (*topology.Topology, error) {
+
+	uri, err := amqp.ParseURI(os.Getenv("AMQP_URL"))
+	if err != nil {
+		slog.Error("Failed to parse AMQP_URL", "error", err)
+		return nil, err
+	}
+
+	// the management plugin listens on 15672 of the broker host unless told otherwise
+	managementURL := config.GetEnv("AMQP_MANAGEMENT_URL", (&url.URL{Scheme: "http", Host: net.JoinHostPort(uri.Host, "15672")}).String())
+	client := topology.NewManagementClient(managementURL, uri.Username, uri.Password, uri.Vhost)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return client.Current(ctx)
+
+}
@@ -4,7 +4,6 @@ import (
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/middlewares"
-	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/producers"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/routes"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
@@ -13,15 +12,20 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func SetUpUser(router *gin.RouterGroup, collection *mongo.Collection, conn config.AMQPconnection, firebaseClient config.FirebaseClient) {
+func SetUpUser(router *gin.RouterGroup, client *mongo.Client, collection *mongo.Collection, webhookCollection *mongo.Collection, outboxCollection *mongo.Collection, auditCollection *mongo.Collection, firebaseClient config.FirebaseClient) {
 
 	repository := repositories.NewUserRepository(collection)
-	producer := producers.NewWelcomeProducer(conn)
-	service := services.NewUserService(repository, producer)
+	webhookRepository := repositories.NewWebhookRepository(webhookCollection)
+	outboxRepository := repositories.NewOutboxRepository(outboxCollection)
+	auditRepository := repositories.NewAuditRepository(auditCollection)
+	erasureRepository := repositories.NewErasureRepository(collection.Database().Collection(config.GetEnv("ERASURE_COLLECTION", "erasures")))
+	transactionRepository := repositories.NewTransactionRepository(client)
+	service := services.NewUserService(repository, webhookRepository, outboxRepository, auditRepository, erasureRepository, transactionRepository)
 	controller := controllers.NewUserController(service)
 	validations.RegisterUserValidations()
 	authorization := middlewares.Authorization(firebaseClient)
-	routes.RegisterUserRoutes(router, authorization, controller)
+	roleAuthorization := middlewares.RoleAuthorization(routes.GetAccessPolicy())
+	routes.RegisterUserRoutes(router, authorization, roleAuthorization, controller)
 
 }
 
@@ -29,5 +33,7 @@ func SetUpUserRepositoryIndexes(collection *mongo.Collection) {
 
 	repository := repositories.NewUserRepositorySetup(collection)
 	repository.MakeUserIdUniqueIndex()
+	repository.MakeFCMtokenIndexes()
+	repository.MakeSearchIndexes()
 
 }
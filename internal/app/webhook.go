@@ -0,0 +1,60 @@
+package app
+
+import (
+	"os"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/jobs"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/middlewares"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/routes"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/validations"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpWebhook(router *gin.RouterGroup, client *mongo.Client, collection *mongo.Collection, webhookCollection *mongo.Collection, webhookDeliveryCollection *mongo.Collection, outboxCollection *mongo.Collection, auditCollection *mongo.Collection, firebaseClient config.FirebaseClient) {
+
+	repository := repositories.NewUserRepository(collection)
+	webhookRepository := repositories.NewWebhookRepository(webhookCollection)
+	webhookDeliveryRepository := repositories.NewWebhookDeliveryRepository(webhookDeliveryCollection)
+	outboxRepository := repositories.NewOutboxRepository(outboxCollection)
+	auditRepository := repositories.NewAuditRepository(auditCollection)
+	transactionRepository := repositories.NewTransactionRepository(client)
+	service := services.NewWebhookService(repository, webhookRepository, webhookDeliveryRepository, outboxRepository, auditRepository, transactionRepository, config.NewWebhookClient())
+	controller := controllers.NewWebhookController(service)
+	validations.RegisterUserValidations()
+	authorization := middlewares.Authorization(firebaseClient)
+	roleAuthorization := middlewares.RoleAuthorization(routes.GetAccessPolicy())
+	routes.RegisterWebhookRoutes(router, authorization, roleAuthorization, controller)
+
+}
+
+func SetUpWebhookVerification(database *mongo.Database) jobs.WebhookVerificationJob {
+
+	webhookRepository := repositories.NewWebhookRepository(database.Collection(os.Getenv("WEBHOOK_COLLECTION")))
+	outboxRepository := repositories.NewOutboxRepository(database.Collection(os.Getenv("OUTBOX_COLLECTION")))
+	transactionRepository := repositories.NewTransactionRepository(database.Client())
+	service := services.NewWebhookVerificationService(webhookRepository, outboxRepository, transactionRepository, config.NewWebhookClient())
+	return jobs.NewWebhookVerificationJob(service)
+
+}
+
+func SetUpWebhookRepositoryIndexes(collection *mongo.Collection) {
+
+	repository := repositories.NewWebhookRepositorySetup(collection)
+	repository.MakeUserIdIndex()
+	repository.MakeVerificationIndex()
+
+}
+
+func SetUpWebhookDeliveryRepositoryIndexes(collection *mongo.Collection) {
+
+	repository := repositories.NewWebhookDeliveryRepositorySetup(collection)
+	repository.MakeWebhookIdCreatedAtIndex()
+	repository.MakeCreatedAtTTLIndex(config.GetEnvDuration("WEBHOOK_DELIVERY_RETENTION", 30*24*time.Hour))
+
+}
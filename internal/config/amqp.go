@@ -1,45 +1,392 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"os"
+	"sync"
+	"time"
 
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"golang.org/x/exp/slog"
 )
 
+var ErrAMQPnotConnected = errors.New("not connected to RabbitMQ")
+
+// AMQPtopology declares exchanges, queues and bindings, it has to be safe to run again on every reconnect.
+// It gets the connection rather than a channel since a failed check closes the channel it ran on.
+type AMQPtopology func(conn *amqp.Connection) error
+
+// AMQPconfirmation is the broker's pending confirm of a published message
+type AMQPconfirmation interface {
+	WaitContext(ctx context.Context) (bool, error)
+}
+
+// AMQPchannel is a pooled channel in confirm mode, along with the messages the broker returned to it as unroutable
+type AMQPchannel interface {
+	PublishWithDeferredConfirmWithContext(ctx context.Context, exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) (AMQPconfirmation, error)
+	Returns() <-chan amqp.Return
+	IsClosed() bool
+	Close() error
+}
+
+type confirmChannel struct {
+	*amqp.Channel
+	returns <-chan amqp.Return
+}
+
+func (c *confirmChannel) PublishWithDeferredConfirmWithContext(ctx context.Context, exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) (AMQPconfirmation, error) {
+
+	confirmation, err := c.Channel.PublishWithDeferredConfirmWithContext(ctx, exchange, key, mandatory, immediate, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return confirmation, nil
+
+}
+
+func (c *confirmChannel) Returns() <-chan amqp.Return {
+	return c.returns
+}
+
+// AMQPconnection is a supervised connection: it reconnects whenever the broker closes it,
+// declaring the registered topology again before it is used
 type AMQPconnection interface {
 	NewChannel() (*amqp.Channel, error)
-	DisconnectAll()
+	AcquireChannel() (AMQPchannel, error)
+	ReleaseChannel(ch AMQPchannel)
+	DeclareTopology(name string, topology AMQPtopology) error
+	Connected() <-chan struct{}
+	State() constants.AMQPstate
+	DisconnectAll(ctx context.Context) error
+}
+
+type namedTopology struct {
+	name     string
+	topology AMQPtopology
 }
 
 type amqpConnection struct {
-	conn *amqp.Connection
+	url        string
+	mutex      sync.RWMutex
+	conn       *amqp.Connection
+	state      constants.AMQPstate
+	connected  chan struct{}
+	topologies []namedTopology
+	pool       chan AMQPchannel
+	closing    chan struct{}
+	done       chan struct{}
+	minBackoff time.Duration
+	maxBackoff time.Duration
 }
 
+// NewAMQPconnection starts connecting in the background, a broker that is down at startup is retried like any other disconnect
 func NewAMQPconnection() AMQPconnection {
 
-	conn, err := amqp.Dial(os.Getenv("AMQP_URL"))
+	a := &amqpConnection{
+		url:        os.Getenv("AMQP_URL"),
+		state:      constants.AMQPconnecting,
+		connected:  make(chan struct{}),
+		pool:       make(chan AMQPchannel, GetEnvInt("AMQP_CHANNEL_POOL_SIZE", 8)),
+		closing:    make(chan struct{}),
+		done:       make(chan struct{}),
+		minBackoff: GetEnvDuration("AMQP_RECONNECT_MIN_BACKOFF", time.Second),
+		maxBackoff: GetEnvDuration("AMQP_RECONNECT_MAX_BACKOFF", 30*time.Second),
+	}
+
+	go a.supervise()
+	return a
+
+}
+
+// supervise keeps the connection up, backing off exponentially between failed attempts until DisconnectAll
+func (a *amqpConnection) supervise() {
+
+	defer close(a.done)
+
+	backoff := a.minBackoff
+	for {
+		closed, err := a.connect()
+		if err != nil {
+			slog.Error("Failed to connect to RabbitMQ, retrying", "error", err, "backoff", backoff)
+
+			select {
+			case <-a.closing:
+				return
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			if backoff > a.maxBackoff {
+				backoff = a.maxBackoff
+			}
+			continue
+		}
+
+		backoff = a.minBackoff
+		slog.Info("Connected to RabbitMQ")
+
+		closeErr := <-closed
+		select {
+		case <-a.closing:
+			return
+		default:
+		}
+
+		slog.Error("Lost the connection to RabbitMQ, reconnecting", "error", closeErr)
+		a.disconnected()
+	}
+
+}
+
+// connect dials and declares the topology before the connection is handed out,
+// returning the channel told when the connection closes
+func (a *amqpConnection) connect() (chan *amqp.Error, error) {
+
+	conn, err := amqp.Dial(a.url)
 	if err != nil {
-		slog.Error("Failed to connect to RabbitMQ", "error", err)
-		panic(err)
+		return nil, err
 	}
 
-	return &amqpConnection{conn}
+	// the topology is declared over the network without holding the lock, so the state and the pool stay
+	// readable meanwhile. A topology registered in the meantime is not declared by DeclareTopology since there
+	// is no connection yet, so it is picked up here before the connection is handed out.
+	declared := 0
+	for {
+		a.mutex.RLock()
+		pending := a.topologies[declared:]
+		a.mutex.RUnlock()
+
+		for _, topology := range pending {
+			err = declare(conn, topology)
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+			declared++
+		}
+
+		a.mutex.Lock()
+		if declared == len(a.topologies) {
+			break
+		}
+		a.mutex.Unlock()
+	}
+	defer a.mutex.Unlock()
+
+	select {
+	case <-a.closing:
+		conn.Close()
+		return nil, ErrAMQPnotConnected
+	default:
+	}
+
+	// registered before the connection is handed out so a close right away is not missed
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	a.conn = conn
+	a.state = constants.AMQPconnected
+	close(a.connected)
+	return closed, nil
 
 }
 
+func (a *amqpConnection) disconnected() {
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.conn = nil
+	a.state = constants.AMQPconnecting
+	a.connected = make(chan struct{})
+	a.drainPool()
+
+}
+
+// NewChannel opens a channel of its own, for consumers that set their own prefetch and close it when they stop
 func (a *amqpConnection) NewChannel() (*amqp.Channel, error) {
 
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	if a.conn == nil {
+		return nil, ErrAMQPnotConnected
+	}
+
 	return a.conn.Channel()
 
 }
 
-func (a *amqpConnection) DisconnectAll() {
+// AcquireChannel hands out a pooled channel in confirm mode, to be given back with ReleaseChannel
+func (a *amqpConnection) AcquireChannel() (AMQPchannel, error) {
 
-	err := a.conn.Close()
+	for {
+		select {
+		case ch := <-a.pool:
+			if !ch.IsClosed() {
+				return ch, nil
+			}
+		default:
+			return a.newConfirmChannel()
+		}
+	}
+
+}
+
+func (a *amqpConnection) newConfirmChannel() (AMQPchannel, error) {
+
+	ch, err := a.NewChannel()
 	if err != nil {
-		slog.Error("Failed to disconnet from RabbitMQ", "error", err)
-		panic(err)
+		return nil, err
+	}
+
+	err = ch.Confirm(false)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	// a return is dispatched before the ack of its message, one slot is enough as long as every return is read after the ack
+	returns := ch.NotifyReturn(make(chan amqp.Return, 1))
+
+	return &confirmChannel{Channel: ch, returns: returns}, nil
+
+}
+
+// ReleaseChannel puts the channel back into the pool, or closes it when the pool is full.
+// A channel that failed is closed by the broker and simply dropped, and one holding an unread return is closed
+// so that the return is neither taken for the next message's nor blocks the connection once the next one comes in.
+func (a *amqpConnection) ReleaseChannel(ch AMQPchannel) {
+
+	if ch.IsClosed() {
+		return
+	}
+
+	if len(ch.Returns()) > 0 {
+		ch.Close()
+		return
+	}
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	if a.conn == nil {
+		ch.Close()
+		return
+	}
+
+	select {
+	case a.pool <- ch:
+	default:
+		ch.Close()
+	}
+
+}
+
+// DeclareTopology registers the topology to be declared on every connect and declares it right away when connected
+func (a *amqpConnection) DeclareTopology(name string, topology AMQPtopology) error {
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	named := namedTopology{name: name, topology: topology}
+	a.topologies = append(a.topologies, named)
+
+	if a.conn == nil {
+		return nil
+	}
+
+	return declare(a.conn, named)
+
+}
+
+func declare(conn *amqp.Connection, topology namedTopology) error {
+
+	err := topology.topology(conn)
+	if err != nil {
+		slog.Error("Failed to declare topology", "error", err, "topology", topology.name)
+		return err
+	}
+
+	slog.Debug("Declared topology", "topology", topology.name)
+	return nil
+
+}
+
+// Connected is closed once the connection is up, a new one is handed out after each disconnect
+func (a *amqpConnection) Connected() <-chan struct{} {
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return a.connected
+
+}
+
+func (a *amqpConnection) State() constants.AMQPstate {
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return a.state
+
+}
+
+// DisconnectAll stops reconnecting and closes the connection and with it every channel, waiting at most until ctx is done
+func (a *amqpConnection) DisconnectAll(ctx context.Context) error {
+
+	a.mutex.Lock()
+	select {
+	case <-a.closing:
+		a.mutex.Unlock()
+		return nil
+	default:
+	}
+	close(a.closing)
+
+	conn := a.conn
+	a.conn = nil
+	a.state = constants.AMQPclosed
+	a.drainPool()
+	a.mutex.Unlock()
+
+	if conn != nil {
+		var err error
+		if deadline, ok := ctx.Deadline(); ok {
+			err = conn.CloseDeadline(deadline)
+		} else {
+			err = conn.Close()
+		}
+
+		if err != nil && !errors.Is(err, amqp.ErrClosed) {
+			slog.Error("Failed to disconnect from RabbitMQ", "error", err)
+			return err
+		}
+	}
+
+	select {
+	case <-a.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	slog.Info("Disconnected from RabbitMQ")
+	return nil
+
+}
+
+// drainPool closes the pooled channels, the caller holds the lock
+func (a *amqpConnection) drainPool() {
+
+	for {
+		select {
+		case ch := <-a.pool:
+			ch.Close()
+		default:
+			return
+		}
 	}
 
 }
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type fakeChannel struct {
+	returns chan amqp.Return
+	closed  bool
+}
+
+func (c *fakeChannel) PublishWithDeferredConfirmWithContext(ctx context.Context, exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) (AMQPconfirmation, error) {
+	return nil, nil
+}
+
+func (c *fakeChannel) Returns() <-chan amqp.Return {
+	return c.returns
+}
+
+func (c *fakeChannel) IsClosed() bool {
+	return c.closed
+}
+
+func (c *fakeChannel) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestReleaseChannel(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		connected bool
+		closed    bool
+		returned  bool
+		pooled    bool
+	}{
+		{name: "pooled", connected: true, pooled: true},
+		{name: "holding an unread return", connected: true, returned: true},
+		{name: "closed by the broker", connected: true, closed: true},
+		{name: "disconnected", connected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := &amqpConnection{pool: make(chan AMQPchannel, 1)}
+			if test.connected {
+				a.conn = &amqp.Connection{}
+			}
+			ch := &fakeChannel{returns: make(chan amqp.Return, 1), closed: test.closed}
+			if test.returned {
+				ch.returns <- amqp.Return{ReplyCode: amqp.NoRoute}
+			}
+
+			a.ReleaseChannel(ch)
+
+			if pooled := len(a.pool) == 1; pooled != test.pooled {
+				t.Errorf("got pooled %v, want %v", pooled, test.pooled)
+			}
+			if !test.pooled && !ch.closed {
+				t.Error("got the channel left open, want it closed")
+			}
+		})
+	}
+
+}
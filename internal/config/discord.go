@@ -0,0 +1,101 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/exp/slog"
+	"golang.org/x/oauth2"
+)
+
+// ErrDiscordUnavailable wraps every failure of the calls to Discord, whether it refused the code or did not answer properly
+var ErrDiscordUnavailable = errors.New("discord request failed")
+
+type DiscordOAuthClient interface {
+	AuthCodeURL(state string) string
+	Exchange(ctx context.Context, code string) (string, string, error)
+}
+
+type discordOAuthClient struct {
+	config  *oauth2.Config
+	baseURL string
+}
+
+// NewDiscordOAuthClient reads DISCORD_API_BASE_URL so that a stand-in OAuth server can replace Discord
+func NewDiscordOAuthClient() DiscordOAuthClient {
+
+	baseURL := strings.TrimSuffix(GetEnv("DISCORD_API_BASE_URL", "https://discord.com/api"), "/")
+
+	return &discordOAuthClient{
+		config: &oauth2.Config{
+			ClientID:     os.Getenv("DISCORD_CLIENT_ID"),
+			ClientSecret: os.Getenv("DISCORD_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("DISCORD_REDIRECT_URL"),
+			Scopes:       []string{"identify"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:   baseURL + "/oauth2/authorize",
+				TokenURL:  baseURL + "/oauth2/token",
+				AuthStyle: oauth2.AuthStyleInParams,
+			},
+		},
+		baseURL: baseURL,
+	}
+
+}
+
+func (c *discordOAuthClient) AuthCodeURL(state string) string {
+
+	return c.config.AuthCodeURL(state, oauth2.SetAuthURLParam("prompt", "consent"))
+
+}
+
+// Exchange trades the authorization code for a token and returns the id and username of the Discord account it belongs to
+func (c *discordOAuthClient) Exchange(ctx context.Context, code string) (string, string, error) {
+
+	token, err := c.config.Exchange(ctx, code)
+	if err != nil {
+		slog.Error("error exchanging Discord authorization code", "error", err)
+		return "", "", fmt.Errorf("%w: %s", ErrDiscordUnavailable, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/users/@me", nil)
+	if err != nil {
+		slog.Error("error creating Discord user request", "error", err)
+		return "", "", err
+	}
+
+	resp, err := c.config.Client(ctx, token).Do(req)
+	if err != nil {
+		slog.Error("error getting Discord user", "error", err)
+		return "", "", fmt.Errorf("%w: %s", ErrDiscordUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		slog.Error("error getting Discord user", "status", resp.StatusCode)
+		return "", "", fmt.Errorf("%w: user request failed with status %d", ErrDiscordUnavailable, resp.StatusCode)
+	}
+
+	var discordUser struct {
+		Id       string `json:"id"`
+		Username string `json:"username"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&discordUser)
+	if err != nil {
+		slog.Error("error decoding Discord user", "error", err)
+		return "", "", fmt.Errorf("%w: %s", ErrDiscordUnavailable, err)
+	}
+
+	if discordUser.Id == "" {
+		slog.Error("Discord user without id")
+		return "", "", fmt.Errorf("%w: user response has no id", ErrDiscordUnavailable)
+	}
+
+	return discordUser.Id, discordUser.Username, nil
+
+}
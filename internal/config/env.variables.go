@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 	"golang.org/x/exp/slog"
@@ -40,3 +42,23 @@ func LoadEnvVariables() {
 	}
 
 }
+
+// GetEnvInt returns an environment variable parsed as an int or a default value if not present or invalid
+func GetEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// GetEnvDuration returns an environment variable parsed as a time.Duration or a default value if not present or invalid
+func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
@@ -2,17 +2,30 @@ package config
 
 import (
 	"context"
+	"errors"
 	"os"
 
 	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/auth"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
 	"golang.org/x/exp/slog"
 	"google.golang.org/api/option"
 )
 
 type FirebaseClient interface {
-	VerifyIDToken(ctx context.Context, idToken string) (string, error)
+	VerifyIDToken(ctx context.Context, idToken string) (*models.FirebaseIdentity, error)
+	DeleteUser(ctx context.Context, uid string) error
+	CheckCredentials(ctx context.Context) error
 }
 
+// reservedClaims are set by Firebase itself, every other claim in the token is a custom claim
+var reservedClaims = map[string]struct{}{
+	"iss": {}, "aud": {}, "auth_time": {}, "user_id": {}, "sub": {}, "iat": {}, "exp": {},
+	"email": {}, "email_verified": {}, "phone_number": {}, "name": {}, "picture": {}, "firebase": {}, "uid": {},
+}
+
+var errFirebaseNotInitialized = errors.New("firebase app not initialized")
+
 type firebaseClient struct {
 	app *firebase.App
 }
@@ -28,20 +41,67 @@ func NewFirebaseClient() FirebaseClient {
 	return &firebaseClient{app}
 }
 
-func (c *firebaseClient) VerifyIDToken(ctx context.Context, idToken string) (string, error) {
+func (c *firebaseClient) VerifyIDToken(ctx context.Context, idToken string) (*models.FirebaseIdentity, error) {
 
 	client, err := c.app.Auth(ctx)
 	if err != nil {
 		slog.Error("error getting Auth client", "error", err)
-		return "", err
+		return nil, err
 	}
 
 	token, err := client.VerifyIDToken(ctx, idToken)
 	if err != nil {
 		slog.Error("error verifying ID token", "error", err)
-		return "", err
+		return nil, err
+	}
+
+	identity := &models.FirebaseIdentity{
+		UID:            token.UID,
+		SignInProvider: token.Firebase.SignInProvider,
+		CustomClaims:   map[string]interface{}{},
+	}
+	identity.Email, _ = token.Claims["email"].(string)
+	identity.EmailVerified, _ = token.Claims["email_verified"].(bool)
+	identity.DisplayName, _ = token.Claims["name"].(string)
+	identity.PhotoURL, _ = token.Claims["picture"].(string)
+
+	for claim, value := range token.Claims {
+		if _, ok := reservedClaims[claim]; !ok {
+			identity.CustomClaims[claim] = value
+		}
+	}
+
+	return identity, nil
+
+}
+
+// DeleteUser deletes the Firebase account, an account that is already gone counts as deleted
+func (c *firebaseClient) DeleteUser(ctx context.Context, uid string) error {
+
+	client, err := c.app.Auth(ctx)
+	if err != nil {
+		slog.Error("error getting Auth client", "error", err)
+		return err
+	}
+
+	err = client.DeleteUser(ctx, uid)
+	if err != nil && !auth.IsUserNotFound(err) {
+		slog.Error("error deleting user", "error", err, "uid", uid)
+		return err
+	}
+
+	return nil
+
+}
+
+// CheckCredentials fails when the service account key could not be loaded, without it no token can be verified
+func (c *firebaseClient) CheckCredentials(ctx context.Context) error {
+
+	if c.app == nil {
+		return errFirebaseNotInitialized
 	}
 
-	return token.UID, nil
+	_, err := c.app.Auth(ctx)
+	return err
 
 }
@@ -2,17 +2,23 @@ package config
 
 import (
 	"context"
+	"errors"
 	"os"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"golang.org/x/exp/slog"
 )
 
+var ErrNoTransactions = errors.New("MONGO_URI has to point at a replica set or a sharded cluster")
+
 type MongoClient interface {
 	ConnectToDB() *mongo.Database
-	Disconnect()
+	Ping(ctx context.Context) error
+	Disconnect(ctx context.Context) error
 }
 
 type mongoClient struct {
@@ -36,26 +42,63 @@ func NewMongoClient() MongoClient { // *  v.v.v.imp MongoClient and not *mongoCl
 		panic(err)
 	}
 
+	err = requireTransactions(ctx, client)
+	if err != nil {
+		slog.Error("MongoDB does not support transactions", "error", err)
+		panic(err)
+	}
+
 	return &mongoClient{
 		client: client,
 	}
 }
 
+// requireTransactions fails on a standalone server, the outbox and the other multi-document writes run in
+// transactions and these are only available on a replica set or a sharded cluster
+func requireTransactions(ctx context.Context, client *mongo.Client) error {
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return err
+	}
+
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		return ErrNoTransactions
+	}
+
+	return nil
+
+}
+
 func (m *mongoClient) ConnectToDB() *mongo.Database {
 
 	return m.client.Database(os.Getenv("MONGO_DB"))
 
 }
 
-func (m *mongoClient) Disconnect() {
+func (m *mongoClient) Ping(ctx context.Context) error {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return m.client.Ping(ctx, readpref.Primary())
+
+}
+
+// Disconnect closes the client, waiting at most until ctx is done or 10 seconds for the operations in flight
+func (m *mongoClient) Disconnect(ctx context.Context) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	err := m.client.Disconnect(ctx)
-	if err != nil {
+	if err != nil && !errors.Is(err, mongo.ErrClientDisconnected) {
 		slog.Error("Failed to disconnect from MongoDB", "error", err)
-		panic(err)
+		return err
 	}
 
+	slog.Info("Disconnected from MongoDB")
+	return nil
+
 }
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+)
+
+var ErrNonPublicAddress = errors.New("webhook address is not public")
+
+type WebhookResponse struct {
+	StatusCode int
+	Body       []byte
+	Latency    time.Duration
+}
+
+type WebhookClient interface {
+	Post(ctx context.Context, url string, header http.Header, body []byte) (*WebhookResponse, error)
+}
+
+type webhookClient struct {
+	client       *http.Client
+	maxBodyBytes int64
+}
+
+// NewWebhookClient returns a client for calling user supplied URLs.
+// Every connection is checked after DNS resolution so a hostname cannot point it at internal addresses,
+// redirects are not followed and proxies from the environment are ignored.
+func NewWebhookClient() WebhookClient {
+
+	timeout := GetEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second)
+
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !utils.IsPublicIP(net.ParseIP(host)) {
+				return fmt.Errorf("%w: %s", ErrNonPublicAddress, host)
+			}
+			return nil
+		},
+	}
+
+	return &webhookClient{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy:                 nil,
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   5 * time.Second,
+				ResponseHeaderTimeout: timeout,
+				MaxIdleConns:          10,
+				IdleConnTimeout:       30 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxBodyBytes: int64(GetEnvInt("WEBHOOK_MAX_RESPONSE_BYTES", 4096)),
+	}
+
+}
+
+// Post sends body to url and reads at most WEBHOOK_MAX_RESPONSE_BYTES of the response
+func (c *webhookClient) Post(ctx context.Context, url string, header http.Header, body []byte) (*WebhookResponse, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "VQE-Webhooks/1.0")
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(resp.Body, c.maxBodyBytes))
+	latency := time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookResponse{
+		StatusCode: resp.StatusCode,
+		Body:       responseBody,
+		Latency:    latency,
+	}, nil
+
+}
@@ -0,0 +1,37 @@
+package constants
+
+type AMQPstate string
+
+const (
+	AMQPconnecting AMQPstate = "connecting"
+	AMQPconnected  AMQPstate = "connected"
+	AMQPclosed     AMQPstate = "closed"
+)
+
+func (s AMQPstate) String() string {
+	return string(s)
+}
+
+// TopologyChange is what declaring the topology would do to an exchange, queue or binding on the broker
+type TopologyChange string
+
+const (
+	TopologyCreate    TopologyChange = "create"
+	TopologyConflict  TopologyChange = "conflict"
+	TopologyUnmanaged TopologyChange = "unmanaged"
+)
+
+func (c TopologyChange) String() string {
+	return string(c)
+}
+
+// Headers a consumer sets when it hands a message to a retry queue, the original exchange and routing key are kept from the first retry
+const (
+	AttemptsHeader           = "x-attempts"
+	MaxAttemptsHeader        = "x-max-attempts"
+	OriginalExchangeHeader   = "x-original-exchange"
+	OriginalRoutingKeyHeader = "x-original-routing-key"
+)
+
+// UserIdHeader names the user an outbox message is about, so that its dead letter can be erased along with the user
+const UserIdHeader = "x-user-id"
@@ -0,0 +1,16 @@
+package constants
+
+type DeadLetterStatus string
+
+const (
+	DeadLetterPending  DeadLetterStatus = "pending"
+	DeadLetterReplayed DeadLetterStatus = "replayed"
+)
+
+func (s DeadLetterStatus) String() string {
+	return string(s)
+}
+
+// DeadLetterUnroutable is the reason of a message that reached a dead-letter queue through an alternate exchange,
+// RabbitMQ gives the reason of the other ones in the x-death header
+const DeadLetterUnroutable = "unroutable"
@@ -0,0 +1,30 @@
+package constants
+
+type ErasureStatus string
+
+const (
+	ErasureScheduled ErasureStatus = "scheduled"
+	ErasureCancelled ErasureStatus = "cancelled"
+	ErasureErasing   ErasureStatus = "erasing"
+	ErasureErased    ErasureStatus = "erased"
+	ErasureCompleted ErasureStatus = "completed"
+)
+
+func (s ErasureStatus) String() string {
+	return string(s)
+}
+
+type ErasureAcknowledgementStatus string
+
+const (
+	ErasureAcknowledgementPending ErasureAcknowledgementStatus = "pending"
+	ErasureAcknowledgementErased  ErasureAcknowledgementStatus = "erased"
+	ErasureAcknowledgementFailed  ErasureAcknowledgementStatus = "failed"
+)
+
+func (s ErasureAcknowledgementStatus) String() string {
+	return string(s)
+}
+
+// FirebaseErasureService acknowledges the deletion of the Firebase account, which this service does itself
+const FirebaseErasureService = "firebase"
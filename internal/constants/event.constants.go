@@ -0,0 +1,19 @@
+package constants
+
+type UserEventType string
+
+const (
+	UserCreated UserEventType = "user.created"
+	UserUpdated UserEventType = "user.updated"
+	UserDeleted UserEventType = "user.deleted"
+
+	// UserErasureRequested asks every service holding data about the user to erase it and acknowledge on the erasure reply queue
+	UserErasureRequested UserEventType = "user.erasure_requested"
+)
+
+// UserEventVersion is bumped whenever the user event envelope changes in a way consumers have to handle
+const UserEventVersion = 1
+
+func (t UserEventType) String() string {
+	return string(t)
+}
@@ -0,0 +1,25 @@
+package constants
+
+type ExportStatus string
+
+const (
+	ExportPending    ExportStatus = "pending"
+	ExportProcessing ExportStatus = "processing"
+	ExportReady      ExportStatus = "ready"
+	ExportFailed     ExportStatus = "failed"
+)
+
+func (s ExportStatus) String() string {
+	return string(s)
+}
+
+type ExportFormat string
+
+const (
+	ExportJSON ExportFormat = "json"
+	ExportZIP  ExportFormat = "zip"
+)
+
+func (f ExportFormat) String() string {
+	return string(f)
+}
@@ -0,0 +1,29 @@
+package constants
+
+type FCMplatform string
+
+const (
+	Web     FCMplatform = "web"
+	Android FCMplatform = "android"
+	IOS     FCMplatform = "ios"
+)
+
+func (p FCMplatform) String() string {
+	return string(p)
+}
+
+func GetFCMplatforms() [3]FCMplatform {
+	return [...]FCMplatform{Web, Android, IOS}
+}
+
+func GetFCMplatformSet() map[FCMplatform]struct{} {
+	platforms := GetFCMplatforms()
+	platformSet := make(map[FCMplatform]struct{})
+	for _, platform := range platforms {
+		platformSet[platform] = struct{}{}
+	}
+	return platformSet
+}
+
+// FCMunregistered is the error the push sender reports for tokens FCM no longer accepts
+const FCMunregistered = "UNREGISTERED"
@@ -0,0 +1,12 @@
+package constants
+
+type HealthStatus string
+
+const (
+	HealthUp   HealthStatus = "up"
+	HealthDown HealthStatus = "down"
+)
+
+func (s HealthStatus) String() string {
+	return string(s)
+}
@@ -0,0 +1,31 @@
+package constants
+
+type NotificationEventType string
+
+const (
+	EnhancementCompleted NotificationEventType = "enhancement.completed"
+	EnhancementFailed    NotificationEventType = "enhancement.failed"
+	Marketing            NotificationEventType = "marketing"
+)
+
+func (t NotificationEventType) String() string {
+	return string(t)
+}
+
+func GetNotificationEventTypes() [3]NotificationEventType {
+	return [...]NotificationEventType{EnhancementCompleted, EnhancementFailed, Marketing}
+}
+
+func GetNotificationEventTypeSet() map[NotificationEventType]struct{} {
+	notificationEventTypes := GetNotificationEventTypes()
+	notificationEventTypesSet := make(map[NotificationEventType]struct{})
+	for _, notificationEventType := range notificationEventTypes {
+		notificationEventTypesSet[notificationEventType] = struct{}{}
+	}
+	return notificationEventTypesSet
+}
+
+// IsOptIn reports whether the event type is only delivered to users who explicitly set a preference for it
+func (t NotificationEventType) IsOptIn() bool {
+	return t == Marketing
+}
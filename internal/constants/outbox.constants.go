@@ -0,0 +1,13 @@
+package constants
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxPublished OutboxStatus = "published"
+	OutboxParked    OutboxStatus = "parked"
+)
+
+func (s OutboxStatus) String() string {
+	return string(s)
+}
@@ -0,0 +1,32 @@
+package constants
+
+type Role string
+
+const (
+	// RoleUser is held by every authenticated caller and only grants access to their own account
+	RoleUser    Role = "user"
+	RoleSupport Role = "support"
+	RoleService Role = "service"
+	// RoleAdmin satisfies every role requirement
+	RoleAdmin Role = "admin"
+)
+
+func (r Role) String() string {
+	return string(r)
+}
+
+func GetRoles() [4]Role {
+	return [...]Role{RoleUser, RoleSupport, RoleService, RoleAdmin}
+}
+
+func GetRoleSet() map[Role]struct{} {
+	roles := GetRoles()
+	roleSet := make(map[Role]struct{})
+	for _, role := range roles {
+		roleSet[role] = struct{}{}
+	}
+	return roleSet
+}
+
+// RolesClaim is the Firebase custom claim holding the caller's roles, either a list or a single role
+const RolesClaim = "roles"
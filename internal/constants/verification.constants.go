@@ -0,0 +1,12 @@
+package constants
+
+type VerificationStatus string
+
+const (
+	VerificationPending  VerificationStatus = "pending"
+	VerificationVerified VerificationStatus = "verified"
+)
+
+func (s VerificationStatus) String() string {
+	return string(s)
+}
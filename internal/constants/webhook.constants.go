@@ -0,0 +1,20 @@
+package constants
+
+const (
+	WebhookSignatureHeader    = "X-VQE-Signature"
+	WebhookSignatureAlgorithm = "HMAC-SHA256"
+	WebhookEventHeader        = "X-VQE-Event"
+	WebhookVerificationEvent  = "webhook.verification"
+)
+
+type WebhookVerificationStatus string
+
+const (
+	WebhookVerificationPending  WebhookVerificationStatus = "pending"
+	WebhookVerificationVerified WebhookVerificationStatus = "verified"
+	WebhookVerificationFailed   WebhookVerificationStatus = "failed"
+)
+
+func (s WebhookVerificationStatus) String() string {
+	return string(s)
+}
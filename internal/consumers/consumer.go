@@ -0,0 +1,214 @@
+package consumers
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/producers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/topology"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"golang.org/x/exp/slog"
+)
+
+// consumerTag names the consumer after the queue and host so it can be told apart in the management UI
+func consumerTag(queue string) string {
+	hostname, _ := os.Hostname()
+	return queue + "-" + hostname
+}
+
+// queueConsumer consumes a durable queue with manual acks and subscribes again whenever the connection comes back
+type queueConsumer struct {
+	conn          config.AMQPconnection
+	publisher     producers.Publisher
+	name          string
+	queue         string
+	prefetch      int
+	handle        func(delivery amqp.Delivery)
+	retries       *topology.Retry
+	retryInterval time.Duration
+	mutex         sync.Mutex
+	ch            *amqp.Channel
+	stop          chan struct{}
+	done          chan struct{}
+}
+
+func newQueueConsumer(conn config.AMQPconnection, messaging *topology.Topology, name string, queue string, prefetch int, handle func(delivery amqp.Delivery)) *queueConsumer {
+	return &queueConsumer{
+		conn:          conn,
+		publisher:     producers.NewPublisher(conn),
+		name:          name,
+		queue:         queue,
+		prefetch:      prefetch,
+		handle:        handle,
+		retries:       messaging.RetryOf(queue),
+		retryInterval: config.GetEnvDuration("AMQP_CONSUMER_RETRY_INTERVAL", 5*time.Second),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Start consumes the queue in the background, it is declared with the rest of the topology.
+// A broker that is down is not an error, consuming starts once it is reachable.
+func (consumer *queueConsumer) Start() {
+
+	go consumer.run()
+
+	slog.Info("Started consumer", "consumer", consumer.name, "queue", consumer.queue)
+
+}
+
+// Stop closes the channel, which ends the deliveries after the one being handled
+func (consumer *queueConsumer) Stop() {
+
+	close(consumer.stop)
+
+	consumer.mutex.Lock()
+	if consumer.ch != nil {
+		consumer.ch.Close()
+	}
+	consumer.mutex.Unlock()
+
+	<-consumer.done
+
+	slog.Info("Stopped consumer", "consumer", consumer.name, "queue", consumer.queue)
+
+}
+
+// run handles deliveries until the channel closes, then waits for the connection and subscribes again
+func (consumer *queueConsumer) run() {
+
+	defer close(consumer.done)
+
+	for {
+		select {
+		case <-consumer.stop:
+			return
+		case <-consumer.conn.Connected():
+		}
+
+		deliveries, err := consumer.subscribe()
+		if err != nil {
+			slog.Error("Failed to consume a queue, retrying", "error", err, "queue", consumer.queue, "retryInterval", consumer.retryInterval)
+		} else {
+			for delivery := range deliveries {
+				consumer.handle(delivery)
+			}
+		}
+
+		// the connection may not have noticed the close yet, waiting keeps this from spinning
+		select {
+		case <-consumer.stop:
+			return
+		case <-time.After(consumer.retryInterval):
+		}
+	}
+
+}
+
+func (consumer *queueConsumer) subscribe() (<-chan amqp.Delivery, error) {
+
+	consumer.mutex.Lock()
+	defer consumer.mutex.Unlock()
+
+	select {
+	case <-consumer.stop:
+		return nil, config.ErrAMQPnotConnected
+	default:
+	}
+
+	ch, err := consumer.conn.NewChannel()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ch.Qos(consumer.prefetch, 0, false)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	deliveries, err := ch.Consume(
+		consumer.queue,              // queue
+		consumerTag(consumer.queue), // consumer
+		false,                       // auto-ack
+		false,                       // exclusive
+		false,                       // no-local
+		false,                       // no-wait
+		nil,                         // args
+	)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	consumer.ch = ch
+	slog.Debug("Subscribed to queue", "consumer", consumer.name, "queue", consumer.queue)
+	return deliveries, nil
+
+}
+
+// retry hands the delivery to the retry queue of its attempt, which delays it before it comes back to the queue.
+// Once the attempts are used up, or when the queue does not retry, the delivery is dead-lettered instead.
+func (consumer *queueConsumer) retry(delivery amqp.Delivery) {
+
+	attempts := headerInt(delivery.Headers, constants.AttemptsHeader) + 1
+	if consumer.retries == nil || attempts >= consumer.retries.MaxAttempts() {
+		slog.Warn("Dead-lettered message", "queue", consumer.queue, "messageId", delivery.MessageId, "attempts", attempts)
+		delivery.Nack(false, false)
+		return
+	}
+
+	headers := amqp.Table{}
+	for key, value := range delivery.Headers {
+		headers[key] = value
+	}
+	headers[constants.AttemptsHeader] = int64(attempts)
+	headers[constants.MaxAttemptsHeader] = int64(consumer.retries.MaxAttempts())
+	if _, ok := headers[constants.OriginalExchangeHeader]; !ok {
+		headers[constants.OriginalExchangeHeader] = delivery.Exchange
+		headers[constants.OriginalRoutingKeyHeader] = delivery.RoutingKey
+	}
+
+	retryQueue := topology.RetryQueue(consumer.queue, attempts)
+	err := consumer.publisher.Publish(context.Background(), "", retryQueue, amqp.Publishing{
+		Headers:       headers,
+		ContentType:   delivery.ContentType,
+		MessageId:     delivery.MessageId,
+		CorrelationId: delivery.CorrelationId,
+		Timestamp:     delivery.Timestamp,
+		Body:          delivery.Body,
+	})
+	if err != nil {
+		// requeued rather than acked, the message must not be lost because the retry could not be scheduled
+		delivery.Nack(false, true)
+		return
+	}
+
+	slog.Info("Scheduled message for retry", "queue", consumer.queue, "retryQueue", retryQueue, "messageId", delivery.MessageId, "attempts", attempts)
+	delivery.Ack(false)
+
+}
+
+// headerInt reads a whole number header, whichever integer type the publisher encoded it as
+func headerInt(headers amqp.Table, key string) int {
+
+	switch value := headers[key].(type) {
+	case int:
+		return value
+	case int8:
+		return int(value)
+	case int16:
+		return int(value)
+	case int32:
+		return int(value)
+	case int64:
+		return int(value)
+	default:
+		return 0
+	}
+
+}
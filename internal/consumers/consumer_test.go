@@ -0,0 +1,100 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/topology"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// fakeAcknowledger records how a delivery was settled
+type fakeAcknowledger struct {
+	acked   bool
+	nacked  bool
+	requeue bool
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.acked = true
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	a.nacked = true
+	a.requeue = requeue
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+type fakePublisher struct {
+	err        error
+	routingKey string
+	publishing amqp.Publishing
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, exchange string, routingKey string, publishing amqp.Publishing) error {
+	p.routingKey = routingKey
+	p.publishing = publishing
+	return p.err
+}
+
+func TestRetryCountsAttempts(t *testing.T) {
+
+	retries := &topology.Retry{Delays: []topology.Duration{1, 2}}
+
+	tests := []struct {
+		name       string
+		retries    *topology.Retry
+		headers    amqp.Table
+		publishErr error
+		retryQueue string
+		attempts   int64
+		requeue    bool
+	}{
+		{name: "first failure", retries: retries, headers: amqp.Table{}, retryQueue: "work.retry.1", attempts: 1},
+		{name: "attempts as int32", retries: retries, headers: amqp.Table{constants.AttemptsHeader: int32(1)}, retryQueue: "work.retry.2", attempts: 2},
+		{name: "attempts used up", retries: retries, headers: amqp.Table{constants.AttemptsHeader: int64(2)}},
+		{name: "queue without retries", headers: amqp.Table{}},
+		{name: "retry not scheduled", retries: retries, headers: amqp.Table{}, publishErr: errors.New("closed"), retryQueue: "work.retry.1", attempts: 1, requeue: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			publisher := &fakePublisher{err: test.publishErr}
+			consumer := &queueConsumer{publisher: publisher, queue: "work", retries: test.retries}
+			acknowledger := &fakeAcknowledger{}
+
+			consumer.retry(amqp.Delivery{Acknowledger: acknowledger, Exchange: "events", RoutingKey: "user.created", Headers: test.headers})
+
+			if publisher.routingKey != test.retryQueue {
+				t.Errorf("published to %q, want %q", publisher.routingKey, test.retryQueue)
+			}
+			if test.retryQueue == "" {
+				if !acknowledger.nacked || acknowledger.requeue {
+					t.Error("message not dead-lettered")
+				}
+				return
+			}
+
+			if got := publisher.publishing.Headers[constants.AttemptsHeader]; got != test.attempts {
+				t.Errorf("got %v attempts, want %d", got, test.attempts)
+			}
+			if got := publisher.publishing.Headers[constants.MaxAttemptsHeader]; got != int64(3) {
+				t.Errorf("got %v max attempts, want 3", got)
+			}
+			if publisher.publishing.Headers[constants.OriginalRoutingKeyHeader] != "user.created" {
+				t.Errorf("original routing key not kept: %v", publisher.publishing.Headers)
+			}
+			if test.requeue != (acknowledger.nacked && acknowledger.requeue) || test.requeue == acknowledger.acked {
+				t.Errorf("got acked %v, requeued %v, want requeued %v", acknowledger.acked, acknowledger.requeue, test.requeue)
+			}
+		})
+	}
+
+}
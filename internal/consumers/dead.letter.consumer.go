@@ -0,0 +1,104 @@
+package consumers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/topology"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"golang.org/x/exp/slog"
+)
+
+type DeadLetterConsumer interface {
+	Start()
+	Stop()
+}
+
+type deadLetterConsumer struct {
+	*queueConsumer
+	deadLetterService services.DeadLetterService
+}
+
+// NewDeadLetterConsumer moves the messages of a dead-letter queue into the dead letter collection
+func NewDeadLetterConsumer(conn config.AMQPconnection, messaging *topology.Topology, queue string, deadLetterService services.DeadLetterService) DeadLetterConsumer {
+	consumer := &deadLetterConsumer{
+		deadLetterService: deadLetterService,
+	}
+	consumer.queueConsumer = newQueueConsumer(conn, messaging, "dead letter consumer", queue, config.GetEnvInt("DEAD_LETTER_PREFETCH", 10), consumer.handle)
+	return consumer
+}
+
+// handle stores the dead letter, failing to store it requeues it since a dead-letter queue has nowhere else to send it
+func (consumer *deadLetterConsumer) handle(delivery amqp.Delivery) {
+
+	deadLetter := newDeadLetter(consumer.queue, delivery)
+
+	err := consumer.deadLetterService.StoreDeadLetter(deadLetter)
+	if err != nil {
+		slog.Error("Failed to store dead letter", "error", err, "queue", consumer.queue, "messageId", delivery.MessageId)
+		delivery.Nack(false, true)
+		return
+	}
+
+	delivery.Ack(false)
+
+}
+
+// newDeadLetter finds where the message was first published to. A retried message carries it in the headers set by the retry,
+// the others in the latest x-death entry. Without either it came through an alternate exchange, which keeps the exchange and routing key.
+func newDeadLetter(queue string, delivery amqp.Delivery) *models.DeadLetter {
+
+	deadLetter := &models.DeadLetter{
+		Queue:          queue,
+		Reason:         constants.DeadLetterUnroutable,
+		Exchange:       delivery.Exchange,
+		RoutingKey:     delivery.RoutingKey,
+		MessageId:      delivery.MessageId,
+		CorrelationId:  delivery.CorrelationId,
+		ContentType:    delivery.ContentType,
+		Headers:        delivery.Headers,
+		Body:           delivery.Body,
+		Attempts:       headerInt(delivery.Headers, constants.AttemptsHeader),
+		DeadLetteredAt: time.Now().UTC(),
+	}
+
+	// RabbitMQ puts the latest dead-lettering first
+	if deaths, ok := delivery.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			deadLetter.SourceQueue, _ = death["queue"].(string)
+			deadLetter.Reason, _ = death["reason"].(string)
+			deadLetter.Exchange, _ = death["exchange"].(string)
+			if routingKeys, ok := death["routing-keys"].([]interface{}); ok && len(routingKeys) > 0 {
+				deadLetter.RoutingKey, _ = routingKeys[0].(string)
+			}
+		}
+	}
+
+	// the relay names the user of an outbox message in a header, the other services' messages carry it in the body
+	deadLetter.UserId, _ = delivery.Headers[constants.UserIdHeader].(string)
+	if deadLetter.UserId == "" {
+		var body struct {
+			UserId string `json:"userId"`
+		}
+		if json.Unmarshal(delivery.Body, &body) == nil {
+			deadLetter.UserId = body.UserId
+		}
+	}
+
+	if exchange, ok := delivery.Headers[constants.OriginalExchangeHeader].(string); ok {
+		deadLetter.Exchange = exchange
+		deadLetter.RoutingKey, _ = delivery.Headers[constants.OriginalRoutingKeyHeader].(string)
+	}
+
+	// the attempt that rejected the message is not counted in the header yet
+	if deadLetter.Reason == "rejected" {
+		deadLetter.Attempts++
+	}
+
+	return deadLetter
+
+}
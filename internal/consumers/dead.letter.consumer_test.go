@@ -0,0 +1,101 @@
+package consumers
+
+import (
+	"testing"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// deadLetterOrigin is the part of a dead letter newDeadLetter works out from the delivery
+type deadLetterOrigin struct {
+	SourceQueue string
+	Reason      string
+	Exchange    string
+	RoutingKey  string
+	UserId      string
+	Attempts    int
+}
+
+func TestNewDeadLetter(t *testing.T) {
+
+	// RabbitMQ puts the latest dead-lettering first
+	deaths := []interface{}{
+		amqp.Table{"queue": "work", "reason": "rejected", "exchange": "events", "routing-keys": []interface{}{"user.updated"}, "count": int64(1)},
+		amqp.Table{"queue": "work.retry.1", "reason": "expired", "exchange": "", "routing-keys": []interface{}{"work.retry.1"}, "count": int64(1)},
+	}
+
+	tests := []struct {
+		name     string
+		delivery amqp.Delivery
+		want     deadLetterOrigin
+	}{
+		{
+			name: "rejected",
+			delivery: amqp.Delivery{
+				Exchange:   "work.dlx",
+				RoutingKey: "user.updated",
+				Headers:    amqp.Table{"x-death": deaths[:1]},
+				Body:       []byte(`{"userId": "user-1"}`),
+			},
+			want: deadLetterOrigin{SourceQueue: "work", Reason: "rejected", Exchange: "events", RoutingKey: "user.updated", UserId: "user-1", Attempts: 1},
+		},
+		{
+			name: "rejected after retries",
+			delivery: amqp.Delivery{
+				Exchange:   "work.dlx",
+				RoutingKey: "work",
+				Headers: amqp.Table{
+					"x-death":                          deaths,
+					constants.AttemptsHeader:           int64(2),
+					constants.OriginalExchangeHeader:   "events",
+					constants.OriginalRoutingKeyHeader: "user.created",
+					constants.UserIdHeader:             "user-2",
+				},
+				Body: []byte(`{"userId": "user-1"}`),
+			},
+			want: deadLetterOrigin{SourceQueue: "work", Reason: "rejected", Exchange: "events", RoutingKey: "user.created", UserId: "user-2", Attempts: 3},
+		},
+		{
+			name: "expired",
+			delivery: amqp.Delivery{
+				Exchange:   "work.dlx",
+				RoutingKey: "work",
+				Headers:    amqp.Table{"x-death": []interface{}{amqp.Table{"queue": "work", "reason": "expired", "exchange": "", "routing-keys": []interface{}{"work"}}}},
+				Body:       []byte("user-3"),
+			},
+			want: deadLetterOrigin{SourceQueue: "work", Reason: "expired", Exchange: "", RoutingKey: "work"},
+		},
+		{
+			name: "unroutable",
+			delivery: amqp.Delivery{
+				Exchange:   "events",
+				RoutingKey: "user.deleted",
+				Headers:    amqp.Table{constants.UserIdHeader: "user-4"},
+			},
+			want: deadLetterOrigin{Reason: constants.DeadLetterUnroutable, Exchange: "events", RoutingKey: "user.deleted", UserId: "user-4"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deadLetter := newDeadLetter("work.dead", test.delivery)
+
+			got := deadLetterOrigin{
+				SourceQueue: deadLetter.SourceQueue,
+				Reason:      deadLetter.Reason,
+				Exchange:    deadLetter.Exchange,
+				RoutingKey:  deadLetter.RoutingKey,
+				UserId:      deadLetter.UserId,
+				Attempts:    deadLetter.Attempts,
+			}
+			if got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+			if deadLetter.Queue != "work.dead" {
+				t.Errorf("got queue %q, want work.dead", deadLetter.Queue)
+			}
+		})
+	}
+
+}
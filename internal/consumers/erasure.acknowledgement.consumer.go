@@ -0,0 +1,61 @@
+package consumers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/topology"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"golang.org/x/exp/slog"
+)
+
+type ErasureAcknowledgementConsumer interface {
+	Start()
+	Stop()
+}
+
+type erasureAcknowledgementConsumer struct {
+	*queueConsumer
+	erasureService services.ErasureService
+}
+
+// NewErasureAcknowledgementConsumer consumes the answers to user.erasure_requested events on ERASURE_ACK_QUEUE
+func NewErasureAcknowledgementConsumer(conn config.AMQPconnection, messaging *topology.Topology, erasureService services.ErasureService) ErasureAcknowledgementConsumer {
+	consumer := &erasureAcknowledgementConsumer{
+		erasureService: erasureService,
+	}
+	consumer.queueConsumer = newQueueConsumer(conn, messaging, "erasure acknowledgement consumer", config.GetEnv("ERASURE_ACK_QUEUE", "user_erasure_ack_queue"), config.GetEnvInt("ERASURE_ACK_PREFETCH", 10), consumer.handle)
+	return consumer
+}
+
+// handle records the acknowledgement. Malformed messages and acknowledgements of unknown erasures are dead-lettered,
+// failures to record are retried.
+func (consumer *erasureAcknowledgementConsumer) handle(delivery amqp.Delivery) {
+
+	var message models.ErasureAcknowledgementMessage
+	err := json.Unmarshal(delivery.Body, &message)
+	if err != nil {
+		slog.Error("Rejected malformed erasure acknowledgement", "error", err, "messageId", delivery.MessageId)
+		delivery.Nack(false, false)
+		return
+	}
+
+	err = consumer.erasureService.RecordAcknowledgement(&message)
+	if errors.Is(err, services.ErrInvalidAcknowledgement) || errors.Is(err, services.ErrErasureNotFound) {
+		slog.Error("Rejected erasure acknowledgement", "error", err, "erasureId", message.ErasureId, "service", message.Service, "messageId", delivery.MessageId)
+		delivery.Nack(false, false)
+		return
+	}
+
+	if err != nil {
+		slog.Error("Failed to record erasure acknowledgement", "error", err, "erasureId", message.ErasureId, "service", message.Service, "messageId", delivery.MessageId)
+		consumer.retry(delivery)
+		return
+	}
+
+	delivery.Ack(false)
+
+}
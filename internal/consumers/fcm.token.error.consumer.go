@@ -0,0 +1,62 @@
+package consumers
+
+import (
+	"encoding/json"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/topology"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"golang.org/x/exp/slog"
+)
+
+type FCMtokenErrorConsumer interface {
+	Start()
+	Stop()
+}
+
+type fcmTokenErrorConsumer struct {
+	*queueConsumer
+	userService services.UserService
+}
+
+// NewFCMtokenErrorConsumer consumes the delivery errors the push sender reports on FCM_TOKEN_ERROR_QUEUE
+func NewFCMtokenErrorConsumer(conn config.AMQPconnection, messaging *topology.Topology, userService services.UserService) FCMtokenErrorConsumer {
+	consumer := &fcmTokenErrorConsumer{
+		userService: userService,
+	}
+	consumer.queueConsumer = newQueueConsumer(conn, messaging, "FCM token error consumer", config.GetEnv("FCM_TOKEN_ERROR_QUEUE", "fcm_token_error_queue"), config.GetEnvInt("FCM_TOKEN_ERROR_PREFETCH", 10), consumer.handle)
+	return consumer
+}
+
+// handle removes tokens reported as UNREGISTERED, other errors are transient or on the sender's side and are dropped.
+// Malformed messages are dead-lettered, failures to remove are retried.
+func (consumer *fcmTokenErrorConsumer) handle(delivery amqp.Delivery) {
+
+	var message models.FCMtokenErrorMessage
+	err := json.Unmarshal(delivery.Body, &message)
+	if err != nil || message.Token == "" {
+		slog.Error("Rejected malformed FCM token error message", "error", err, "messageId", delivery.MessageId)
+		delivery.Nack(false, false)
+		return
+	}
+
+	if message.Error != constants.FCMunregistered {
+		slog.Debug("Ignored FCM token error", "error", message.Error, "messageId", delivery.MessageId)
+		delivery.Ack(false)
+		return
+	}
+
+	err = consumer.userService.RemoveUnregisteredFCMtoken(message.UserId, message.Token)
+	if err != nil {
+		slog.Error("Failed to remove unregistered FCM token", "error", err, "userId", message.UserId, "messageId", delivery.MessageId)
+		consumer.retry(delivery)
+		return
+	}
+
+	slog.Debug("Removed unregistered FCM token", "userId", message.UserId, "messageId", delivery.MessageId)
+	delivery.Ack(false)
+
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController interface {
+	SearchUsers(c *gin.Context)
+	GetUser(c *gin.Context)
+	EditUser(c *gin.Context)
+	DisableChannel(c *gin.Context)
+	DeleteUser(c *gin.Context)
+}
+
+type adminController struct {
+	adminService services.AdminService
+}
+
+func NewAdminController(adminService services.AdminService) AdminController {
+	return &adminController{
+		adminService: adminService,
+	}
+}
+
+func (controller *adminController) SearchUsers(c *gin.Context) {
+	var userQuery models.UserQuery
+	err := c.ShouldBindQuery(&userQuery)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, err := controller.adminService.SearchUsers(&userQuery)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+func (controller *adminController) GetUser(c *gin.Context) {
+	user, err := controller.adminService.GetUser(c.Param("userId"))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (controller *adminController) EditUser(c *gin.Context) {
+	var adminUserRequest models.AdminUserRequest
+	err := c.ShouldBindJSON(&adminUserRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := controller.adminService.EditUser(utils.GetActor(c), c.Param("userId"), &adminUserRequest)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (controller *adminController) DisableChannel(c *gin.Context) {
+	user, err := controller.adminService.DisableChannel(utils.GetActor(c), c.Param("userId"), c.Param("channel"))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (controller *adminController) DeleteUser(c *gin.Context) {
+	job, err := controller.adminService.DeleteUser(utils.GetActor(c), c.Param("userId"))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func adminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrNothingToUpdate), errors.Is(err, services.ErrInvalidChannel):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
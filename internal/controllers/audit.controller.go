@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController interface {
+	GetAudit(c *gin.Context)
+	SearchAudit(c *gin.Context)
+}
+
+type auditController struct {
+	auditService services.AuditService
+}
+
+func NewAuditController(auditService services.AuditService) AuditController {
+	return &auditController{
+		auditService: auditService,
+	}
+}
+
+func (controller *auditController) GetAudit(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var auditQuery models.AuditQuery
+	err = c.ShouldBindQuery(&auditQuery)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	audit, err := controller.auditService.GetUserAudit(utils.GetActor(c), userId, &auditQuery)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, audit)
+}
+
+func (controller *auditController) SearchAudit(c *gin.Context) {
+	var auditQuery models.AuditQuery
+	err := c.ShouldBindQuery(&auditQuery)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	audit, err := controller.auditService.SearchAudit(&auditQuery)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, audit)
+}
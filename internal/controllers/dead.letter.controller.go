@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/producers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type DeadLetterController interface {
+	ListDeadLetters(c *gin.Context)
+	GetDeadLetter(c *gin.Context)
+	ReplayDeadLetter(c *gin.Context)
+}
+
+type deadLetterController struct {
+	deadLetterService services.DeadLetterService
+}
+
+func NewDeadLetterController(deadLetterService services.DeadLetterService) DeadLetterController {
+	return &deadLetterController{
+		deadLetterService: deadLetterService,
+	}
+}
+
+func (controller *deadLetterController) ListDeadLetters(c *gin.Context) {
+	var deadLetterQuery models.DeadLetterQuery
+	err := c.ShouldBindQuery(&deadLetterQuery)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deadLetters, err := controller.deadLetterService.ListDeadLetters(&deadLetterQuery)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetters)
+}
+
+func (controller *deadLetterController) GetDeadLetter(c *gin.Context) {
+	deadLetter, err := controller.deadLetterService.GetDeadLetter(c.Param("id"))
+	if err != nil {
+		c.JSON(deadLetterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetter)
+}
+
+func (controller *deadLetterController) ReplayDeadLetter(c *gin.Context) {
+	deadLetter, err := controller.deadLetterService.ReplayDeadLetter(utils.GetActor(c), c.Param("id"))
+	if err != nil {
+		c.JSON(deadLetterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetter)
+}
+
+func deadLetterErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrDeadLetterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrDeadLetterAlreadyReplayed), errors.Is(err, producers.ErrUnroutable):
+		return http.StatusConflict
+	case errors.Is(err, producers.ErrPublishNacked), errors.Is(err, producers.ErrConfirmTimeout), errors.Is(err, config.ErrAMQPnotConnected):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type DiscordController interface {
+	StartLink(c *gin.Context)
+	Callback(c *gin.Context)
+	Unlink(c *gin.Context)
+}
+
+type discordController struct {
+	discordService services.DiscordService
+	redirectURL    string
+}
+
+func NewDiscordController(discordService services.DiscordService) DiscordController {
+	return &discordController{
+		discordService: discordService,
+		redirectURL:    os.Getenv("DISCORD_LINK_REDIRECT_URL"),
+	}
+}
+
+func (controller *discordController) StartLink(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authorizationURL, err := controller.discordService.StartLink(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": authorizationURL})
+}
+
+// Callback is where Discord sends the browser back to, so it is not behind the Firebase authorization.
+// The user is identified by the state instead, and the browser is sent on to DISCORD_LINK_REDIRECT_URL when set.
+func (controller *discordController) Callback(c *gin.Context) {
+	var discordCallbackRequest models.DiscordCallbackRequest
+	err := c.ShouldBindQuery(&discordCallbackRequest)
+	if err != nil {
+		controller.respond(c, http.StatusBadRequest, "failed", gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = controller.discordService.CompleteLink(utils.GetActor(c), discordCallbackRequest.State, discordCallbackRequest.Code)
+	if err != nil {
+		controller.respond(c, discordErrorStatus(err), "failed", gin.H{"error": "Discord account could not be linked"})
+		return
+	}
+
+	controller.respond(c, http.StatusOK, "linked", gin.H{"message": "Discord account linked successfully"})
+}
+
+func (controller *discordController) Unlink(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = controller.discordService.Unlink(utils.GetActor(c), userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Discord account unlinked successfully"})
+}
+
+func (controller *discordController) respond(c *gin.Context, status int, result string, body gin.H) {
+	if controller.redirectURL == "" {
+		c.JSON(status, body)
+		return
+	}
+
+	c.Redirect(http.StatusFound, controller.redirectURL+"?discord="+url.QueryEscape(result))
+}
+
+func discordErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidLinkState):
+		return http.StatusBadRequest
+	case errors.Is(err, config.ErrDiscordUnavailable):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeDiscordService struct {
+	services.DiscordService
+	err error
+}
+
+func (s *fakeDiscordService) CompleteLink(actor *models.Actor, state string, code string) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	return "user-1", nil
+}
+
+func TestDiscordCallbackStatus(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		query  string
+		err    error
+		status int
+	}{
+		{"linked", "?state=s&code=c", nil, http.StatusOK},
+		{"missing code", "?state=s", nil, http.StatusBadRequest},
+		{"invalid state", "?state=s&code=c", services.ErrInvalidLinkState, http.StatusBadRequest},
+		{"discord failed", "?state=s&code=c", fmt.Errorf("%w: status 500", config.ErrDiscordUnavailable), http.StatusBadGateway},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := &discordController{discordService: &fakeDiscordService{err: test.err}}
+
+			router := gin.New()
+			router.GET("/api/discord/callback", controller.Callback)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/discord/callback"+test.query, nil))
+
+			if recorder.Code != test.status {
+				t.Errorf("got status %d, want %d", recorder.Code, test.status)
+			}
+		})
+	}
+
+}
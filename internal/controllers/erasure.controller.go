@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ErasureController interface {
+	RequestErasure(c *gin.Context)
+	CancelErasure(c *gin.Context)
+	GetErasure(c *gin.Context)
+	GetUserErasure(c *gin.Context)
+}
+
+type erasureController struct {
+	erasureService services.ErasureService
+}
+
+func NewErasureController(erasureService services.ErasureService) ErasureController {
+	return &erasureController{
+		erasureService: erasureService,
+	}
+}
+
+func (controller *erasureController) RequestErasure(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := controller.erasureService.RequestErasure(utils.GetActor(c), userId)
+	if err != nil {
+		c.JSON(erasureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (controller *erasureController) CancelErasure(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = controller.erasureService.CancelErasure(utils.GetActor(c), userId)
+	if err != nil {
+		c.JSON(erasureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User deletion cancelled successfully",
+	})
+}
+
+func (controller *erasureController) GetErasure(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := controller.erasureService.GetErasure(userId)
+	if err != nil {
+		c.JSON(erasureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// GetUserErasure lets operators follow an erasure after the user, and with it their own access, is gone
+func (controller *erasureController) GetUserErasure(c *gin.Context) {
+	job, err := controller.erasureService.GetErasure(c.Param("userId"))
+	if err != nil {
+		c.JSON(erasureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func erasureErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrErasureNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrErasureAlreadyRequested), errors.Is(err, services.ErrErasureNotCancellable):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
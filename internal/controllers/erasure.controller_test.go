@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type fakeErasureService struct {
+	services.ErasureService
+	err error
+}
+
+func (s *fakeErasureService) RequestErasure(actor *models.Actor, userId string) (*models.ErasureJob, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.ErasureJob{UserId: userId}, nil
+}
+
+func TestRequestErasureStatus(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"requested", nil, http.StatusAccepted},
+		{"user not found", services.ErrUserNotFound, http.StatusNotFound},
+		{"already requested", services.ErrErasureAlreadyRequested, http.StatusConflict},
+		{"storage failure", mongo.ErrClientDisconnected, http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := NewErasureController(&fakeErasureService{err: test.err})
+
+			router := gin.New()
+			router.DELETE("/api/user/", func(c *gin.Context) {
+				c.Set("X-User-ID", "user-1")
+				controller.RequestErasure(c)
+			})
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/api/user/", nil))
+
+			if recorder.Code != test.status {
+				t.Errorf("got status %d, want %d", recorder.Code, test.status)
+			}
+		})
+	}
+
+}
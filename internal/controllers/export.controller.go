@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ExportController interface {
+	RequestExport(c *gin.Context)
+	GetExport(c *gin.Context)
+	Download(c *gin.Context)
+}
+
+type exportController struct {
+	exportService services.ExportService
+}
+
+func NewExportController(exportService services.ExportService) ExportController {
+	return &exportController{
+		exportService: exportService,
+	}
+}
+
+func (controller *exportController) RequestExport(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var exportRequest models.ExportRequest
+	err = c.ShouldBindQuery(&exportRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := controller.exportService.RequestExport(userId, &exportRequest)
+	if err != nil {
+		c.JSON(exportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (controller *exportController) GetExport(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	export, err := controller.exportService.GetExport(userId, c.Param("id"))
+	if err != nil {
+		c.JSON(exportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, export)
+}
+
+// Download is reached through the link handed out by GetExport, the token in the link is the only authorization
+func (controller *exportController) Download(c *gin.Context) {
+	job, err := controller.exportService.Download(c.Param("token"))
+	if err != nil {
+		c.JSON(exportErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "application/json"
+	if job.Format == constants.ExportZIP.String() {
+		contentType = "application/zip"
+	}
+
+	fileName := fmt.Sprintf("vqe-user-export-%s.%s", job.RequestedAt.Format("2006-01-02"), job.Format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, contentType, job.Archive)
+}
+
+func exportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrExportNotFound), errors.Is(err, services.ErrExportNotAvailable), errors.Is(err, services.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrExportRateLimited):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
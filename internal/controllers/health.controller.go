@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController interface {
+	Live(c *gin.Context)
+	Ready(c *gin.Context)
+}
+
+type healthController struct {
+	healthService services.HealthService
+}
+
+func NewHealthController(healthService services.HealthService) HealthController {
+	return &healthController{
+		healthService: healthService,
+	}
+}
+
+func (controller *healthController) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, controller.healthService.Live())
+}
+
+func (controller *healthController) Ready(c *gin.Context) {
+	health, ready := controller.healthService.Ready()
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, health)
+		return
+	}
+
+	c.JSON(http.StatusOK, health)
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type InternalController interface {
+	GetDeliveryTargets(c *gin.Context)
+}
+
+type internalController struct {
+	userService services.UserService
+}
+
+func NewInternalController(userService services.UserService) InternalController {
+	return &internalController{
+		userService: userService,
+	}
+}
+
+func (controller *internalController) GetDeliveryTargets(c *gin.Context) {
+	userId := c.Param("userId")
+
+	var deliveryTargetsQuery models.DeliveryTargetsQuery
+	err := c.ShouldBindQuery(&deliveryTargetsQuery)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveryTargets, err := controller.userService.ResolveDeliveryTargets(userId, deliveryTargetsQuery.EventType)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveryTargets)
+}
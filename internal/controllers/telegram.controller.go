@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type TelegramController interface {
+	StartBind(c *gin.Context)
+	CompleteBind(c *gin.Context)
+}
+
+type telegramController struct {
+	telegramService services.TelegramService
+}
+
+func NewTelegramController(telegramService services.TelegramService) TelegramController {
+	return &telegramController{
+		telegramService: telegramService,
+	}
+}
+
+func (controller *telegramController) StartBind(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	telegramBindResponse, err := controller.telegramService.StartBind(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, telegramBindResponse)
+}
+
+func (controller *telegramController) CompleteBind(c *gin.Context) {
+	var telegramBindRequest models.TelegramBindRequest
+	err := c.ShouldBindJSON(&telegramBindRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = controller.telegramService.CompleteBind(utils.GetServiceActor(c, "telegram-bot"), telegramBindRequest.Token, telegramBindRequest.ChatId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid or expired bind token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Telegram chat bound successfully"})
+}
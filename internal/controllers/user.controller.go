@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
@@ -14,25 +16,24 @@ type UserController interface {
 	GetUser(c *gin.Context)
 
 	EditWhatsAppNumber(c *gin.Context)
+	ConfirmWhatsAppNumber(c *gin.Context)
 	GetWhatsAppNumber(c *gin.Context)
 
-	EditDiscordId(c *gin.Context)
-	GetDiscordId(c *gin.Context)
+	GetDiscordAccount(c *gin.Context)
 
 	EditTelegramNumber(c *gin.Context)
+	ConfirmTelegramNumber(c *gin.Context)
 	GetTelegramNumber(c *gin.Context)
 
 	EditNotificationInterfaces(c *gin.Context)
 	GetNotificationInterfaces(c *gin.Context)
 
+	EditNotificationPreferences(c *gin.Context)
+	GetNotificationPreferences(c *gin.Context)
+
 	AddFCMtoken(c *gin.Context)
 	DeleteFCMtoken(c *gin.Context)
 	GetFCMtokens(c *gin.Context)
-
-	EditWebhooks(c *gin.Context)
-	GetWebhooks(c *gin.Context)
-
-	DeleteUser(c *gin.Context)
 }
 
 type userController struct {
@@ -46,13 +47,17 @@ func NewUserController(userService services.UserService) UserController {
 }
 
 func (controller *userController) UpsertUser(c *gin.Context) {
-	userId, err := utils.GetUserId(c)
+	identity, err := utils.GetIdentity(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	isUpserted, err := controller.userService.UpsertUser(userId)
+	isUpserted, err := controller.userService.UpsertUser(utils.GetActor(c), identity)
+	if errors.Is(err, services.ErrUserErased) {
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -96,57 +101,61 @@ func (controller *userController) EditWhatsAppNumber(c *gin.Context) {
 		return
 	}
 
-	err = controller.userService.EditWhatsAppNumber(userId, whatsAppRequest.WhatsAppNumber)
+	verification, err := controller.userService.EditWhatsAppNumber(utils.GetActor(c), userId, whatsAppRequest.WhatsAppNumber)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(verificationErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, whatsAppRequest)
+	c.JSON(http.StatusAccepted, models.VerificationResponse{
+		Number:    verification.Number,
+		Status:    verification.Status,
+		ExpiresAt: verification.ExpiresAt,
+	})
 }
 
-func (controller *userController) GetWhatsAppNumber(c *gin.Context) {
-
+func (controller *userController) ConfirmWhatsAppNumber(c *gin.Context) {
 	userId, err := utils.GetUserId(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	whatsAppNumber, err := controller.userService.GetWhatsAppNumber(userId)
+	var verificationCodeRequest models.VerificationCodeRequest
+	err = c.ShouldBindJSON(&verificationCodeRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.WhatsAppRequest{WhatsAppNumber: whatsAppNumber})
-
-}
-
-func (controller *userController) EditDiscordId(c *gin.Context) {
-	userId, err := utils.GetUserId(c)
+	err = controller.userService.ConfirmWhatsAppNumber(utils.GetActor(c), userId, verificationCodeRequest.Code)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(verificationErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	var discordRequest models.DiscordRequest
-	err = c.ShouldBindJSON(&discordRequest)
+	c.JSON(http.StatusOK, gin.H{"status": constants.VerificationVerified})
+}
+
+func (controller *userController) GetWhatsAppNumber(c *gin.Context) {
+
+	userId, err := utils.GetUserId(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = controller.userService.EditDiscordId(userId, discordRequest.DiscordId)
+	whatsAppNumber, err := controller.userService.GetWhatsAppNumber(userId)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, discordRequest)
+	c.JSON(http.StatusOK, models.WhatsAppRequest{WhatsAppNumber: whatsAppNumber})
+
 }
 
-func (controller *userController) GetDiscordId(c *gin.Context) {
+func (controller *userController) GetDiscordAccount(c *gin.Context) {
 
 	userId, err := utils.GetUserId(c)
 	if err != nil {
@@ -154,13 +163,13 @@ func (controller *userController) GetDiscordId(c *gin.Context) {
 		return
 	}
 
-	discordId, err := controller.userService.GetDiscordId(userId)
+	discordId, discordUsername, err := controller.userService.GetDiscordAccount(userId)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.DiscordRequest{DiscordId: discordId})
+	c.JSON(http.StatusOK, models.DiscordRequest{DiscordId: discordId, DiscordUsername: discordUsername})
 
 }
 
@@ -178,13 +187,40 @@ func (controller *userController) EditTelegramNumber(c *gin.Context) {
 		return
 	}
 
-	err = controller.userService.EditTelegramNumber(userId, telegramRequest.TelegramNumber)
+	verification, err := controller.userService.EditTelegramNumber(utils.GetActor(c), userId, telegramRequest.TelegramNumber)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(verificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.VerificationResponse{
+		Number:    verification.Number,
+		Status:    verification.Status,
+		ExpiresAt: verification.ExpiresAt,
+	})
+}
+
+func (controller *userController) ConfirmTelegramNumber(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var verificationCodeRequest models.VerificationCodeRequest
+	err = c.ShouldBindJSON(&verificationCodeRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = controller.userService.ConfirmTelegramNumber(utils.GetActor(c), userId, verificationCodeRequest.Code)
+	if err != nil {
+		c.JSON(verificationErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, telegramRequest)
+	c.JSON(http.StatusOK, gin.H{"status": constants.VerificationVerified})
 }
 
 func (controller *userController) GetTelegramNumber(c *gin.Context) {
@@ -219,7 +255,7 @@ func (controller *userController) EditNotificationInterfaces(c *gin.Context) {
 		return
 	}
 
-	err = controller.userService.EditNotificationInterfaces(userId, notificationInterfacesRequest.NotificationInterfaces)
+	err = controller.userService.EditNotificationInterfaces(utils.GetActor(c), userId, notificationInterfacesRequest.NotificationInterfaces)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -246,94 +282,98 @@ func (controller *userController) GetNotificationInterfaces(c *gin.Context) {
 
 }
 
-func (controller *userController) AddFCMtoken(c *gin.Context) {
+func (controller *userController) EditNotificationPreferences(c *gin.Context) {
 	userId, err := utils.GetUserId(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var fcmTokensRequest models.FCMtokenRequest
-	err = c.ShouldBindJSON(&fcmTokensRequest)
+	var notificationPreferencesRequest models.NotificationPreferencesRequest
+	err = c.ShouldBindJSON(&notificationPreferencesRequest)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = controller.userService.AddFCMtoken(userId, fcmTokensRequest.FCMtoken)
+	err = controller.userService.EditNotificationPreferences(utils.GetActor(c), userId, notificationPreferencesRequest.NotificationPreferences)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, fcmTokensRequest)
+	c.JSON(http.StatusOK, notificationPreferencesRequest)
 }
 
-func (controller *userController) DeleteFCMtoken(c *gin.Context) {
-	userId, err := utils.GetUserId(c)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+func (controller *userController) GetNotificationPreferences(c *gin.Context) {
 
-	var fcmTokensRequest models.FCMtokenRequest
-	err = c.ShouldBindJSON(&fcmTokensRequest)
+	userId, err := utils.GetUserId(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = controller.userService.DeleteFCMtoken(userId, fcmTokensRequest.FCMtoken)
+	notificationPreferences, err := controller.userService.GetNotificationPreferences(userId)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, fcmTokensRequest)
-}
+	c.JSON(http.StatusOK, models.NotificationPreferencesRequest{NotificationPreferences: notificationPreferences})
 
-func (controller *userController) GetFCMtokens(c *gin.Context) {
+}
 
+func (controller *userController) AddFCMtoken(c *gin.Context) {
 	userId, err := utils.GetUserId(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	fcmTokens, err := controller.userService.GetFCMtokens(userId)
+	var fcmTokensRequest models.FCMtokenRequest
+	err = c.ShouldBindJSON(&fcmTokensRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.FCMtokensRequest{FCMtokens: fcmTokens})
+	if fcmTokensRequest.UserAgent == "" {
+		fcmTokensRequest.UserAgent = c.Request.UserAgent()
+	}
+
+	err = controller.userService.AddFCMtoken(utils.GetActor(c), userId, &fcmTokensRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
+	c.JSON(http.StatusOK, fcmTokensRequest)
 }
 
-func (controller *userController) EditWebhooks(c *gin.Context) {
+func (controller *userController) DeleteFCMtoken(c *gin.Context) {
 	userId, err := utils.GetUserId(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var webhooksRequest models.WebhooksRequest
-	err = c.ShouldBindJSON(&webhooksRequest)
+	var fcmTokensRequest models.FCMtokenDeleteRequest
+	err = c.ShouldBindJSON(&fcmTokensRequest)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = controller.userService.EditWebhooks(userId, webhooksRequest.Webhooks)
+	err = controller.userService.DeleteFCMtoken(utils.GetActor(c), userId, fcmTokensRequest.FCMtoken)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, webhooksRequest)
+	c.JSON(http.StatusOK, fcmTokensRequest)
 }
 
-func (controller *userController) GetWebhooks(c *gin.Context) {
+func (controller *userController) GetFCMtokens(c *gin.Context) {
 
 	userId, err := utils.GetUserId(c)
 	if err != nil {
@@ -341,30 +381,26 @@ func (controller *userController) GetWebhooks(c *gin.Context) {
 		return
 	}
 
-	webhooks, err := controller.userService.GetWebhooks(userId)
+	fcmTokens, err := controller.userService.GetFCMtokens(userId)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.WebhooksRequest{Webhooks: webhooks})
+	c.JSON(http.StatusOK, models.FCMtokensResponse{FCMtokens: fcmTokens})
 
 }
 
-func (controller *userController) DeleteUser(c *gin.Context) {
-	userId, err := utils.GetUserId(c)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+func verificationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNoPendingVerification),
+		errors.Is(err, services.ErrVerificationCodeExpired),
+		errors.Is(err, services.ErrInvalidVerificationCode):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrTooManyVerificationAttempts),
+		errors.Is(err, services.ErrVerificationResendTooSoon):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
 	}
-
-	err = controller.userService.DeleteUser(userId)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "User deleted successfully",
-	})
 }
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController interface {
+	CreateWebhook(c *gin.Context)
+	GetWebhooks(c *gin.Context)
+	GetWebhook(c *gin.Context)
+	UpdateWebhook(c *gin.Context)
+	DeleteWebhook(c *gin.Context)
+	RotateSecret(c *gin.Context)
+	RequestVerification(c *gin.Context)
+	TestWebhook(c *gin.Context)
+	GetDeliveries(c *gin.Context)
+}
+
+type webhookController struct {
+	webhookService services.WebhookService
+}
+
+func NewWebhookController(webhookService services.WebhookService) WebhookController {
+	return &webhookController{
+		webhookService: webhookService,
+	}
+}
+
+func (controller *webhookController) CreateWebhook(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var webhookRequest models.WebhookRequest
+	err = c.ShouldBindJSON(&webhookRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := controller.webhookService.CreateWebhook(utils.GetActor(c), userId, &webhookRequest)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+func (controller *webhookController) GetWebhooks(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhooks, err := controller.webhookService.GetWebhooks(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+func (controller *webhookController) GetWebhook(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := controller.webhookService.GetWebhook(userId, c.Param("id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (controller *webhookController) UpdateWebhook(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var webhookPatchRequest models.WebhookPatchRequest
+	err = c.ShouldBindJSON(&webhookPatchRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := controller.webhookService.UpdateWebhook(utils.GetActor(c), userId, c.Param("id"), &webhookPatchRequest)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (controller *webhookController) DeleteWebhook(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = controller.webhookService.DeleteWebhook(utils.GetActor(c), userId, c.Param("id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+func (controller *webhookController) RotateSecret(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rotationRequest models.WebhookSecretRotationRequest
+	if c.Request.ContentLength > 0 {
+		err = c.ShouldBindJSON(&rotationRequest)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var overlap *time.Duration
+	if rotationRequest.OverlapSeconds != nil {
+		d := time.Duration(*rotationRequest.OverlapSeconds) * time.Second
+		overlap = &d
+	}
+
+	webhook, err := controller.webhookService.RotateSecret(utils.GetActor(c), userId, c.Param("id"), overlap)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (controller *webhookController) RequestVerification(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := controller.webhookService.RequestVerification(utils.GetActor(c), userId, c.Param("id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, webhook)
+}
+
+func (controller *webhookController) TestWebhook(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	delivery, err := controller.webhookService.TestWebhook(userId, c.Param("id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+func (controller *webhookController) GetDeliveries(c *gin.Context) {
+	userId, err := utils.GetUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var deliveriesQuery models.WebhookDeliveriesQuery
+	err = c.ShouldBindQuery(&deliveriesQuery)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, err := controller.webhookService.GetDeliveries(userId, c.Param("id"), &deliveriesQuery)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrWebhookLimitReached), errors.Is(err, services.ErrWebhookNotVerified), errors.Is(err, services.ErrWebhookDisabled):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrWebhookTestTooSoon):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
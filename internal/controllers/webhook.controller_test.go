@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeWebhookService struct {
+	services.WebhookService
+}
+
+func (s *fakeWebhookService) GetDeliveries(userId string, id string, query *models.WebhookDeliveriesQuery) (*models.WebhookDeliveriesResponse, error) {
+	return &models.WebhookDeliveriesResponse{Deliveries: []models.WebhookDelivery{}, Page: query.Page, Limit: query.Limit}, nil
+}
+
+func TestGetDeliveriesQueryBounds(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		query  string
+		status int
+	}{
+		{"", http.StatusOK},
+		{"?page=2&limit=50", http.StatusOK},
+		{"?page=-1", http.StatusBadRequest},
+		{"?limit=-5", http.StatusBadRequest},
+		{"?limit=1000", http.StatusBadRequest},
+		{"?page=99999999999999999999", http.StatusBadRequest},
+		{"?page=9223372036854775807", http.StatusBadRequest},
+		{"?limit=ten", http.StatusBadRequest},
+	}
+
+	controller := NewWebhookController(&fakeWebhookService{})
+	router := gin.New()
+	router.GET("/api/user/webhooks/:id/deliveries", func(c *gin.Context) {
+		c.Set("X-User-ID", "user-1")
+		controller.GetDeliveries(c)
+	})
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/user/webhooks/64b7f0c2a1b2c3d4e5f60718/deliveries"+test.query, nil))
+
+			if recorder.Code != test.status {
+				t.Errorf("got status %d, want %d", recorder.Code, test.status)
+			}
+		})
+	}
+
+}
@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"golang.org/x/exp/slog"
+)
+
+type ErasureJob interface {
+	Start()
+	Stop()
+}
+
+type erasureJob struct {
+	erasureService services.ErasureService
+	interval       time.Duration
+	batchSize      int
+	stop           chan struct{}
+	done           chan struct{}
+}
+
+func NewErasureJob(erasureService services.ErasureService) ErasureJob {
+	return &erasureJob{
+		erasureService: erasureService,
+		interval:       config.GetEnvDuration("ERASURE_INTERVAL", time.Minute),
+		batchSize:      config.GetEnvInt("ERASURE_BATCH_SIZE", 20),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+}
+
+func (job *erasureJob) Start() {
+
+	go func() {
+		defer close(job.done)
+
+		ticker := time.NewTicker(job.interval)
+		defer ticker.Stop()
+
+		for {
+			job.erase()
+
+			select {
+			case <-job.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	slog.Info("Started user erasure", "interval", job.interval)
+
+}
+
+func (job *erasureJob) Stop() {
+
+	close(job.stop)
+	<-job.done
+
+	slog.Info("Stopped user erasure")
+
+}
+
+// erase hard deletes up to batchSize users whose grace period is over, stopping early once none are due
+func (job *erasureJob) erase() {
+
+	for i := 0; i < job.batchSize; i++ {
+
+		select {
+		case <-job.stop:
+			return
+		default:
+		}
+
+		erased, err := job.erasureService.EraseNext()
+		if err != nil || !erased {
+			return
+		}
+
+	}
+
+}
@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"golang.org/x/exp/slog"
+)
+
+type ExportJob interface {
+	Start()
+	Stop()
+}
+
+type exportJob struct {
+	exportService services.ExportService
+	interval      time.Duration
+	batchSize     int
+	stop          chan struct{}
+	done          chan struct{}
+}
+
+func NewExportJob(exportService services.ExportService) ExportJob {
+	return &exportJob{
+		exportService: exportService,
+		interval:      config.GetEnvDuration("EXPORT_INTERVAL", 5*time.Second),
+		batchSize:     config.GetEnvInt("EXPORT_BATCH_SIZE", 10),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+func (job *exportJob) Start() {
+
+	go func() {
+		defer close(job.done)
+
+		ticker := time.NewTicker(job.interval)
+		defer ticker.Stop()
+
+		for {
+			job.export()
+
+			select {
+			case <-job.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	slog.Info("Started data export", "interval", job.interval)
+
+}
+
+func (job *exportJob) Stop() {
+
+	close(job.stop)
+	<-job.done
+
+	slog.Info("Stopped data export")
+
+}
+
+// export builds up to batchSize pending exports, stopping early once none are left
+func (job *exportJob) export() {
+
+	for i := 0; i < job.batchSize; i++ {
+
+		select {
+		case <-job.stop:
+			return
+		default:
+		}
+
+		exported, err := job.exportService.ExportNext()
+		if err != nil || !exported {
+			return
+		}
+
+	}
+
+}
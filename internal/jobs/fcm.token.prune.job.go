@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"golang.org/x/exp/slog"
+)
+
+type FCMtokenPruneJob interface {
+	Start()
+	Stop()
+}
+
+type fcmTokenPruneJob struct {
+	userService services.UserService
+	interval    time.Duration
+	batchSize   int
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+func NewFCMtokenPruneJob(userService services.UserService) FCMtokenPruneJob {
+	return &fcmTokenPruneJob{
+		userService: userService,
+		interval:    config.GetEnvDuration("FCM_TOKEN_PRUNE_INTERVAL", time.Hour),
+		batchSize:   config.GetEnvInt("FCM_TOKEN_PRUNE_BATCH_SIZE", 100),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+func (job *fcmTokenPruneJob) Start() {
+
+	go func() {
+		defer close(job.done)
+
+		ticker := time.NewTicker(job.interval)
+		defer ticker.Stop()
+
+		for {
+			job.prune()
+
+			select {
+			case <-job.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	slog.Info("Started FCM token pruning", "interval", job.interval)
+
+}
+
+func (job *fcmTokenPruneJob) Stop() {
+
+	close(job.stop)
+	<-job.done
+
+	slog.Info("Stopped FCM token pruning")
+
+}
+
+// prune works through the users with stale tokens a batch at a time until none are left
+func (job *fcmTokenPruneJob) prune() {
+
+	for {
+
+		select {
+		case <-job.stop:
+			return
+		default:
+		}
+
+		pruned, err := job.userService.PruneStaleFCMtokens(job.batchSize)
+		if err != nil || pruned < job.batchSize {
+			return
+		}
+
+	}
+
+}
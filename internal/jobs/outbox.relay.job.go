@@ -0,0 +1,134 @@
+package jobs
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/producers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"golang.org/x/exp/slog"
+)
+
+type OutboxRelayJob interface {
+	Start()
+	Stop()
+}
+
+type outboxRelayJob struct {
+	outboxRepository repositories.OutboxRepository
+	producer         producers.OutboxProducer
+	interval         time.Duration
+	lease            time.Duration
+	maxBackoff       time.Duration
+	batchSize        int
+	maxAttempts      int
+	stop             chan struct{}
+	done             chan struct{}
+}
+
+func NewOutboxRelayJob(outboxRepository repositories.OutboxRepository, producer producers.OutboxProducer) OutboxRelayJob {
+	return &outboxRelayJob{
+		outboxRepository: outboxRepository,
+		producer:         producer,
+		interval:         config.GetEnvDuration("OUTBOX_RELAY_INTERVAL", 2*time.Second),
+		lease:            config.GetEnvDuration("OUTBOX_RELAY_LEASE", 30*time.Second),
+		maxBackoff:       config.GetEnvDuration("OUTBOX_RELAY_MAX_BACKOFF", 5*time.Minute),
+		batchSize:        config.GetEnvInt("OUTBOX_RELAY_BATCH_SIZE", 100),
+		maxAttempts:      config.GetEnvInt("OUTBOX_RELAY_MAX_ATTEMPTS", 20),
+		stop:             make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+}
+
+func (job *outboxRelayJob) Start() {
+
+	go func() {
+		defer close(job.done)
+
+		ticker := time.NewTicker(job.interval)
+		defer ticker.Stop()
+
+		for {
+			job.relay()
+
+			select {
+			case <-job.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	slog.Info("Started outbox relay", "interval", job.interval)
+
+}
+
+func (job *outboxRelayJob) Stop() {
+
+	close(job.stop)
+	<-job.done
+
+	slog.Info("Stopped outbox relay")
+
+}
+
+// relay publishes up to batchSize due messages, stopping early once the outbox is drained
+func (job *outboxRelayJob) relay() {
+
+	for i := 0; i < job.batchSize; i++ {
+
+		select {
+		case <-job.stop:
+			return
+		default:
+		}
+
+		message, err := job.outboxRepository.ClaimNext(job.lease)
+		if err != nil || message == nil {
+			return
+		}
+
+		err = job.producer.Publish(context.Background(), message)
+		if err != nil && message.Attempts >= job.maxAttempts {
+			slog.Error("Parking outbox message after too many attempts", "error", err, "messageId", message.MessageId, "attempts", message.Attempts)
+			err = job.outboxRepository.MarkParked(message.Id, err.Error())
+			if err != nil {
+				return
+			}
+			continue
+		}
+		if err != nil {
+			nextAttemptAt := time.Now().UTC().Add(job.backoff(message.Attempts))
+			slog.Warn("Failed to relay outbox message", "error", err, "messageId", message.MessageId, "attempts", message.Attempts, "nextAttemptAt", nextAttemptAt)
+			err = job.outboxRepository.MarkFailed(message.Id, nextAttemptAt, err.Error())
+			if err != nil {
+				// the lease still holds the message back, it is retried once it runs out
+				return
+			}
+			continue
+		}
+
+		// the message goes out again once the lease runs out, which at-least-once delivery allows,
+		// but the batch stops here since the outbox cannot be written to right now
+		err = job.outboxRepository.MarkPublished(message.Id)
+		if err != nil {
+			slog.Error("Published outbox message could not be marked, it will be published again", "error", err, "messageId", message.MessageId)
+			return
+		}
+
+	}
+
+}
+
+func (job *outboxRelayJob) backoff(attempts int) time.Duration {
+
+	backoff := time.Duration(math.Pow(2, float64(attempts))) * time.Second
+	if backoff <= 0 || backoff > job.maxBackoff {
+		return job.maxBackoff
+	}
+
+	return backoff
+
+}
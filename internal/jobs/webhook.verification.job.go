@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/services"
+	"golang.org/x/exp/slog"
+)
+
+type WebhookVerificationJob interface {
+	Start()
+	Stop()
+}
+
+type webhookVerificationJob struct {
+	verificationService services.WebhookVerificationService
+	interval            time.Duration
+	batchSize           int
+	stop                chan struct{}
+	done                chan struct{}
+}
+
+func NewWebhookVerificationJob(verificationService services.WebhookVerificationService) WebhookVerificationJob {
+	return &webhookVerificationJob{
+		verificationService: verificationService,
+		interval:            config.GetEnvDuration("WEBHOOK_VERIFICATION_INTERVAL", 5*time.Second),
+		batchSize:           config.GetEnvInt("WEBHOOK_VERIFICATION_BATCH_SIZE", 20),
+		stop:                make(chan struct{}),
+		done:                make(chan struct{}),
+	}
+}
+
+func (job *webhookVerificationJob) Start() {
+
+	go func() {
+		defer close(job.done)
+
+		ticker := time.NewTicker(job.interval)
+		defer ticker.Stop()
+
+		for {
+			job.verify()
+
+			select {
+			case <-job.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	slog.Info("Started webhook verification", "interval", job.interval)
+
+}
+
+func (job *webhookVerificationJob) Stop() {
+
+	close(job.stop)
+	<-job.done
+
+	slog.Info("Stopped webhook verification")
+
+}
+
+// verify handshakes with up to batchSize pending webhooks, stopping early once none are due
+func (job *webhookVerificationJob) verify() {
+
+	for i := 0; i < job.batchSize; i++ {
+
+		select {
+		case <-job.stop:
+			return
+		default:
+		}
+
+		verified, err := job.verificationService.VerifyNext()
+		if err != nil || !verified {
+			return
+		}
+
+	}
+
+}
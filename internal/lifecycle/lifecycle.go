@@ -0,0 +1,166 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"golang.org/x/exp/slog"
+)
+
+// Phase orders the shutdown, every component of a phase is stopped before the next phase starts
+type Phase int
+
+const (
+	// Server stops taking requests and drains the ones in flight
+	Server Phase = iota
+	// Workers are the jobs and consumers, stopped once no request can hand them more work
+	Workers
+	// Messaging closes the AMQP channels and connection once nothing publishes or consumes anymore
+	Messaging
+	// Storage disconnects from Mongo last, everything before it may still write
+	Storage
+)
+
+var phases = []Phase{Server, Workers, Messaging, Storage}
+
+// StopFunc stops a component, giving up once ctx is done
+type StopFunc func(ctx context.Context) error
+
+// Lifecycle runs the service until it is told to stop and then shuts its components down in order
+type Lifecycle interface {
+	Register(phase Phase, name string, stop StopFunc)
+	Fail(name string, err error)
+	IsReady() bool
+	Run() error
+}
+
+type component struct {
+	name string
+	stop StopFunc
+}
+
+type lifecycle struct {
+	mutex      sync.Mutex
+	components map[Phase][]component
+	ready      atomic.Bool
+	failed     chan error
+	drainDelay time.Duration
+	timeout    time.Duration
+}
+
+func New() Lifecycle {
+	return &lifecycle{
+		components: map[Phase][]component{},
+		failed:     make(chan error, 1),
+		drainDelay: config.GetEnvDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+		timeout:    config.GetEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+	}
+}
+
+// Register adds a component to be stopped in the given phase, within a phase the last one registered is stopped first
+func (l *lifecycle) Register(phase Phase, name string, stop StopFunc) {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.components[phase] = append(l.components[phase], component{name: name, stop: stop})
+
+}
+
+// Fail reports a component that can no longer work, which shuts the whole service down
+func (l *lifecycle) Fail(name string, err error) {
+
+	slog.Error("Component failed, shutting down", "error", err, "component", name)
+
+	select {
+	case l.failed <- err:
+	default:
+	}
+
+}
+
+// IsReady is true from Run until the shutdown starts
+func (l *lifecycle) IsReady() bool {
+	return l.ready.Load()
+}
+
+// Run marks the service ready and blocks until SIGINT, SIGTERM or a failed component, then shuts it down.
+// It returns the failure that ended the service, if any, joined with the errors of the shutdown.
+func (l *lifecycle) Run() error {
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	l.ready.Store(true)
+	slog.Info("Service is ready")
+
+	var err error
+	select {
+	case <-ctx.Done():
+		slog.Info("Received shutdown signal")
+	case err = <-l.failed:
+	}
+
+	return errors.Join(err, l.shutdown())
+
+}
+
+// shutdown reports the service as not ready and waits SHUTDOWN_DRAIN_DELAY for load balancers to stop routing to it,
+// then stops the components phase by phase, all within SHUTDOWN_TIMEOUT
+func (l *lifecycle) shutdown() error {
+
+	l.ready.Store(false)
+	slog.Info("Shutting down", "drainDelay", l.drainDelay, "timeout", l.timeout)
+	time.Sleep(l.drainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var errs []error
+	for _, phase := range phases {
+		components := l.components[phase]
+		for i := len(components) - 1; i >= 0; i-- {
+			err := components[i].stop(ctx)
+			if err != nil {
+				slog.Error("Failed to stop component", "error", err, "component", components[i].name)
+				errs = append(errs, err)
+				continue
+			}
+			slog.Debug("Stopped component", "component", components[i].name)
+		}
+	}
+
+	slog.Info("Shut down", "errors", len(errs))
+	return errors.Join(errs...)
+
+}
+
+// Blocking adapts the Stop of a job or consumer, which waits for the work in flight, to the shutdown deadline.
+// A Stop still running at the deadline is left behind so the remaining phases get to run.
+func Blocking(stop func()) StopFunc {
+	return func(ctx context.Context) error {
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			stop()
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+	}
+}
@@ -15,12 +15,16 @@ import (
 func Authorization(firebaseClient config.FirebaseClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		token := strings.Split(c.GetHeader("Authorization"), " ")[1]
+		token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		uid, err := firebaseClient.VerifyIDToken(ctx, token)
+		identity, err := firebaseClient.VerifyIDToken(ctx, token)
 
 		if err != nil {
 			slog.Error("error verifying ID token", "error", err)
@@ -28,7 +32,8 @@ func Authorization(firebaseClient config.FirebaseClient) gin.HandlerFunc {
 			return
 		}
 
-		utils.SetUserId(c, uid)
+		utils.SetUserId(c, identity.UID)
+		utils.SetIdentity(c, identity)
 
 	}
 }
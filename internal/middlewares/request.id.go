@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"regexp"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const requestIdHeader = "X-Request-Id"
+
+// validRequestId keeps ids set by the caller or a proxy only when they are safe to log and store
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestId tags the request with the id from the X-Request-Id header, or a new one, and echoes it in the response
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		requestId := c.GetHeader(requestIdHeader)
+		if !validRequestId.MatchString(requestId) {
+			requestId = uuid.NewString()
+		}
+
+		utils.SetRequestId(c, requestId)
+		c.Header(requestIdHeader, requestId)
+
+		c.Next()
+
+	}
+}
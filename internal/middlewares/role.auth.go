@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slog"
+)
+
+// AccessPolicy maps routes, as "METHOD /full/path" the way they are registered, to the roles allowed to call them
+type AccessPolicy map[string][]constants.Role
+
+// RoleAuthorization runs after Authorization and lets the request through when the caller holds one of the roles
+// the policy requires for the route. Routes missing from the policy are forbidden, so a new route cannot be
+// reachable before its roles are decided.
+func RoleAuthorization(policy AccessPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		route := c.Request.Method + " " + c.FullPath()
+
+		required, ok := policy[route]
+		if !ok {
+			slog.Error("route missing from access policy", "route", route)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+
+		roles := utils.GetRoles(c)
+		if !utils.HasAnyRole(roles, required) {
+			userId, _ := utils.GetUserId(c)
+			slog.Error("caller lacks the roles for the route", "route", route, "userId", userId, "roles", roles, "required", required)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+
+	}
+}
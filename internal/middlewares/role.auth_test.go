@@ -0,0 +1,85 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeFirebaseClient verifies the tokens it was given the identities of
+type fakeFirebaseClient struct {
+	config.FirebaseClient
+	identities map[string]*models.FirebaseIdentity
+}
+
+func (c *fakeFirebaseClient) VerifyIDToken(ctx context.Context, idToken string) (*models.FirebaseIdentity, error) {
+	identity, ok := c.identities[idToken]
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	return identity, nil
+}
+
+func TestRoleAuthorization(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	firebaseClient := &fakeFirebaseClient{identities: map[string]*models.FirebaseIdentity{
+		"user":    {UID: "user-1", CustomClaims: map[string]interface{}{}},
+		"support": {UID: "support-1", CustomClaims: map[string]interface{}{constants.RolesClaim: []interface{}{constants.RoleSupport.String()}}},
+		"admin":   {UID: "admin-1", CustomClaims: map[string]interface{}{constants.RolesClaim: constants.RoleAdmin.String()}},
+		"unknown": {UID: "user-2", CustomClaims: map[string]interface{}{constants.RolesClaim: []interface{}{"superuser", 7}}},
+	}}
+	policy := AccessPolicy{
+		"GET /api/user/":           {constants.RoleUser},
+		"GET /api/admin/users":     {constants.RoleSupport},
+		"DELETE /api/admin/users/": {constants.RoleAdmin},
+	}
+
+	router := gin.New()
+	router.Use(Authorization(firebaseClient), RoleAuthorization(policy))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/user/", ok)
+	router.GET("/api/admin/users", ok)
+	router.DELETE("/api/admin/users/", ok)
+	router.GET("/api/unlisted", ok)
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		path   string
+		status int
+	}{
+		{"user on a self route", "user", http.MethodGet, "/api/user/", http.StatusOK},
+		{"no roles claim on a support route", "user", http.MethodGet, "/api/admin/users", http.StatusForbidden},
+		{"support on a support route", "support", http.MethodGet, "/api/admin/users", http.StatusOK},
+		{"support on an admin route", "support", http.MethodDelete, "/api/admin/users/", http.StatusForbidden},
+		{"admin on a support route", "admin", http.MethodGet, "/api/admin/users", http.StatusOK},
+		{"admin on an admin route", "admin", http.MethodDelete, "/api/admin/users/", http.StatusOK},
+		{"unknown roles claim", "unknown", http.MethodGet, "/api/admin/users", http.StatusForbidden},
+		{"route missing from the policy", "admin", http.MethodGet, "/api/unlisted", http.StatusForbidden},
+		{"invalid token", "forged", http.MethodGet, "/api/user/", http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, test.path, nil)
+			request.Header.Set("Authorization", "Bearer "+test.token)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.status {
+				t.Errorf("got status %d, want %d", recorder.Code, test.status)
+			}
+		})
+	}
+
+}
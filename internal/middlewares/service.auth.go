@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slog"
+)
+
+// ServiceAuthorization guards service-to-service routes with a shared API key sent in the X-API-Key header.
+// An empty apiKey rejects every request, so a missing configuration never opens the routes up.
+func ServiceAuthorization(apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		key := c.GetHeader("X-API-Key")
+
+		if apiKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+			slog.Error("invalid service API key", "path", c.Request.URL.Path, "ip", c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+	}
+}
@@ -30,6 +30,7 @@ func JSONlogger() gin.HandlerFunc {
 			slog.String("method", c.Request.Method),
 			slog.String("path", path),
 			slog.String("user-id", userId),
+			slog.String("request-id", utils.GetRequestId(c)),
 			slog.String("ip", c.ClientIP()),
 			slog.Duration("latency", latency),
 			slog.String("user-agent", c.Request.UserAgent()),
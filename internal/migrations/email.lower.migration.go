@@ -0,0 +1,101 @@
+package migrations
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// emailLowerMigration fills in the lowercased email the admin search matches against for users who have not
+// logged in since it was added. It is lowercased here rather than with $toLower, which only handles ASCII,
+// so it matches what Upsert stores.
+func emailLowerMigration() Migration {
+	return Migration{
+		Version:     4,
+		Description: "store a lowercased copy of the email for case-insensitive search",
+		Up:          migrateEmailLowerUp,
+		Down:        migrateEmailLowerDown,
+	}
+}
+
+func migrateEmailLowerUp(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error) {
+
+	collection := database.Collection(os.Getenv("USER_COLLECTION"))
+	filter := bson.M{"email": bson.M{"$exists": true}, "emailLower": bson.M{"$exists": false}}
+
+	if dryRun {
+		return collection.CountDocuments(ctx, filter)
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"email": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var count int64
+	updates := []mongo.WriteModel{}
+	flush := func() error {
+		if len(updates) == 0 {
+			return nil
+		}
+		bulkResult, err := collection.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return err
+		}
+		count += bulkResult.ModifiedCount
+		updates = updates[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var user struct {
+			Id    interface{} `bson:"_id"`
+			Email string      `bson:"email"`
+		}
+		err := cursor.Decode(&user)
+		if err != nil {
+			return count, err
+		}
+
+		updates = append(updates, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": user.Id}).
+			SetUpdate(bson.M{"$set": bson.M{"emailLower": strings.ToLower(user.Email)}}))
+
+		if len(updates) == 500 {
+			err = flush()
+			if err != nil {
+				return count, err
+			}
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return count, err
+	}
+
+	return count, flush()
+
+}
+
+func migrateEmailLowerDown(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error) {
+
+	collection := database.Collection(os.Getenv("USER_COLLECTION"))
+	filter := bson.M{"emailLower": bson.M{"$exists": true}}
+
+	if dryRun {
+		return collection.CountDocuments(ctx, filter)
+	}
+
+	updatedResult, err := collection.UpdateMany(ctx, filter, bson.M{"$unset": bson.M{"emailLower": ""}})
+	if err != nil {
+		return 0, err
+	}
+
+	return updatedResult.ModifiedCount, nil
+
+}
@@ -0,0 +1,95 @@
+package migrations
+
+import (
+	"context"
+	"os"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fcmTokensMigration moves the tokens written to the misnamed "FCMtokens" field, which the user model never read,
+// into "fcmTokens" device records. Their device is unknown and they count as refreshed now, so pruning only
+// drops them once clients stop refreshing them.
+func fcmTokensMigration() Migration {
+	return Migration{
+		Version:     1,
+		Description: "move FCMtokens into fcmTokens device records",
+		Up:          migrateFCMtokensUp,
+		Down:        migrateFCMtokensDown,
+	}
+}
+
+func migrateFCMtokensUp(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error) {
+
+	collection := database.Collection(os.Getenv("USER_COLLECTION"))
+	filter := bson.M{"FCMtokens": bson.M{"$exists": true}}
+
+	if dryRun {
+		return collection.CountDocuments(ctx, filter)
+	}
+
+	legacyTokens := bson.M{"$ifNull": bson.A{"$FCMtokens", bson.A{}}}
+	knownTokens := bson.M{"$ifNull": bson.A{"$fcmTokens.token", bson.A{}}}
+	update := bson.A{
+		bson.M{
+			"$set": bson.M{
+				"fcmTokens": bson.M{
+					"$concatArrays": bson.A{
+						bson.M{"$ifNull": bson.A{"$fcmTokens", bson.A{}}},
+						bson.M{
+							"$map": bson.M{
+								"input": bson.M{
+									"$filter": bson.M{
+										"input": legacyTokens,
+										"as":    "token",
+										"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$token", knownTokens}}}},
+									},
+								},
+								"as": "token",
+								"in": bson.M{
+									"token":           "$$token",
+									"createdAt":       "$$NOW",
+									"lastRefreshedAt": "$$NOW",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		bson.M{"$unset": "FCMtokens"},
+	}
+
+	updatedResult, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return updatedResult.ModifiedCount, nil
+
+}
+
+// migrateFCMtokensDown puts the bare tokens back, the device metadata is lost
+func migrateFCMtokensDown(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error) {
+
+	collection := database.Collection(os.Getenv("USER_COLLECTION"))
+	filter := bson.M{"fcmTokens": bson.M{"$exists": true}}
+
+	if dryRun {
+		return collection.CountDocuments(ctx, filter)
+	}
+
+	update := bson.A{
+		bson.M{"$set": bson.M{"FCMtokens": "$fcmTokens.token"}},
+		bson.M{"$unset": "fcmTokens"},
+	}
+
+	updatedResult, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return updatedResult.ModifiedCount, nil
+
+}
@@ -0,0 +1,145 @@
+package migrations
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// legacyWebhooksMigration turns the plain URL list once stored on the user into registry webhooks.
+// Their generated secrets were never shown to anyone, so users have to rotate them before verifying signatures.
+func legacyWebhooksMigration() Migration {
+	return Migration{
+		Version:     3,
+		Description: "move the users' webhooks list into the webhook registry",
+		Up:          migrateLegacyWebhooksUp,
+		Down:        migrateLegacyWebhooksDown,
+	}
+}
+
+func migrateLegacyWebhooksUp(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error) {
+
+	collection := database.Collection(os.Getenv("USER_COLLECTION"))
+	webhookCollection := database.Collection(os.Getenv("WEBHOOK_COLLECTION"))
+	filter := bson.M{"webhooks": bson.M{"$exists": true}}
+
+	if dryRun {
+		return collection.CountDocuments(ctx, filter)
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var count int64
+	for cursor.Next(ctx) {
+		var user struct {
+			UserId   string   `bson:"userId"`
+			Webhooks []string `bson:"webhooks"`
+		}
+		err := cursor.Decode(&user)
+		if err != nil {
+			return count, err
+		}
+
+		for _, url := range user.Webhooks {
+			err := insertLegacyWebhook(ctx, webhookCollection, user.UserId, url)
+			if err != nil {
+				return count, err
+			}
+		}
+
+		_, err = collection.UpdateOne(ctx, bson.M{"userId": user.UserId}, bson.M{"$unset": bson.M{"webhooks": ""}})
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, cursor.Err()
+
+}
+
+// insertLegacyWebhook skips URLs the user has registered already, so a migration interrupted halfway can run again
+func insertLegacyWebhook(ctx context.Context, webhookCollection *mongo.Collection, userId string, url string) error {
+
+	existing, err := webhookCollection.CountDocuments(ctx, bson.M{"userId": userId, "url": url})
+	if err != nil || existing > 0 {
+		return err
+	}
+
+	secret, err := utils.GenerateToken(32)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	_, err = webhookCollection.InsertOne(ctx, models.Webhook{
+		Id:          primitive.NewObjectID(),
+		UserId:      userId,
+		URL:         url,
+		Description: "Migrated from the webhooks list",
+		EventTypes:  []string{},
+		Enabled:     true,
+		Secrets: []models.WebhookSecret{
+			{Secret: "whsec_" + secret, CreatedAt: now},
+		},
+		Verification: models.WebhookVerification{
+			Status:        constants.WebhookVerificationPending.String(),
+			NextAttemptAt: now,
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+
+	return err
+
+}
+
+// migrateLegacyWebhooksDown writes the registered URLs back onto the users and leaves the registry as it is
+func migrateLegacyWebhooksDown(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error) {
+
+	collection := database.Collection(os.Getenv("USER_COLLECTION"))
+	webhookCollection := database.Collection(os.Getenv("WEBHOOK_COLLECTION"))
+
+	cursor, err := webhookCollection.Aggregate(ctx, bson.A{
+		bson.M{"$sort": bson.M{"createdAt": 1}},
+		bson.M{"$group": bson.M{"_id": "$userId", "urls": bson.M{"$push": "$url"}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var count int64
+	for cursor.Next(ctx) {
+		var webhooks struct {
+			UserId string   `bson:"_id"`
+			URLs   []string `bson:"urls"`
+		}
+		err := cursor.Decode(&webhooks)
+		if err != nil {
+			return count, err
+		}
+
+		if !dryRun {
+			_, err = collection.UpdateOne(ctx, bson.M{"userId": webhooks.UserId}, bson.M{"$set": bson.M{"webhooks": webhooks.URLs}})
+			if err != nil {
+				return count, err
+			}
+		}
+		count++
+	}
+
+	return count, cursor.Err()
+
+}
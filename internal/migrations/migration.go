@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migration moves existing documents from one schema version to the next.
+// Up and Down return how many documents they changed or, when dryRun is set, how many they would change without changing them.
+// A nil Down makes the migration irreversible.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error)
+	Down        func(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error)
+}
+
+var (
+	ErrIrreversibleMigration = errors.New("migration cannot be rolled back")
+	ErrDuplicateMigration    = errors.New("migration version registered twice")
+	ErrMigrationLocked       = errors.New("another run is migrating the database")
+)
+
+// GetMigrations lists every migration in the order they are applied, new migrations are appended with the next version
+func GetMigrations() []Migration {
+
+	migrations := []Migration{
+		fcmTokensMigration(),
+		webhookVerificationMigration(),
+		legacyWebhooksMigration(),
+		emailLowerMigration(),
+	}
+
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations
+
+}
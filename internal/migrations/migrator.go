@@ -0,0 +1,218 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/exp/slog"
+)
+
+type Migrator interface {
+	Status() error
+	Up(target int, dryRun bool) error
+	Down(target int, dryRun bool) error
+}
+
+type migrator struct {
+	database                *mongo.Database
+	migrationRepository     repositories.MigrationRepository
+	migrationLockRepository repositories.MigrationLockRepository
+	migrations              []Migration
+	timeout                 time.Duration
+	lockTTL                 time.Duration
+}
+
+func NewMigrator(database *mongo.Database, migrationRepository repositories.MigrationRepository, migrationLockRepository repositories.MigrationLockRepository, migrations []Migration) Migrator {
+
+	timeout := config.GetEnvDuration("MIGRATION_TIMEOUT", 10*time.Minute)
+
+	return &migrator{
+		database:                database,
+		migrationRepository:     migrationRepository,
+		migrationLockRepository: migrationLockRepository,
+		migrations:              migrations,
+		timeout:                 timeout,
+		// by default the lock outlives a run in which every migration takes its full timeout
+		lockTTL: config.GetEnvDuration("MIGRATION_LOCK_TTL", time.Duration(len(migrations)+1)*timeout),
+	}
+
+}
+
+// Status logs every registered migration and whether it has been applied
+func (m *migrator) Status() error {
+
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		appliedMigration, ok := applied[migration.Version]
+		if ok {
+			slog.Info("Migration applied", "version", migration.Version, "description", migration.Description, "appliedAt", appliedMigration.AppliedAt)
+		} else {
+			slog.Info("Migration pending", "version", migration.Version, "description", migration.Description)
+		}
+	}
+
+	return nil
+
+}
+
+// Up applies the pending migrations in order up to and including target, a target below 1 applies all of them.
+// With dryRun set later migrations count against the documents as they are now, before the earlier ones ran.
+func (m *migrator) Up(target int, dryRun bool) error {
+
+	release, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		count, err := m.run(migration.Up, dryRun)
+		if err != nil {
+			slog.Error("Failed to apply migration", "error", err, "version", migration.Version, "description", migration.Description)
+			return err
+		}
+
+		if dryRun {
+			slog.Info("Migration would be applied", "version", migration.Version, "description", migration.Description, "documents", count)
+			continue
+		}
+
+		err = m.migrationRepository.Insert(&models.AppliedMigration{Version: migration.Version, Description: migration.Description})
+		if err != nil {
+			return err
+		}
+
+		slog.Info("Applied migration", "version", migration.Version, "description", migration.Description, "documents", count)
+	}
+
+	return nil
+
+}
+
+// Down rolls back the applied migrations above target, newest first
+func (m *migrator) Down(target int, dryRun bool) error {
+
+	release, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if migration.Down == nil {
+			slog.Error("Failed to roll back migration", "error", ErrIrreversibleMigration, "version", migration.Version, "description", migration.Description)
+			return fmt.Errorf("%w: %d", ErrIrreversibleMigration, migration.Version)
+		}
+
+		count, err := m.run(migration.Down, dryRun)
+		if err != nil {
+			slog.Error("Failed to roll back migration", "error", err, "version", migration.Version, "description", migration.Description)
+			return err
+		}
+
+		if dryRun {
+			slog.Info("Migration would be rolled back", "version", migration.Version, "description", migration.Description, "documents", count)
+			continue
+		}
+
+		err = m.migrationRepository.Delete(migration.Version)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("Rolled back migration", "version", migration.Version, "description", migration.Description, "documents", count)
+	}
+
+	return nil
+
+}
+
+// lock keeps a second one-time-setup run from applying the same migrations concurrently, the returned func releases it
+func (m *migrator) lock() (func(), error) {
+
+	owner := primitive.NewObjectID().Hex()
+
+	err := m.migrationLockRepository.Acquire(owner, m.lockTTL)
+	if mongo.IsDuplicateKeyError(err) {
+		slog.Error("Failed to acquire migration lock, another run holds it")
+		return nil, ErrMigrationLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if err := m.migrationLockRepository.Release(owner); err != nil {
+			slog.Error("Migration lock could not be released, it is taken over once it expires", "error", err, "owner", owner)
+		}
+	}, nil
+
+}
+
+func (m *migrator) run(step func(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error), dryRun bool) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	return step(ctx, m.database, dryRun)
+
+}
+
+func (m *migrator) applied() (map[int]models.AppliedMigration, error) {
+
+	seen := make(map[int]struct{}, len(m.migrations))
+	for _, migration := range m.migrations {
+		if _, ok := seen[migration.Version]; ok {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateMigration, migration.Version)
+		}
+		seen[migration.Version] = struct{}{}
+	}
+
+	appliedMigrations, err := m.migrationRepository.FindApplied()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]models.AppliedMigration, len(appliedMigrations))
+	for _, appliedMigration := range appliedMigrations {
+		applied[appliedMigration.Version] = appliedMigration
+	}
+
+	return applied, nil
+
+}
@@ -0,0 +1,188 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type fakeMigrationRepository struct {
+	repositories.MigrationRepository
+	applied map[int]models.AppliedMigration
+}
+
+func (r *fakeMigrationRepository) FindApplied() ([]models.AppliedMigration, error) {
+	migrations := []models.AppliedMigration{}
+	for _, migration := range r.applied {
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+func (r *fakeMigrationRepository) Insert(migration *models.AppliedMigration) error {
+	r.applied[migration.Version] = *migration
+	return nil
+}
+
+func (r *fakeMigrationRepository) Delete(version int) error {
+	delete(r.applied, version)
+	return nil
+}
+
+// fakeMigrationLockRepository answers a held lock with the duplicate key error the unique _id gives
+type fakeMigrationLockRepository struct {
+	owner string
+}
+
+func (r *fakeMigrationLockRepository) Acquire(owner string, ttl time.Duration) error {
+	if r.owner != "" {
+		return mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+	}
+	r.owner = owner
+	return nil
+}
+
+func (r *fakeMigrationLockRepository) Release(owner string) error {
+	if r.owner == owner {
+		r.owner = ""
+	}
+	return nil
+}
+
+// recorder registers migrations that log which step ran and whether it was a dry run
+type recorder struct {
+	steps []string
+}
+
+func (r *recorder) migration(version int) Migration {
+
+	step := func(name string) func(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error) {
+		return func(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error) {
+			if dryRun {
+				name += " (dry run)"
+			}
+			r.steps = append(r.steps, name)
+			return 1, nil
+		}
+	}
+
+	return Migration{
+		Version: version,
+		Up:      step("up " + strconv.Itoa(version)),
+		Down:    step("down " + strconv.Itoa(version)),
+	}
+
+}
+
+func newTestMigrator(recorder *recorder, applied ...int) (*migrator, *fakeMigrationRepository, *fakeMigrationLockRepository) {
+
+	migrationRepository := &fakeMigrationRepository{applied: map[int]models.AppliedMigration{}}
+	for _, version := range applied {
+		migrationRepository.applied[version] = models.AppliedMigration{Version: version}
+	}
+	migrationLockRepository := &fakeMigrationLockRepository{}
+
+	return &migrator{
+		migrationRepository:     migrationRepository,
+		migrationLockRepository: migrationLockRepository,
+		migrations:              []Migration{recorder.migration(1), recorder.migration(2), recorder.migration(3)},
+		timeout:                 time.Second,
+		lockTTL:                 time.Minute,
+	}, migrationRepository, migrationLockRepository
+
+}
+
+func TestMigratorOrder(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		applied []int
+		migrate func(m *migrator) error
+		steps   []string
+		after   []int
+	}{
+		{"up applies pending in order", []int{1}, func(m *migrator) error { return m.Up(0, false) }, []string{"up 2", "up 3"}, []int{1, 2, 3}},
+		{"up stops at the target", nil, func(m *migrator) error { return m.Up(2, false) }, []string{"up 1", "up 2"}, []int{1, 2}},
+		{"down rolls back newest first", []int{1, 2, 3}, func(m *migrator) error { return m.Down(1, false) }, []string{"down 3", "down 2"}, []int{1}},
+		{"down skips pending", []int{1, 3}, func(m *migrator) error { return m.Down(0, false) }, []string{"down 3", "down 1"}, []int{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := &recorder{}
+			m, migrationRepository, migrationLockRepository := newTestMigrator(recorder, test.applied...)
+
+			err := test.migrate(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(recorder.steps, test.steps) {
+				t.Errorf("ran %v, want %v", recorder.steps, test.steps)
+			}
+			after := []int{}
+			for _, migration := range m.migrations {
+				if _, ok := migrationRepository.applied[migration.Version]; ok {
+					after = append(after, migration.Version)
+				}
+			}
+			if !reflect.DeepEqual(after, test.after) {
+				t.Errorf("applied %v afterwards, want %v", after, test.after)
+			}
+			if migrationLockRepository.owner != "" {
+				t.Error("lock not released")
+			}
+		})
+	}
+
+}
+
+func TestMigratorDryRun(t *testing.T) {
+
+	recorder := &recorder{}
+	m, migrationRepository, _ := newTestMigrator(recorder, 1)
+
+	err := m.Up(0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Down(0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"up 2 (dry run)", "up 3 (dry run)", "down 1 (dry run)"}
+	if !reflect.DeepEqual(recorder.steps, want) {
+		t.Errorf("ran %v, want %v", recorder.steps, want)
+	}
+	if len(migrationRepository.applied) != 1 {
+		t.Errorf("dry run recorded %d applied migrations, want 1", len(migrationRepository.applied))
+	}
+
+}
+
+func TestMigratorLocked(t *testing.T) {
+
+	recorder := &recorder{}
+	m, migrationRepository, migrationLockRepository := newTestMigrator(recorder)
+	migrationLockRepository.owner = "other run"
+
+	err := m.Up(0, false)
+	if !errors.Is(err, ErrMigrationLocked) {
+		t.Fatalf("got error %v, want %v", err, ErrMigrationLocked)
+	}
+	if len(recorder.steps) != 0 || len(migrationRepository.applied) != 0 {
+		t.Error("migrated without the lock")
+	}
+	if migrationLockRepository.owner != "other run" {
+		t.Error("released a lock held by another run")
+	}
+
+}
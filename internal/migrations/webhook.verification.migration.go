@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// webhookVerificationMigration queues the handshake for webhooks registered before verification existed,
+// they receive no deliveries until they pass it
+func webhookVerificationMigration() Migration {
+	return Migration{
+		Version:     2,
+		Description: "queue verification for unverified webhooks",
+		Up:          migrateWebhookVerificationUp,
+		Down:        migrateWebhookVerificationDown,
+	}
+}
+
+func migrateWebhookVerificationUp(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error) {
+
+	collection := database.Collection(os.Getenv("WEBHOOK_COLLECTION"))
+	filter := bson.M{"verification": bson.M{"$exists": false}}
+
+	if dryRun {
+		return collection.CountDocuments(ctx, filter)
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"verification": models.WebhookVerification{
+				Status:        constants.WebhookVerificationPending.String(),
+				NextAttemptAt: time.Now().UTC(),
+			},
+		},
+	}
+
+	updatedResult, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return updatedResult.ModifiedCount, nil
+
+}
+
+// migrateWebhookVerificationDown has nothing to undo, code from before verification ignores the field
+func migrateWebhookVerificationDown(ctx context.Context, database *mongo.Database, dryRun bool) (int64, error) {
+	return 0, nil
+}
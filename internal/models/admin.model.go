@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// UserQuery filters the users an operator lists, every filter is optional and they all have to match.
+// Phone matches either the WhatsApp or the Telegram number.
+type UserQuery struct {
+	UserId                string    `form:"userId"`
+	Email                 string    `form:"email" binding:"omitempty,email"`
+	Phone                 string    `form:"phone" binding:"omitempty,e164"`
+	DiscordId             string    `form:"discordId"`
+	NotificationInterface string    `form:"notificationInterface" binding:"omitempty,is-notification-interface-valid"`
+	CreatedFrom           time.Time `form:"createdFrom" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo             time.Time `form:"createdTo" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page                  int       `form:"page" binding:"omitempty,min=1"`
+	Limit                 int       `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type UsersResponse struct {
+	Users []User `json:"users"`
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
+	Total int64  `json:"total"`
+}
+
+// AdminUserRequest edits the channel fields of any user, only the fields sent are changed.
+// Numbers set here are not verified, the user still has to confirm them before they receive notifications.
+type AdminUserRequest struct {
+	WhatsAppNumber          *string              `json:"whatsAppNumber" binding:"omitempty,e164"`
+	TelegramNumber          *string              `json:"telegramNumber" binding:"omitempty,e164"`
+	TelegramChatId          *int64               `json:"telegramChatId"`
+	DiscordId               *string              `json:"discordId"`
+	DiscordUsername         *string              `json:"discordUsername"`
+	NotificationInterfaces  *[]string            `json:"notificationInterfaces" binding:"omitempty,are-notification-interfaces-valid"`
+	NotificationPreferences *map[string][]string `json:"notificationPreferences" binding:"omitempty,are-notification-preferences-valid"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actor is whoever made a change: the user, an operator, another service or one of this service's jobs
+type Actor struct {
+	UserId    string `json:"userId" bson:"userId"`
+	Role      string `json:"role" bson:"role"`
+	RequestId string `json:"requestId,omitempty" bson:"requestId,omitempty"`
+	IP        string `json:"ip,omitempty" bson:"ip,omitempty"`
+}
+
+// AuditEntry records the change of a single field of a user, the values are masked before they are stored
+type AuditEntry struct {
+	Id        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserId    string             `json:"userId" bson:"userId"`
+	Actor     Actor              `json:"actor" bson:"actor"`
+	Field     string             `json:"field" bson:"field"`
+	OldValue  interface{}        `json:"oldValue,omitempty" bson:"oldValue,omitempty"`
+	NewValue  interface{}        `json:"newValue,omitempty" bson:"newValue,omitempty"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// AuditQuery filters the audit trail, users only ever see their own entries whatever userId they ask for
+type AuditQuery struct {
+	UserId  string    `form:"userId"`
+	ActorId string    `form:"actorId"`
+	Field   string    `form:"field"`
+	From    time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To      time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page    int       `form:"page" binding:"omitempty,min=1"`
+	Limit   int       `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type AuditResponse struct {
+	Entries []AuditEntry `json:"entries"`
+	Page    int          `json:"page"`
+	Limit   int          `json:"limit"`
+	Total   int64        `json:"total"`
+}
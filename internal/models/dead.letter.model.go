@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeadLetter is a message collected from a dead-letter queue, kept with the exchange and routing key it was first published to
+type DeadLetter struct {
+	Id             primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	Queue          string                 `json:"queue" bson:"queue"`
+	SourceQueue    string                 `json:"sourceQueue,omitempty" bson:"sourceQueue,omitempty"`
+	Reason         string                 `json:"reason" bson:"reason"`
+	Exchange       string                 `json:"exchange" bson:"exchange"`
+	RoutingKey     string                 `json:"routingKey" bson:"routingKey"`
+	MessageId      string                 `json:"messageId" bson:"messageId"`
+	UserId         string                 `json:"userId,omitempty" bson:"userId,omitempty"`
+	CorrelationId  string                 `json:"correlationId,omitempty" bson:"correlationId,omitempty"`
+	ContentType    string                 `json:"contentType" bson:"contentType"`
+	Headers        map[string]interface{} `json:"headers,omitempty" bson:"headers,omitempty"`
+	Body           []byte                 `json:"body" bson:"body"`
+	Attempts       int                    `json:"attempts" bson:"attempts"`
+	Status         string                 `json:"status" bson:"status"`
+	DeadLetteredAt time.Time              `json:"deadLetteredAt" bson:"deadLetteredAt"`
+	ReplayedAt     time.Time              `json:"replayedAt,omitempty" bson:"replayedAt,omitempty"`
+	ReplayedBy     *Actor                 `json:"replayedBy,omitempty" bson:"replayedBy,omitempty"`
+}
+
+type DeadLetterQuery struct {
+	Queue      string `form:"queue"`
+	RoutingKey string `form:"routingKey"`
+	Status     string `form:"status" binding:"omitempty,oneof=pending replayed"`
+	Page       int    `form:"page" binding:"omitempty,min=1"`
+	Limit      int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type DeadLetterResponse struct {
+	DeadLetters []DeadLetter `json:"deadLetters"`
+	Page        int          `json:"page"`
+	Limit       int          `json:"limit"`
+	Total       int64        `json:"total"`
+}
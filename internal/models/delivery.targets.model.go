@@ -0,0 +1,18 @@
+package models
+
+type DeliveryTargets struct {
+	UserId                 string          `json:"userId"`
+	EventType              string          `json:"eventType"`
+	NotificationInterfaces []string        `json:"notificationInterfaces"`
+	Email                  string          `json:"email,omitempty"`
+	FCMtokens              []string        `json:"fcmTokens,omitempty"`
+	WhatsAppNumber         string          `json:"whatsAppNumber,omitempty"`
+	DiscordId              string          `json:"discordId,omitempty"`
+	TelegramNumber         string          `json:"telegramNumber,omitempty"`
+	TelegramChatId         int64           `json:"telegramChatId,omitempty"`
+	Webhooks               []WebhookTarget `json:"webhooks,omitempty"`
+}
+
+type DeliveryTargetsQuery struct {
+	EventType string `form:"eventType" binding:"required,is-notification-event-type-valid"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErasureJob tracks the deletion of a user, from the soft delete through the grace period
+// to every service acknowledging it erased its data
+type ErasureJob struct {
+	Id               primitive.ObjectID                 `json:"id" bson:"_id,omitempty"`
+	UserId           string                             `json:"userId" bson:"userId"`
+	Status           string                             `json:"status" bson:"status"`
+	RequestedAt      time.Time                          `json:"requestedAt" bson:"requestedAt"`
+	ScheduledFor     time.Time                          `json:"scheduledFor" bson:"scheduledFor"`
+	LeasedUntil      time.Time                          `json:"-" bson:"leasedUntil,omitempty"`
+	CancelledAt      time.Time                          `json:"cancelledAt,omitempty" bson:"cancelledAt,omitempty"`
+	ErasedAt         time.Time                          `json:"erasedAt,omitempty" bson:"erasedAt,omitempty"`
+	CompletedAt      time.Time                          `json:"completedAt,omitempty" bson:"completedAt,omitempty"`
+	Acknowledgements map[string]*ErasureAcknowledgement `json:"acknowledgements,omitempty" bson:"acknowledgements,omitempty"`
+}
+
+type ErasureAcknowledgement struct {
+	Status         string    `json:"status" bson:"status"`
+	Error          string    `json:"error,omitempty" bson:"error,omitempty"`
+	AcknowledgedAt time.Time `json:"acknowledgedAt,omitempty" bson:"acknowledgedAt,omitempty"`
+}
+
+// ErasureAcknowledgementMessage is sent by a service on the erasure reply queue once it handled a user.erasure_requested event
+type ErasureAcknowledgementMessage struct {
+	ErasureId string `json:"erasureId"`
+	UserId    string `json:"userId"`
+	Service   string `json:"service"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type UserEvent struct {
+	EventId       string                 `json:"eventId"`
+	EventType     string                 `json:"eventType"`
+	Version       int                    `json:"version"`
+	OccurredAt    time.Time              `json:"occurredAt"`
+	UserId        string                 `json:"userId"`
+	ChangedFields map[string]interface{} `json:"changedFields,omitempty"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportJob is a request for a copy of the user's data, the archive is kept in the job until it expires
+type ExportJob struct {
+	Id                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserId            string             `json:"userId" bson:"userId"`
+	Format            string             `json:"format" bson:"format"`
+	Status            string             `json:"status" bson:"status"`
+	Error             string             `json:"error,omitempty" bson:"error,omitempty"`
+	Attempts          int                `json:"-" bson:"attempts,omitempty"`
+	Size              int                `json:"size,omitempty" bson:"size,omitempty"`
+	Archive           []byte             `json:"-" bson:"archive,omitempty"`
+	DownloadTokenHash string             `json:"-" bson:"downloadTokenHash,omitempty"`
+	DownloadExpiresAt time.Time          `json:"-" bson:"downloadExpiresAt,omitempty"`
+	LeasedUntil       time.Time          `json:"-" bson:"leasedUntil,omitempty"`
+	RequestedAt       time.Time          `json:"requestedAt" bson:"requestedAt"`
+	CompletedAt       time.Time          `json:"completedAt,omitempty" bson:"completedAt,omitempty"`
+	ExpiresAt         time.Time          `json:"expiresAt" bson:"expiresAt"`
+}
+
+type ExportRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=json zip"`
+}
+
+// ExportResponse carries a download link once the archive is ready, every poll hands out a new link
+type ExportResponse struct {
+	*ExportJob
+	DownloadURL       string    `json:"downloadURL,omitempty"`
+	DownloadExpiresAt time.Time `json:"downloadExpiresAt,omitempty"`
+}
+
+// UserExport is everything this service holds about a user
+type UserExport struct {
+	ExportedAt        time.Time         `json:"exportedAt"`
+	Profile           ExportProfile     `json:"profile"`
+	Channels          ExportChannels    `json:"channels"`
+	FCMdevices        []FCMtoken        `json:"fcmDevices"`
+	Webhooks          []Webhook         `json:"webhooks"`
+	WebhookDeliveries []WebhookDelivery `json:"webhookDeliveries"`
+	PreferenceHistory []AuditEntry      `json:"preferenceHistory"`
+	AuditEntries      []AuditEntry      `json:"auditEntries"`
+	// Truncated names the sections that only hold the most recent entries, the rest did not fit in the export
+	Truncated []string `json:"truncated,omitempty"`
+}
+
+type ExportProfile struct {
+	UserId         string                 `json:"userId"`
+	Email          string                 `json:"email,omitempty"`
+	EmailVerified  bool                   `json:"emailVerified"`
+	DisplayName    string                 `json:"displayName,omitempty"`
+	PhotoURL       string                 `json:"photoURL,omitempty"`
+	SignInProvider string                 `json:"signInProvider,omitempty"`
+	CustomClaims   map[string]interface{} `json:"customClaims,omitempty"`
+	LastLoginAt    time.Time              `json:"lastLoginAt"`
+	LoginCount     int64                  `json:"loginCount"`
+	CreatedAt      time.Time              `json:"createdAt"`
+	UpdatedAt      time.Time              `json:"updatedAt"`
+}
+
+type ExportChannels struct {
+	NotificationInterfaces  []string                        `json:"notificationInterfaces"`
+	NotificationPreferences map[string][]string             `json:"notificationPreferences,omitempty"`
+	WhatsAppNumber          string                          `json:"whatsAppNumber,omitempty"`
+	DiscordId               string                          `json:"discordId,omitempty"`
+	DiscordUsername         string                          `json:"discordUsername,omitempty"`
+	TelegramNumber          string                          `json:"telegramNumber,omitempty"`
+	TelegramChatId          int64                           `json:"telegramChatId,omitempty"`
+	ChannelVerifications    map[string]*ChannelVerification `json:"channelVerifications,omitempty"`
+}
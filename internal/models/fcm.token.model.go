@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// FCMtoken is a registered push token together with the device it belongs to.
+// LastRefreshedAt is bumped every time the client registers the token again, stale tokens are pruned by it.
+type FCMtoken struct {
+	Token           string    `json:"token" bson:"token"`
+	DeviceLabel     string    `json:"deviceLabel,omitempty" bson:"deviceLabel,omitempty"`
+	Platform        string    `json:"platform,omitempty" bson:"platform,omitempty"`
+	UserAgent       string    `json:"userAgent,omitempty" bson:"userAgent,omitempty"`
+	CreatedAt       time.Time `json:"createdAt" bson:"createdAt"`
+	LastRefreshedAt time.Time `json:"lastRefreshedAt" bson:"lastRefreshedAt"`
+}
+
+type FCMtokenRequest struct {
+	FCMtoken    string `json:"fcmTokens" bson:"fcmTokens" binding:"required"`
+	DeviceLabel string `json:"deviceLabel" binding:"max=64"`
+	Platform    string `json:"platform" binding:"omitempty,is-fcm-platform-valid"`
+	UserAgent   string `json:"userAgent" binding:"max=512"`
+}
+
+type FCMtokenDeleteRequest struct {
+	FCMtoken string `json:"fcmTokens" bson:"fcmTokens" binding:"required"`
+}
+
+type FCMtokensResponse struct {
+	FCMtokens []FCMtoken `json:"fcmTokens"`
+}
+
+// FCMtokenErrorMessage is what the push sender reports for a token it failed to deliver to, UserId may be empty
+type FCMtokenErrorMessage struct {
+	UserId string `json:"userId"`
+	Token  string `json:"token"`
+	Error  string `json:"error"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// FirebaseIdentity is what a verified Firebase ID token says about the caller
+type FirebaseIdentity struct {
+	UID            string                 `json:"uid"`
+	Email          string                 `json:"email,omitempty"`
+	EmailVerified  bool                   `json:"emailVerified"`
+	DisplayName    string                 `json:"displayName,omitempty"`
+	PhotoURL       string                 `json:"photoURL,omitempty"`
+	SignInProvider string                 `json:"signInProvider,omitempty"`
+	CustomClaims   map[string]interface{} `json:"customClaims,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type HealthResponse struct {
+	Status    string                      `json:"status"`
+	Error     string                      `json:"error,omitempty"`
+	Checks    map[string]DependencyHealth `json:"checks,omitempty"`
+	CheckedAt time.Time                   `json:"checkedAt"`
+}
+
+// DependencyHealth is the result of probing a single dependency, the reason it failed is only logged
+type DependencyHealth struct {
+	Status string `json:"status"`
+}
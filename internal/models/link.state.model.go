@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type LinkState struct {
+	State     string    `json:"state" bson:"state"`
+	UserId    string    `json:"userId" bson:"userId"`
+	Provider  string    `json:"provider" bson:"provider"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt" bson:"expiresAt"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// AppliedMigration records a schema migration that has been applied to the database
+type AppliedMigration struct {
+	Version     int       `json:"version" bson:"version"`
+	Description string    `json:"description" bson:"description"`
+	AppliedAt   time.Time `json:"appliedAt" bson:"appliedAt"`
+}
+
+// MigrationLock is held by the one-time-setup run that is applying or rolling back migrations
+type MigrationLock struct {
+	Id        string    `json:"id" bson:"_id"`
+	Owner     string    `json:"owner" bson:"owner"`
+	LockedAt  time.Time `json:"lockedAt" bson:"lockedAt"`
+	ExpiresAt time.Time `json:"expiresAt" bson:"expiresAt"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OutboxMessage struct {
+	Id            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	MessageId     string             `json:"messageId" bson:"messageId"`
+	CorrelationId string             `json:"correlationId,omitempty" bson:"correlationId,omitempty"`
+	UserId        string             `json:"userId,omitempty" bson:"userId,omitempty"`
+	Exchange      string             `json:"exchange" bson:"exchange"`
+	RoutingKey    string             `json:"routingKey" bson:"routingKey"`
+	ContentType   string             `json:"contentType" bson:"contentType"`
+	Body          []byte             `json:"body" bson:"body"`
+	Status        string             `json:"status" bson:"status"`
+	Attempts      int                `json:"attempts" bson:"attempts"`
+	LastError     string             `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	NextAttemptAt time.Time          `json:"nextAttemptAt" bson:"nextAttemptAt"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	PublishedAt   time.Time          `json:"publishedAt,omitempty" bson:"publishedAt,omitempty"`
+	ParkedAt      time.Time          `json:"parkedAt,omitempty" bson:"parkedAt,omitempty"`
+}
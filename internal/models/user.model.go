@@ -3,15 +3,29 @@ package models
 import "time"
 
 type User struct {
-	UserId                 string    `json:"userId" bson:"userId"`
-	NotificationInterfaces []string  `json:"notificationInterfaces,omitempty" bson:"notificationInterfaces,omitempty"`
-	FCMtokens              []string  `json:"fcmTokens,omitempty" bson:"fcmTokens,omitempty"`
-	WhatsAppNumber         string    `json:"whatsAppNumber,omitempty" bson:"whatsAppNumber,omitempty"`
-	DiscordId              string    `json:"discordId,omitempty" bson:"discordId,omitempty"`
-	TelegramNumber         string    `json:"telegramNumber,omitempty" bson:"telegramNumber,omitempty"`
-	Webhooks               []string  `json:"webhooks,omitempty" bson:"webhooks,omitempty"`
-	CreatedAt              time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt              time.Time `json:"updatedAt" bson:"updatedAt"`
+	UserId                  string                          `json:"userId" bson:"userId"`
+	Email                   string                          `json:"email,omitempty" bson:"email,omitempty"`
+	EmailLower              string                          `json:"-" bson:"emailLower,omitempty"`
+	EmailVerified           bool                            `json:"emailVerified" bson:"emailVerified"`
+	DisplayName             string                          `json:"displayName,omitempty" bson:"displayName,omitempty"`
+	PhotoURL                string                          `json:"photoURL,omitempty" bson:"photoURL,omitempty"`
+	SignInProvider          string                          `json:"signInProvider,omitempty" bson:"signInProvider,omitempty"`
+	CustomClaims            map[string]interface{}          `json:"customClaims,omitempty" bson:"customClaims,omitempty"`
+	NotificationInterfaces  []string                        `json:"notificationInterfaces,omitempty" bson:"notificationInterfaces,omitempty"`
+	NotificationPreferences map[string][]string             `json:"notificationPreferences,omitempty" bson:"notificationPreferences,omitempty"`
+	FCMtokens               []FCMtoken                      `json:"fcmTokens,omitempty" bson:"fcmTokens,omitempty"`
+	WhatsAppNumber          string                          `json:"whatsAppNumber,omitempty" bson:"whatsAppNumber,omitempty"`
+	DiscordId               string                          `json:"discordId,omitempty" bson:"discordId,omitempty"`
+	DiscordUsername         string                          `json:"discordUsername,omitempty" bson:"discordUsername,omitempty"`
+	TelegramNumber          string                          `json:"telegramNumber,omitempty" bson:"telegramNumber,omitempty"`
+	TelegramChatId          int64                           `json:"telegramChatId,omitempty" bson:"telegramChatId,omitempty"`
+	ChannelVerifications    map[string]*ChannelVerification `json:"channelVerifications,omitempty" bson:"channelVerifications,omitempty"`
+	LastLoginAt             time.Time                       `json:"lastLoginAt" bson:"lastLoginAt"`
+	LoginCount              int64                           `json:"loginCount" bson:"loginCount"`
+	CreatedAt               time.Time                       `json:"createdAt" bson:"createdAt"`
+	UpdatedAt               time.Time                       `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt               time.Time                       `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	ExportLockedAt          time.Time                       `json:"-" bson:"exportLockedAt,omitempty"`
 }
 
 type WhatsAppRequest struct {
@@ -19,25 +33,34 @@ type WhatsAppRequest struct {
 }
 
 type DiscordRequest struct {
-	DiscordId string `json:"discordId" bson:"discordId" binding:"required"`
+	DiscordId       string `json:"discordId" bson:"discordId"`
+	DiscordUsername string `json:"discordUsername" bson:"discordUsername"`
+}
+
+type DiscordCallbackRequest struct {
+	Code  string `form:"code" binding:"required"`
+	State string `form:"state" binding:"required"`
 }
 
 type TelegramRequest struct {
 	TelegramNumber string `json:"telegramNumber" bson:"telegramNumber" binding:"required,e164"`
 }
 
-type NotificationInterfacesRequest struct {
-	NotificationInterfaces []string `json:"notificationInterfaces" bson:"notificationInterfaces" binding:"required,are-notification-interfaces-valid"`
+type TelegramBindRequest struct {
+	Token  string `json:"token" binding:"required"`
+	ChatId int64  `json:"chatId" binding:"required"`
 }
 
-type FCMtokenRequest struct {
-	FCMtoken string `json:"fcmTokens" bson:"fcmTokens" binding:"required"`
+type TelegramBindResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
 
-type FCMtokensRequest struct {
-	FCMtokens []string `json:"fcmTokens" bson:"fcmTokens"`
+type NotificationInterfacesRequest struct {
+	NotificationInterfaces []string `json:"notificationInterfaces" bson:"notificationInterfaces" binding:"required,are-notification-interfaces-valid"`
 }
 
-type WebhooksRequest struct {
-	Webhooks []string `json:"webhooks" bson:"webhooks" binding:"required,are-webhooks-valid"`
+type NotificationPreferencesRequest struct {
+	NotificationPreferences map[string][]string `json:"notificationPreferences" bson:"notificationPreferences" binding:"required,are-notification-preferences-valid"`
 }
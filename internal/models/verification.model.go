@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type ChannelVerification struct {
+	Number         string    `json:"number" bson:"number"`
+	Status         string    `json:"status" bson:"status"`
+	CodeHash       string    `json:"-" bson:"codeHash,omitempty"`
+	Attempts       int       `json:"attempts" bson:"attempts"`
+	ExpiresAt      time.Time `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+	RequestedAt    time.Time `json:"requestedAt" bson:"requestedAt"`
+	VerifiedNumber string    `json:"verifiedNumber,omitempty" bson:"verifiedNumber,omitempty"`
+	VerifiedAt     time.Time `json:"verifiedAt,omitempty" bson:"verifiedAt,omitempty"`
+}
+
+type VerificationCodeMessage struct {
+	UserId    string    `json:"userId"`
+	Channel   string    `json:"channel"`
+	Number    string    `json:"number"`
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type VerificationCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type VerificationResponse struct {
+	Number    string    `json:"number"`
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+}
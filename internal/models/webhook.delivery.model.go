@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookDelivery is one attempt to deliver a payload to a webhook, kept in the delivery log
+type WebhookDelivery struct {
+	Id           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WebhookId    primitive.ObjectID `json:"webhookId" bson:"webhookId"`
+	UserId       string             `json:"userId" bson:"userId"`
+	EventId      string             `json:"eventId" bson:"eventId"`
+	EventType    string             `json:"eventType" bson:"eventType"`
+	Test         bool               `json:"test" bson:"test"`
+	URL          string             `json:"url" bson:"url"`
+	StatusCode   int                `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	LatencyMs    int64              `json:"latencyMs" bson:"latencyMs"`
+	ResponseBody string             `json:"responseBody,omitempty" bson:"responseBody,omitempty"`
+	Error        string             `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// WebhookPayload is the envelope posted to webhooks and signed with their secrets
+type WebhookPayload struct {
+	Id        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"createdAt"`
+	Test      bool                   `json:"test"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+type WebhookDeliveriesQuery struct {
+	Page  int `form:"page" binding:"omitempty,min=1,max=10000"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+	Total      int64             `json:"total"`
+}
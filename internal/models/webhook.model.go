@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Webhook struct {
+	Id           primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserId       string              `json:"userId" bson:"userId"`
+	URL          string              `json:"url" bson:"url"`
+	Description  string              `json:"description,omitempty" bson:"description,omitempty"`
+	EventTypes   []string            `json:"eventTypes" bson:"eventTypes"`
+	Enabled      bool                `json:"enabled" bson:"enabled"`
+	Secrets      []WebhookSecret     `json:"-" bson:"secrets"`
+	Verification WebhookVerification `json:"verification" bson:"verification"`
+	LastTestedAt time.Time           `json:"lastTestedAt,omitempty" bson:"lastTestedAt,omitempty"`
+	CreatedAt    time.Time           `json:"createdAt" bson:"createdAt"`
+	UpdatedAt    time.Time           `json:"updatedAt" bson:"updatedAt"`
+}
+
+// WebhookSecret is a signing secret, a rotated out secret keeps working until ExpiresAt
+type WebhookSecret struct {
+	Secret    string    `json:"-" bson:"secret"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+}
+
+// WebhookVerification tracks the challenge-response handshake, only verified webhooks receive deliveries
+type WebhookVerification struct {
+	Status        string    `json:"status" bson:"status"`
+	Attempts      int       `json:"attempts" bson:"attempts"`
+	LastError     string    `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	NextAttemptAt time.Time `json:"-" bson:"nextAttemptAt,omitempty"`
+	VerifiedAt    time.Time `json:"verifiedAt,omitempty" bson:"verifiedAt,omitempty"`
+}
+
+// WebhookChallenge is posted to the webhook URL, the receiver proves it controls the URL by echoing the challenge back
+type WebhookChallenge struct {
+	Type      string `json:"type"`
+	WebhookId string `json:"webhookId"`
+	Challenge string `json:"challenge"`
+}
+
+type WebhookChallengeResponse struct {
+	Challenge string `json:"challenge"`
+}
+
+type WebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,is-webhook-valid"`
+	Description string   `json:"description" binding:"max=256"`
+	EventTypes  []string `json:"eventTypes" binding:"are-notification-event-types-valid"`
+	Enabled     *bool    `json:"enabled"`
+}
+
+type WebhookPatchRequest struct {
+	URL         *string   `json:"url" binding:"omitempty,url,is-webhook-valid"`
+	Description *string   `json:"description" binding:"omitempty,max=256"`
+	EventTypes  *[]string `json:"eventTypes" binding:"omitempty,are-notification-event-types-valid"`
+	Enabled     *bool     `json:"enabled"`
+}
+
+type WebhookSecretRotationRequest struct {
+	OverlapSeconds *int `json:"overlapSeconds" binding:"omitempty,min=0,max=604800"`
+}
+
+// WebhookSecretResponse is the only response carrying the plain signing secret, returned on creation and rotation
+type WebhookSecretResponse struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type WebhookTarget struct {
+	Id                 string   `json:"id"`
+	URL                string   `json:"url"`
+	SignatureHeader    string   `json:"signatureHeader"`
+	SignatureAlgorithm string   `json:"signatureAlgorithm"`
+	Secrets            []string `json:"secrets"`
+}
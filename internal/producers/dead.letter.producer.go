@@ -0,0 +1,51 @@
+package producers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type DeadLetterProducer interface {
+	Replay(ctx context.Context, deadLetter *models.DeadLetter) error
+}
+
+type deadLetterProducer struct {
+	publisher Publisher
+}
+
+func NewDeadLetterProducer(publisher Publisher) DeadLetterProducer {
+	return &deadLetterProducer{
+		publisher: publisher,
+	}
+}
+
+// Replay publishes the dead letter again to the exchange and routing key it was first published to, under the same message id.
+// It starts over with no attempts made, the x- headers of the broker and of the retries are left out
+// but the user it is about is kept, so that the message can still be erased along with the user.
+func (producer *deadLetterProducer) Replay(ctx context.Context, deadLetter *models.DeadLetter) error {
+
+	headers := amqp.Table{}
+	for key, value := range deadLetter.Headers {
+		if strings.HasPrefix(key, "x-") && key != constants.UserIdHeader {
+			continue
+		}
+		switch value.(type) {
+		case string, bool, int32, int64, float64, time.Time:
+			headers[key] = value
+		}
+	}
+
+	return producer.publisher.Publish(ctx, deadLetter.Exchange, deadLetter.RoutingKey, amqp.Publishing{
+		Headers:       headers,
+		ContentType:   deadLetter.ContentType,
+		MessageId:     deadLetter.MessageId,
+		CorrelationId: deadLetter.CorrelationId,
+		Body:          deadLetter.Body,
+	})
+
+}
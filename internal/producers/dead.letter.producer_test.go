@@ -0,0 +1,47 @@
+package producers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type fakePublisher struct {
+	publishings []amqp.Publishing
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, exchange string, routingKey string, publishing amqp.Publishing) error {
+	p.publishings = append(p.publishings, publishing)
+	return nil
+}
+
+func TestReplayKeepsTheUserHeader(t *testing.T) {
+
+	publisher := &fakePublisher{}
+	producer := NewDeadLetterProducer(publisher)
+
+	err := producer.Replay(context.Background(), &models.DeadLetter{
+		Exchange:   "user.events",
+		RoutingKey: "user.updated",
+		Headers: map[string]interface{}{
+			constants.UserIdHeader:             "user-1",
+			constants.AttemptsHeader:           int64(3),
+			constants.OriginalRoutingKeyHeader: "user.updated",
+			"x-death":                          []interface{}{},
+			"traceparent":                      "00-trace-span-01",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := amqp.Table{constants.UserIdHeader: "user-1", "traceparent": "00-trace-span-01"}
+	if headers := publisher.publishings[0].Headers; !reflect.DeepEqual(headers, want) {
+		t.Errorf("got headers %v, want %v", headers, want)
+	}
+
+}
@@ -0,0 +1,42 @@
+package producers
+
+import (
+	"context"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type OutboxProducer interface {
+	Publish(ctx context.Context, message *models.OutboxMessage) error
+}
+
+type outboxProducer struct {
+	publisher Publisher
+}
+
+func NewOutboxProducer(publisher Publisher) OutboxProducer {
+	return &outboxProducer{
+		publisher: publisher,
+	}
+}
+
+// Publish relays the stored message, keeping its id so that consumers can drop the copies of a message relayed twice
+func (producer *outboxProducer) Publish(ctx context.Context, message *models.OutboxMessage) error {
+
+	var headers amqp.Table
+	if message.UserId != "" {
+		headers = amqp.Table{constants.UserIdHeader: message.UserId}
+	}
+
+	return producer.publisher.Publish(ctx, message.Exchange, message.RoutingKey, amqp.Publishing{
+		Headers:       headers,
+		ContentType:   message.ContentType,
+		MessageId:     message.MessageId,
+		CorrelationId: message.CorrelationId,
+		Timestamp:     message.CreatedAt,
+		Body:          message.Body,
+	})
+
+}
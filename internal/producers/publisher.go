@@ -0,0 +1,125 @@
+package producers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	ErrPublishNacked  = errors.New("message was nacked by the broker")
+	ErrUnroutable     = errors.New("message could not be routed to any queue")
+	ErrConfirmTimeout = errors.New("timed out waiting for the broker to confirm the message")
+)
+
+// PublishError tells which message could not be published, wrapping one of the errors above or the one of the channel
+type PublishError struct {
+	MessageId  string
+	Exchange   string
+	RoutingKey string
+	Err        error
+}
+
+func (e *PublishError) Error() string {
+	return fmt.Sprintf("failed to publish message %s to exchange %q with routing key %q: %s", e.MessageId, e.Exchange, e.RoutingKey, e.Err)
+}
+
+func (e *PublishError) Unwrap() error {
+	return e.Err
+}
+
+// Publisher publishes a message and waits until the broker has taken responsibility for it,
+// so that a nil error means the message is stored in at least one queue
+type Publisher interface {
+	Publish(ctx context.Context, exchange string, routingKey string, publishing amqp.Publishing) error
+}
+
+type publisher struct {
+	conn    config.AMQPconnection
+	timeout time.Duration
+}
+
+func NewPublisher(conn config.AMQPconnection) Publisher {
+	return &publisher{
+		conn:    conn,
+		timeout: config.GetEnvDuration("AMQP_PUBLISH_TIMEOUT", 5*time.Second),
+	}
+}
+
+// Publish sends the message as persistent and mandatory, filling in the message id, timestamp and correlation id when they are not set.
+// It waits for the confirm until ctx is done, or AMQP_PUBLISH_TIMEOUT when ctx has no deadline.
+func (p *publisher) Publish(ctx context.Context, exchange string, routingKey string, publishing amqp.Publishing) error {
+
+	if publishing.MessageId == "" {
+		publishing.MessageId = uuid.NewString()
+	}
+	if publishing.Timestamp.IsZero() {
+		publishing.Timestamp = time.Now().UTC()
+	}
+	if publishing.CorrelationId == "" {
+		publishing.CorrelationId = publishing.MessageId
+	}
+	publishing.DeliveryMode = amqp.Persistent
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	publishError := func(err error) error {
+		slog.Error("Failed to publish a message", "error", err, "messageId", publishing.MessageId, "exchange", exchange, "routingKey", routingKey)
+		return &PublishError{MessageId: publishing.MessageId, Exchange: exchange, RoutingKey: routingKey, Err: err}
+	}
+
+	ch, err := p.conn.AcquireChannel()
+	if err != nil {
+		return publishError(err)
+	}
+	defer p.conn.ReleaseChannel(ch)
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(
+		ctx,
+		exchange,
+		routingKey,
+		true,  // mandatory
+		false, // immediate
+		publishing,
+	)
+	if err != nil {
+		return publishError(err)
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		// the confirm may still come in later, the channel is closed so that no one else reads it
+		ch.Close()
+		return publishError(fmt.Errorf("%w: %s", ErrConfirmTimeout, err))
+	}
+
+	if !acked {
+		// a nacked message may have been returned as well, it must not be read as the return of the next message on this channel
+		select {
+		case <-ch.Returns():
+		default:
+		}
+		return publishError(ErrPublishNacked)
+	}
+
+	// the broker acks an unroutable message too, after returning it
+	select {
+	case returned := <-ch.Returns():
+		return publishError(fmt.Errorf("%w: %d %s", ErrUnroutable, returned.ReplyCode, returned.ReplyText))
+	default:
+	}
+
+	slog.Debug("Message Published", "messageId", publishing.MessageId, "correlationId", publishing.CorrelationId, "exchange", exchange, "routingKey", routingKey)
+	return nil
+
+}
@@ -0,0 +1,120 @@
+package producers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type fakeConfirmation struct {
+	acked bool
+}
+
+// WaitContext confirms right away, or never when there is no confirm, waiting until ctx is done
+func (c *fakeConfirmation) WaitContext(ctx context.Context) (bool, error) {
+
+	if c == nil {
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+
+	return c.acked, nil
+
+}
+
+// fakeChannel confirms every message with its confirmation, returning it first when returned is set
+type fakeChannel struct {
+	confirmation *fakeConfirmation
+	returned     bool
+	returns      chan amqp.Return
+	closed       bool
+}
+
+func newFakeChannel(confirmation *fakeConfirmation, returned bool) *fakeChannel {
+	return &fakeChannel{confirmation: confirmation, returned: returned, returns: make(chan amqp.Return, 1)}
+}
+
+func (c *fakeChannel) PublishWithDeferredConfirmWithContext(ctx context.Context, exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) (config.AMQPconfirmation, error) {
+
+	if c.returned {
+		c.returns <- amqp.Return{ReplyCode: amqp.NoRoute, ReplyText: "NO_ROUTE", Exchange: exchange, RoutingKey: key}
+	}
+
+	return c.confirmation, nil
+
+}
+
+func (c *fakeChannel) Returns() <-chan amqp.Return {
+	return c.returns
+}
+
+func (c *fakeChannel) IsClosed() bool {
+	return c.closed
+}
+
+func (c *fakeChannel) Close() error {
+	c.closed = true
+	return nil
+}
+
+type fakeAMQPconnection struct {
+	config.AMQPconnection
+	ch       *fakeChannel
+	released bool
+}
+
+func (c *fakeAMQPconnection) AcquireChannel() (config.AMQPchannel, error) {
+	return c.ch, nil
+}
+
+func (c *fakeAMQPconnection) ReleaseChannel(ch config.AMQPchannel) {
+	c.released = true
+}
+
+func TestPublish(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		confirmation *fakeConfirmation
+		returned     bool
+		err          error
+		closed       bool
+	}{
+		{name: "acked", confirmation: &fakeConfirmation{acked: true}},
+		{name: "acked after being returned", confirmation: &fakeConfirmation{acked: true}, returned: true, err: ErrUnroutable},
+		{name: "nacked", confirmation: &fakeConfirmation{acked: false}, err: ErrPublishNacked},
+		{name: "nacked after being returned", confirmation: &fakeConfirmation{acked: false}, returned: true, err: ErrPublishNacked},
+		{name: "not confirmed in time", err: ErrConfirmTimeout, closed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := &fakeAMQPconnection{ch: newFakeChannel(test.confirmation, test.returned)}
+			publisher := &publisher{conn: conn, timeout: 10 * time.Millisecond}
+
+			err := publisher.Publish(context.Background(), "events", "user.created", amqp.Publishing{Body: []byte("{}")})
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+
+			var publishError *PublishError
+			if test.err != nil && !errors.As(err, &publishError) {
+				t.Errorf("got error %T, want a PublishError", err)
+			}
+			if !conn.released {
+				t.Error("got the channel kept, want it released")
+			}
+			if conn.ch.closed != test.closed {
+				t.Errorf("got closed %v, want %v", conn.ch.closed, test.closed)
+			}
+			if len(conn.ch.returns) != 0 {
+				t.Error("got a return left unread on the channel")
+			}
+		})
+	}
+
+}
@@ -0,0 +1,42 @@
+package producers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slog"
+)
+
+// NewUserEventMessage builds the outbox message for a user lifecycle event,
+// published to the user events topic exchange with the event type as routing key
+func NewUserEventMessage(eventType constants.UserEventType, userId string, changedFields map[string]interface{}) (*models.OutboxMessage, error) {
+
+	event := models.UserEvent{
+		EventId:       uuid.NewString(),
+		EventType:     eventType.String(),
+		Version:       constants.UserEventVersion,
+		OccurredAt:    time.Now().UTC(),
+		UserId:        userId,
+		ChangedFields: changedFields,
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Failed to marshal user event", "error", err, "eventType", eventType, "userId", userId)
+		return nil, err
+	}
+
+	return &models.OutboxMessage{
+		MessageId:   event.EventId,
+		UserId:      userId,
+		Exchange:    config.GetEnv("USER_EVENTS_EXCHANGE", "user_events"),
+		RoutingKey:  eventType.String(),
+		ContentType: "application/json",
+		Body:        body,
+	}, nil
+
+}
@@ -0,0 +1,38 @@
+package producers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slog"
+)
+
+const verificationCodeQueue = "verification_code_queue"
+
+// NewVerificationCodeMessage builds the outbox message asking the notification service to send the code to the number
+func NewVerificationCodeMessage(userId string, channel string, number string, code string, expiresAt time.Time) (*models.OutboxMessage, error) {
+
+	body, err := json.Marshal(models.VerificationCodeMessage{
+		UserId:    userId,
+		Channel:   channel,
+		Number:    number,
+		Code:      code,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		slog.Error("Failed to marshal verification code message", "error", err, "userId", userId, "channel", channel)
+		return nil, err
+	}
+
+	return &models.OutboxMessage{
+		MessageId:   uuid.NewString(),
+		UserId:      userId,
+		Exchange:    "",
+		RoutingKey:  verificationCodeQueue,
+		ContentType: "application/json",
+		Body:        body,
+	}, nil
+
+}
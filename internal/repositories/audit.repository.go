@@ -0,0 +1,226 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slog"
+)
+
+// AuditRepository is append-only, entries are never updated and only deleted along with an erased user
+type AuditRepository interface {
+	Insert(ctx context.Context, entries []models.AuditEntry) error
+	Find(query *models.AuditQuery, page int, limit int) ([]models.AuditEntry, int64, error)
+	FindByUserId(userId string, limit int) ([]models.AuditEntry, error)
+	DeleteByUserId(ctx context.Context, userId string) (int64, error)
+}
+
+type AuditRepositorySetup interface {
+	MakeUserIdCreatedAtIndex()
+	MakeActorIdCreatedAtIndex()
+}
+
+type auditRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuditRepository(collection *mongo.Collection) AuditRepository {
+	return &auditRepository{
+		collection: collection,
+	}
+}
+
+func NewAuditRepositorySetup(collection *mongo.Collection) AuditRepositorySetup {
+	return &auditRepository{
+		collection: collection,
+	}
+}
+
+func (r *auditRepository) Insert(ctx context.Context, entries []models.AuditEntry) error {
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(entries))
+	for i := range entries {
+		entries[i].Id = primitive.NewObjectID()
+		documents[i] = entries[i]
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	insertedResult, err := r.collection.InsertMany(ctx, documents)
+
+	if err != nil {
+		slog.Error("Failed to insert audit entries", "error", err, "userId", entries[0].UserId)
+		return err
+	}
+
+	slog.Debug("Inserted audit entries", "userId", entries[0].UserId, "count", len(insertedResult.InsertedIDs))
+	return nil
+
+}
+
+// Find returns a page of the entries matching the query, newest first, along with the total count
+func (r *auditRepository) Find(query *models.AuditQuery, page int, limit int) ([]models.AuditEntry, int64, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if query.UserId != "" {
+		filter["userId"] = query.UserId
+	}
+	if query.ActorId != "" {
+		filter["actor.userId"] = query.ActorId
+	}
+	if query.Field != "" {
+		filter["field"] = query.Field
+	}
+	if !query.From.IsZero() || !query.To.IsZero() {
+		createdAt := bson.M{}
+		if !query.From.IsZero() {
+			createdAt["$gte"] = query.From
+		}
+		if !query.To.IsZero() {
+			createdAt["$lt"] = query.To
+		}
+		filter["createdAt"] = createdAt
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		slog.Error("Failed to count audit entries", "error", err, "query", query)
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		slog.Error("Failed to find audit entries", "error", err, "query", query)
+		return nil, 0, err
+	}
+
+	entries := []models.AuditEntry{}
+	err = cursor.All(ctx, &entries)
+
+	if err != nil {
+		slog.Error("Failed to decode audit entries", "error", err, "query", query)
+		return nil, 0, err
+	}
+
+	slog.Debug("Found audit entries", "query", query, "page", page, "count", len(entries))
+	return entries, total, nil
+
+}
+
+// FindByUserId returns up to limit of the user's most recent audit entries, oldest first
+func (r *auditRepository) FindByUserId(userId string, limit int) ([]models.AuditEntry, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		slog.Error("Failed to find audit entries", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	entries := []models.AuditEntry{}
+	err = cursor.All(ctx, &entries)
+
+	if err != nil {
+		slog.Error("Failed to decode audit entries", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	// read newest first for the limit, handed out in the order they were made
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	slog.Debug("Found audit entries", "userId", userId, "count", len(entries))
+	return entries, nil
+
+}
+
+func (r *auditRepository) DeleteByUserId(ctx context.Context, userId string) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+
+	deletedResult, err := r.collection.DeleteMany(ctx, filter)
+
+	if err != nil {
+		slog.Error("Failed to delete audit entries", "error", err, "userId", userId)
+		return 0, err
+	}
+
+	slog.Debug("Deleted audit entries", "userId", userId, "deletedCount", deletedResult.DeletedCount)
+	return deletedResult.DeletedCount, nil
+
+}
+
+func (r *auditRepository) MakeUserIdCreatedAtIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "userId", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating userId createdAt index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created userId createdAt index", "indexName", indexName)
+
+}
+
+func (r *auditRepository) MakeActorIdCreatedAtIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "actor.userId", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating actor createdAt index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created actor createdAt index", "indexName", indexName)
+
+}
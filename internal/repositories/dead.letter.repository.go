@@ -0,0 +1,251 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slog"
+)
+
+type DeadLetterRepository interface {
+	Insert(deadLetter *models.DeadLetter) error
+	Find(query *models.DeadLetterQuery, page int, limit int) ([]models.DeadLetter, int64, error)
+	FindById(id primitive.ObjectID) (*models.DeadLetter, error)
+	MarkReplayed(id primitive.ObjectID, actor *models.Actor) (*models.DeadLetter, error)
+	DeleteByUserId(ctx context.Context, userId string) (int64, error)
+}
+
+type DeadLetterRepositorySetup interface {
+	MakeQueueStatusDeadLetteredAtIndex()
+	MakeUserIdIndex()
+	MakeDeadLetteredAtTTLIndex(expireAfter time.Duration)
+}
+
+type deadLetterRepository struct {
+	collection *mongo.Collection
+}
+
+func NewDeadLetterRepository(collection *mongo.Collection) DeadLetterRepository {
+	return &deadLetterRepository{
+		collection: collection,
+	}
+}
+
+func NewDeadLetterRepositorySetup(collection *mongo.Collection) DeadLetterRepositorySetup {
+	return &deadLetterRepository{
+		collection: collection,
+	}
+}
+
+func (r *deadLetterRepository) Insert(deadLetter *models.DeadLetter) error {
+
+	deadLetter.Status = constants.DeadLetterPending.String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	insertedResult, err := r.collection.InsertOne(ctx, deadLetter)
+
+	if err != nil {
+		slog.Error("Failed to insert dead letter", "error", err, "queue", deadLetter.Queue, "messageId", deadLetter.MessageId)
+		return err
+	}
+
+	deadLetter.Id = insertedResult.InsertedID.(primitive.ObjectID)
+	slog.Debug("Inserted dead letter", "queue", deadLetter.Queue, "messageId", deadLetter.MessageId, "id", deadLetter.Id)
+	return nil
+
+}
+
+// Find returns a page of the dead letters matching the query, newest first, along with the total count
+func (r *deadLetterRepository) Find(query *models.DeadLetterQuery, page int, limit int) ([]models.DeadLetter, int64, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if query.Queue != "" {
+		filter["queue"] = query.Queue
+	}
+	if query.RoutingKey != "" {
+		filter["routingKey"] = query.RoutingKey
+	}
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		slog.Error("Failed to count dead letters", "error", err, "query", query)
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "deadLetteredAt", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		slog.Error("Failed to find dead letters", "error", err, "query", query)
+		return nil, 0, err
+	}
+
+	deadLetters := []models.DeadLetter{}
+	err = cursor.All(ctx, &deadLetters)
+
+	if err != nil {
+		slog.Error("Failed to decode dead letters", "error", err, "query", query)
+		return nil, 0, err
+	}
+
+	slog.Debug("Found dead letters", "query", query, "page", page, "count", len(deadLetters))
+	return deadLetters, total, nil
+
+}
+
+func (r *deadLetterRepository) FindById(id primitive.ObjectID) (*models.DeadLetter, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id}
+
+	var deadLetter models.DeadLetter
+	err := r.collection.FindOne(ctx, filter).Decode(&deadLetter)
+
+	if err != nil {
+		slog.Error("Failed to find dead letter", "error", err, "id", id)
+		return nil, err
+	}
+
+	slog.Debug("Found dead letter", "id", id)
+	return &deadLetter, nil
+
+}
+
+// MarkReplayed marks a pending dead letter as replayed, it returns mongo.ErrNoDocuments when it was replayed already
+func (r *deadLetterRepository) MarkReplayed(id primitive.ObjectID, actor *models.Actor) (*models.DeadLetter, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":    id,
+		"status": constants.DeadLetterPending.String(),
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     constants.DeadLetterReplayed.String(),
+			"replayedAt": time.Now().UTC(),
+			"replayedBy": actor,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var deadLetter models.DeadLetter
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&deadLetter)
+
+	if err != nil {
+		slog.Error("Failed to mark dead letter as replayed", "error", err, "id", id)
+		return nil, err
+	}
+
+	slog.Debug("Marked dead letter as replayed", "id", id)
+	return &deadLetter, nil
+
+}
+
+// DeleteByUserId drops every dead letter about the user, their bodies carry the user's contact details and codes
+func (r *deadLetterRepository) DeleteByUserId(ctx context.Context, userId string) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+
+	deletedResult, err := r.collection.DeleteMany(ctx, filter)
+
+	if err != nil {
+		slog.Error("Failed to delete dead letters", "error", err, "userId", userId)
+		return 0, err
+	}
+
+	slog.Debug("Deleted dead letters", "userId", userId, "deletedCount", deletedResult.DeletedCount)
+	return deletedResult.DeletedCount, nil
+
+}
+
+func (r *deadLetterRepository) MakeQueueStatusDeadLetteredAtIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "queue", Value: 1},
+				{Key: "status", Value: 1},
+				{Key: "deadLetteredAt", Value: -1},
+			},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating queue status deadLetteredAt index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created queue status deadLetteredAt index", "indexName", indexName)
+
+}
+
+func (r *deadLetterRepository) MakeUserIdIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "userId", Value: 1}},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating userId index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created userId index", "indexName", indexName)
+
+}
+
+func (r *deadLetterRepository) MakeDeadLetteredAtTTLIndex(expireAfter time.Duration) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "deadLetteredAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(expireAfter.Seconds())),
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating deadLetteredAt TTL index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created deadLetteredAt TTL index", "indexName", indexName)
+
+}
@@ -0,0 +1,376 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slog"
+)
+
+type ErasureRepository interface {
+	Insert(ctx context.Context, job *models.ErasureJob) error
+	FindLatestByUserId(userId string) (*models.ErasureJob, error)
+	IsErased(ctx context.Context, userId string) (bool, error)
+	Cancel(ctx context.Context, userId string) (*models.ErasureJob, error)
+	Expedite(userId string) (*models.ErasureJob, error)
+	ClaimNext(lease time.Duration) (*models.ErasureJob, error)
+	MarkErased(ctx context.Context, id primitive.ObjectID, acknowledgements map[string]*models.ErasureAcknowledgement) (*models.ErasureJob, error)
+	UpdateAcknowledgement(id primitive.ObjectID, service string, acknowledgement *models.ErasureAcknowledgement) (*models.ErasureJob, error)
+	MarkCompleted(id primitive.ObjectID) error
+}
+
+type ErasureRepositorySetup interface {
+	MakeUserIdRequestedAtIndex()
+	MakeStatusScheduledForIndex()
+}
+
+type erasureRepository struct {
+	collection *mongo.Collection
+}
+
+func NewErasureRepository(collection *mongo.Collection) ErasureRepository {
+	return &erasureRepository{
+		collection: collection,
+	}
+}
+
+func NewErasureRepositorySetup(collection *mongo.Collection) ErasureRepositorySetup {
+	return &erasureRepository{
+		collection: collection,
+	}
+}
+
+func (r *erasureRepository) Insert(ctx context.Context, job *models.ErasureJob) error {
+	job.Id = primitive.NewObjectID()
+	job.Status = constants.ErasureScheduled.String()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	insertedResult, err := r.collection.InsertOne(ctx, job)
+
+	if err != nil {
+		slog.Error("Failed to insert erasure job", "error", err, "userId", job.UserId)
+		return err
+	}
+
+	slog.Debug("Inserted erasure job", "userId", job.UserId, "insertedResult", insertedResult)
+	return nil
+}
+
+func (r *erasureRepository) FindLatestByUserId(userId string) (*models.ErasureJob, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+	opts := options.FindOne().SetSort(bson.D{{Key: "requestedAt", Value: -1}})
+
+	var job models.ErasureJob
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&job)
+
+	if err != nil {
+		slog.Error("Failed to find erasure job", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Found erasure job", "userId", userId, "id", job.Id)
+	return &job, nil
+
+}
+
+// Cancel undoes the user's erasure while it is still scheduled, otherwise it returns mongo.ErrNoDocuments
+// IsErased reports whether the user's erasure is underway or done, from then on the user must not be recreated
+func (r *erasureRepository) IsErased(ctx context.Context, userId string) (bool, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"userId": userId,
+		"status": bson.M{"$in": bson.A{
+			constants.ErasureErasing.String(),
+			constants.ErasureErased.String(),
+			constants.ErasureCompleted.String(),
+		}},
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+
+	if err != nil {
+		slog.Error("Failed to check for an erasure", "error", err, "userId", userId)
+		return false, err
+	}
+
+	return count > 0, nil
+
+}
+
+func (r *erasureRepository) Cancel(ctx context.Context, userId string) (*models.ErasureJob, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"userId": userId,
+		"status": constants.ErasureScheduled.String(),
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"status":      constants.ErasureCancelled.String(),
+				"cancelledAt": time.Now().UTC(),
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.ErasureJob
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+
+	if err != nil {
+		slog.Error("Failed to cancel erasure job", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Cancelled erasure job", "userId", userId, "id", job.Id)
+	return &job, nil
+
+}
+
+// ClaimNext leases the oldest erasure whose grace period is over, or one whose lease ran out while erasing.
+// It returns nil when there is nothing to erase.
+// Expedite moves the user's scheduled erasure up to now, ending the grace period
+func (r *erasureRepository) Expedite(userId string) (*models.ErasureJob, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"userId": userId,
+		"status": constants.ErasureScheduled.String(),
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"scheduledFor": time.Now().UTC(),
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.ErasureJob
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+
+	if err != nil {
+		slog.Error("Failed to expedite erasure job", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Expedited erasure job", "userId", userId, "id", job.Id)
+	return &job, nil
+
+}
+
+func (r *erasureRepository) ClaimNext(lease time.Duration) (*models.ErasureJob, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"status": constants.ErasureScheduled.String(), "scheduledFor": bson.M{"$lte": now}},
+			bson.M{"status": constants.ErasureErasing.String(), "leasedUntil": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"status":      constants.ErasureErasing.String(),
+				"leasedUntil": now.Add(lease),
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "scheduledFor", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.ErasureJob
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		slog.Error("Failed to claim erasure job", "error", err)
+		return nil, err
+	}
+
+	slog.Debug("Claimed erasure job", "id", job.Id, "userId", job.UserId)
+	return &job, nil
+
+}
+
+// MarkErased records that the user's data in this service is gone and which services still have to acknowledge
+func (r *erasureRepository) MarkErased(ctx context.Context, id primitive.ObjectID, acknowledgements map[string]*models.ErasureAcknowledgement) (*models.ErasureJob, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":    id,
+		"status": constants.ErasureErasing.String(),
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"status":           constants.ErasureErased.String(),
+				"erasedAt":         time.Now().UTC(),
+				"acknowledgements": acknowledgements,
+			},
+		},
+		{
+			Key: "$unset",
+			Value: bson.M{
+				"leasedUntil": "",
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.ErasureJob
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+
+	if err != nil {
+		slog.Error("Failed to mark erasure job erased", "error", err, "id", id)
+		return nil, err
+	}
+
+	slog.Debug("Marked erasure job erased", "id", id)
+	return &job, nil
+
+}
+
+// UpdateAcknowledgement records a service's answer, it only applies once the user was erased here
+func (r *erasureRepository) UpdateAcknowledgement(id primitive.ObjectID, service string, acknowledgement *models.ErasureAcknowledgement) (*models.ErasureJob, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":    id,
+		"status": bson.M{"$in": bson.A{constants.ErasureErased.String(), constants.ErasureCompleted.String()}},
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"acknowledgements." + service: acknowledgement,
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.ErasureJob
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+
+	if err != nil {
+		slog.Error("Failed to update erasure acknowledgement", "error", err, "id", id, "service", service)
+		return nil, err
+	}
+
+	slog.Debug("Updated erasure acknowledgement", "id", id, "service", service, "status", acknowledgement.Status)
+	return &job, nil
+
+}
+
+func (r *erasureRepository) MarkCompleted(id primitive.ObjectID) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":    id,
+		"status": constants.ErasureErased.String(),
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"status":      constants.ErasureCompleted.String(),
+				"completedAt": time.Now().UTC(),
+			},
+		},
+	}
+
+	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		slog.Error("Failed to mark erasure job completed", "error", err, "id", id)
+		return err
+	}
+
+	slog.Debug("Marked erasure job completed", "id", id, "updatedResult", updatedResult)
+	return nil
+
+}
+
+func (r *erasureRepository) MakeUserIdRequestedAtIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "userId", Value: 1},
+				{Key: "requestedAt", Value: -1},
+			},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating userId requestedAt index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created userId requestedAt index", "indexName", indexName)
+
+}
+
+func (r *erasureRepository) MakeStatusScheduledForIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "scheduledFor", Value: 1},
+			},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating status scheduledFor index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created status scheduledFor index", "indexName", indexName)
+
+}
@@ -0,0 +1,415 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slog"
+)
+
+type ExportRepository interface {
+	Insert(ctx context.Context, job *models.ExportJob) error
+	CountRequestedSince(ctx context.Context, userId string, since time.Time) (int64, error)
+	FindById(userId string, id primitive.ObjectID) (*models.ExportJob, error)
+	FindByDownloadToken(tokenHash string) (*models.ExportJob, error)
+	ClaimNext(lease time.Duration) (*models.ExportJob, error)
+	MarkReady(id primitive.ObjectID, archive []byte) error
+	MarkFailed(id primitive.ObjectID, lastError string) error
+	Release(id primitive.ObjectID, lastError string) error
+	SetDownloadToken(userId string, id primitive.ObjectID, tokenHash string, expiresAt time.Time) error
+	DeleteByUserId(userId string) (int64, error)
+}
+
+type ExportRepositorySetup interface {
+	MakeUserIdRequestedAtIndex()
+	MakeStatusIndex()
+	MakeDownloadTokenIndex()
+	MakeExpiresAtTTLIndex()
+}
+
+type exportRepository struct {
+	collection *mongo.Collection
+}
+
+func NewExportRepository(collection *mongo.Collection) ExportRepository {
+	return &exportRepository{
+		collection: collection,
+	}
+}
+
+func NewExportRepositorySetup(collection *mongo.Collection) ExportRepositorySetup {
+	return &exportRepository{
+		collection: collection,
+	}
+}
+
+// withoutArchive keeps the archive out of the documents read for status checks
+var withoutArchive = bson.M{"archive": 0}
+
+func (r *exportRepository) Insert(ctx context.Context, job *models.ExportJob) error {
+	job.Id = primitive.NewObjectID()
+	job.Status = constants.ExportPending.String()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	insertedResult, err := r.collection.InsertOne(ctx, job)
+
+	if err != nil {
+		slog.Error("Failed to insert export job", "error", err, "userId", job.UserId)
+		return err
+	}
+
+	slog.Debug("Inserted export job", "userId", job.UserId, "insertedResult", insertedResult)
+	return nil
+}
+
+func (r *exportRepository) CountRequestedSince(ctx context.Context, userId string, since time.Time) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"userId":      userId,
+		"requestedAt": bson.M{"$gte": since},
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter)
+
+	if err != nil {
+		slog.Error("Failed to count export jobs", "error", err, "userId", userId)
+		return 0, err
+	}
+
+	slog.Debug("Counted export jobs", "userId", userId, "since", since, "count", count)
+	return count, nil
+
+}
+
+func (r *exportRepository) FindById(userId string, id primitive.ObjectID) (*models.ExportJob, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "userId": userId}
+	opts := options.FindOne().SetProjection(withoutArchive)
+
+	var job models.ExportJob
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&job)
+
+	if err != nil {
+		slog.Error("Failed to find export job", "error", err, "userId", userId, "id", id)
+		return nil, err
+	}
+
+	slog.Debug("Found export job", "userId", userId, "id", id)
+	return &job, nil
+
+}
+
+// FindByDownloadToken returns the ready export, archive included, that the download token was issued for while the token is valid
+func (r *exportRepository) FindByDownloadToken(tokenHash string) (*models.ExportJob, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"downloadTokenHash": tokenHash,
+		"downloadExpiresAt": bson.M{"$gt": time.Now().UTC()},
+		"status":            constants.ExportReady.String(),
+	}
+
+	var job models.ExportJob
+	err := r.collection.FindOne(ctx, filter).Decode(&job)
+
+	if err != nil {
+		slog.Error("Failed to find export job by download token", "error", err)
+		return nil, err
+	}
+
+	slog.Debug("Found export job by download token", "id", job.Id)
+	return &job, nil
+
+}
+
+// ClaimNext leases the oldest pending export, or one whose lease ran out while it was being built, and counts the attempt.
+// It returns nil when there is nothing to export.
+func (r *exportRepository) ClaimNext(lease time.Duration) (*models.ExportJob, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"status": constants.ExportPending.String()},
+			bson.M{"status": constants.ExportProcessing.String(), "leasedUntil": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"status":      constants.ExportProcessing.String(),
+				"leasedUntil": now.Add(lease),
+			},
+		},
+		{
+			Key: "$inc",
+			Value: bson.M{
+				"attempts": 1,
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "requestedAt", Value: 1}}).
+		SetProjection(withoutArchive).
+		SetReturnDocument(options.After)
+
+	var job models.ExportJob
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		slog.Error("Failed to claim export job", "error", err)
+		return nil, err
+	}
+
+	slog.Debug("Claimed export job", "id", job.Id, "userId", job.UserId)
+	return &job, nil
+
+}
+
+func (r *exportRepository) MarkReady(id primitive.ObjectID, archive []byte) error {
+	return r.complete(id, bson.M{
+		"status":  constants.ExportReady.String(),
+		"archive": archive,
+		"size":    len(archive),
+	})
+}
+
+func (r *exportRepository) MarkFailed(id primitive.ObjectID, lastError string) error {
+	return r.complete(id, bson.M{
+		"status": constants.ExportFailed.String(),
+		"error":  lastError,
+	})
+}
+
+// Release hands a failed export back to be built again
+func (r *exportRepository) Release(id primitive.ObjectID, lastError string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":    id,
+		"status": constants.ExportProcessing.String(),
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"status": constants.ExportPending.String(),
+				"error":  lastError,
+			},
+		},
+		{Key: "$unset", Value: bson.M{"leasedUntil": ""}},
+	}
+
+	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		slog.Error("Failed to release export job", "error", err, "id", id)
+		return err
+	}
+
+	slog.Debug("Released export job", "id", id, "updatedResult", updatedResult)
+	return nil
+
+}
+
+func (r *exportRepository) complete(id primitive.ObjectID, fields bson.M) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	fields["completedAt"] = time.Now().UTC()
+
+	filter := bson.M{
+		"_id":    id,
+		"status": constants.ExportProcessing.String(),
+	}
+	update := bson.D{
+		{Key: "$set", Value: fields},
+		{Key: "$unset", Value: bson.M{"leasedUntil": ""}},
+	}
+
+	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		slog.Error("Failed to complete export job", "error", err, "id", id)
+		return err
+	}
+
+	slog.Debug("Completed export job", "id", id, "status", fields["status"], "updatedResult", updatedResult)
+	return nil
+
+}
+
+// SetDownloadToken replaces the download token of a ready export, so only the latest link works
+func (r *exportRepository) SetDownloadToken(userId string, id primitive.ObjectID, tokenHash string, expiresAt time.Time) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":    id,
+		"userId": userId,
+		"status": constants.ExportReady.String(),
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"downloadTokenHash": tokenHash,
+				"downloadExpiresAt": expiresAt,
+			},
+		},
+	}
+
+	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		slog.Error("Failed to set download token", "error", err, "userId", userId, "id", id)
+		return err
+	}
+
+	if updatedResult.MatchedCount == 0 {
+		slog.Error("Failed to set download token, export not ready", "userId", userId, "id", id)
+		return mongo.ErrNoDocuments
+	}
+
+	slog.Debug("Set download token", "userId", userId, "id", id, "expiresAt", expiresAt)
+	return nil
+
+}
+
+func (r *exportRepository) DeleteByUserId(userId string) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+
+	deletedResult, err := r.collection.DeleteMany(ctx, filter)
+
+	if err != nil {
+		slog.Error("Failed to delete export jobs", "error", err, "userId", userId)
+		return 0, err
+	}
+
+	slog.Debug("Deleted export jobs", "userId", userId, "deletedCount", deletedResult.DeletedCount)
+	return deletedResult.DeletedCount, nil
+
+}
+
+func (r *exportRepository) MakeUserIdRequestedAtIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "userId", Value: 1},
+				{Key: "requestedAt", Value: -1},
+			},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating userId requestedAt index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created userId requestedAt index", "indexName", indexName)
+
+}
+
+func (r *exportRepository) MakeStatusIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "requestedAt", Value: 1},
+			},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating status index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created status index", "indexName", indexName)
+
+}
+
+func (r *exportRepository) MakeDownloadTokenIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "downloadTokenHash", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating download token index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created download token index", "indexName", indexName)
+
+}
+
+func (r *exportRepository) MakeExpiresAtTTLIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating expiresAt TTL index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created expiresAt TTL index", "indexName", indexName)
+
+}
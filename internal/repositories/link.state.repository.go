@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slog"
+)
+
+type LinkStateRepository interface {
+	Insert(linkState *models.LinkState) error
+	Consume(state string, provider string) (*models.LinkState, error)
+}
+
+type LinkStateRepositorySetup interface {
+	MakeStateUniqueIndex()
+	MakeExpiresAtTTLIndex()
+}
+
+type linkStateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLinkStateRepository(collection *mongo.Collection) LinkStateRepository {
+	return &linkStateRepository{
+		collection: collection,
+	}
+}
+
+func NewLinkStateRepositorySetup(collection *mongo.Collection) LinkStateRepositorySetup {
+	return &linkStateRepository{
+		collection: collection,
+	}
+}
+
+func (r *linkStateRepository) Insert(linkState *models.LinkState) error {
+	linkState.CreatedAt = time.Now().UTC()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	insertedResult, err := r.collection.InsertOne(ctx, linkState)
+
+	if err != nil {
+		slog.Error("Failed to insert link state", "error", err, "userId", linkState.UserId, "provider", linkState.Provider)
+		return err
+	}
+
+	slog.Debug("Inserted link state", "userId", linkState.UserId, "provider", linkState.Provider, "insertedResult", insertedResult)
+	return nil
+}
+
+// Consume removes and returns the unexpired state, so every state can be used only once
+func (r *linkStateRepository) Consume(state string, provider string) (*models.LinkState, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"state":     state,
+		"provider":  provider,
+		"expiresAt": bson.M{"$gt": time.Now().UTC()},
+	}
+
+	var linkState models.LinkState
+	err := r.collection.FindOneAndDelete(ctx, filter).Decode(&linkState)
+
+	if err != nil {
+		slog.Error("Failed to consume link state", "error", err, "provider", provider)
+		return nil, err
+	}
+
+	slog.Debug("Consumed link state", "userId", linkState.UserId, "provider", provider)
+	return &linkState, nil
+
+}
+
+func (r *linkStateRepository) MakeStateUniqueIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "state", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating state index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created state index", "indexName", indexName)
+
+}
+
+func (r *linkStateRepository) MakeExpiresAtTTLIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating expiresAt TTL index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created expiresAt TTL index", "indexName", indexName)
+
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/exp/slog"
+)
+
+// migrationLockId is the _id of the single lock document, the unique _id lets only one run insert it
+const migrationLockId = "migrations"
+
+type MigrationLockRepository interface {
+	Acquire(owner string, ttl time.Duration) error
+	Release(owner string) error
+}
+
+type migrationLockRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMigrationLockRepository(collection *mongo.Collection) MigrationLockRepository {
+	return &migrationLockRepository{
+		collection: collection,
+	}
+}
+
+// Acquire inserts the lock document and returns a duplicate key error while another run holds it.
+// A lock left behind by a run that died is taken over once it has expired.
+func (r *migrationLockRepository) Acquire(owner string, ttl time.Duration) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+
+	deletedResult, err := r.collection.DeleteOne(ctx, bson.M{"_id": migrationLockId, "expiresAt": bson.M{"$lte": now}})
+	if err != nil {
+		slog.Error("Failed to delete expired migration lock", "error", err)
+		return err
+	}
+	if deletedResult.DeletedCount > 0 {
+		slog.Warn("Took over an expired migration lock")
+	}
+
+	lock := models.MigrationLock{
+		Id:        migrationLockId,
+		Owner:     owner,
+		LockedAt:  now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	_, err = r.collection.InsertOne(ctx, lock)
+	if err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			slog.Error("Failed to acquire migration lock", "error", err, "owner", owner)
+		}
+		return err
+	}
+
+	slog.Debug("Acquired migration lock", "owner", owner, "expiresAt", lock.ExpiresAt)
+	return nil
+
+}
+
+// Release deletes the lock document if it is still held by owner
+func (r *migrationLockRepository) Release(owner string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	deletedResult, err := r.collection.DeleteOne(ctx, bson.M{"_id": migrationLockId, "owner": owner})
+	if err != nil {
+		slog.Error("Failed to release migration lock", "error", err, "owner", owner)
+		return err
+	}
+
+	slog.Debug("Released migration lock", "owner", owner, "deletedCount", deletedResult.DeletedCount)
+	return nil
+
+}
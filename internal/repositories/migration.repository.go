@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slog"
+)
+
+type MigrationRepository interface {
+	FindApplied() ([]models.AppliedMigration, error)
+	Insert(migration *models.AppliedMigration) error
+	Delete(version int) error
+}
+
+type MigrationRepositorySetup interface {
+	MakeVersionUniqueIndex()
+}
+
+type migrationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMigrationRepository(collection *mongo.Collection) MigrationRepository {
+	return &migrationRepository{
+		collection: collection,
+	}
+}
+
+func NewMigrationRepositorySetup(collection *mongo.Collection) MigrationRepositorySetup {
+	return &migrationRepository{
+		collection: collection,
+	}
+}
+
+func (r *migrationRepository) FindApplied() ([]models.AppliedMigration, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		slog.Error("Failed to find applied migrations", "error", err)
+		return nil, err
+	}
+
+	migrations := []models.AppliedMigration{}
+	err = cursor.All(ctx, &migrations)
+
+	if err != nil {
+		slog.Error("Failed to decode applied migrations", "error", err)
+		return nil, err
+	}
+
+	slog.Debug("Found applied migrations", "count", len(migrations))
+	return migrations, nil
+
+}
+
+func (r *migrationRepository) Insert(migration *models.AppliedMigration) error {
+	migration.AppliedAt = time.Now().UTC()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	insertedResult, err := r.collection.InsertOne(ctx, migration)
+
+	if err != nil {
+		slog.Error("Failed to insert applied migration", "error", err, "version", migration.Version)
+		return err
+	}
+
+	slog.Debug("Inserted applied migration", "version", migration.Version, "insertedResult", insertedResult)
+	return nil
+}
+
+func (r *migrationRepository) Delete(version int) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"version": version}
+
+	deletedResult, err := r.collection.DeleteOne(ctx, filter)
+
+	if err != nil {
+		slog.Error("Failed to delete applied migration", "error", err, "version", version)
+		return err
+	}
+
+	slog.Debug("Deleted applied migration", "version", version, "deletedResult", deletedResult)
+	return nil
+
+}
+
+func (r *migrationRepository) MakeVersionUniqueIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "version", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating version index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created version index", "indexName", indexName)
+
+}
@@ -0,0 +1,287 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slog"
+)
+
+type OutboxRepository interface {
+	Insert(ctx context.Context, message *models.OutboxMessage) error
+	ClaimNext(lease time.Duration) (*models.OutboxMessage, error)
+	MarkPublished(id primitive.ObjectID) error
+	MarkFailed(id primitive.ObjectID, nextAttemptAt time.Time, lastError string) error
+	MarkParked(id primitive.ObjectID, lastError string) error
+	DeleteByUserId(ctx context.Context, userId string) (int64, error)
+}
+
+type OutboxRepositorySetup interface {
+	MakeStatusNextAttemptAtIndex()
+	MakeUserIdIndex()
+	MakePublishedAtTTLIndex(expireAfter time.Duration)
+}
+
+type outboxRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOutboxRepository(collection *mongo.Collection) OutboxRepository {
+	return &outboxRepository{
+		collection: collection,
+	}
+}
+
+func NewOutboxRepositorySetup(collection *mongo.Collection) OutboxRepositorySetup {
+	return &outboxRepository{
+		collection: collection,
+	}
+}
+
+func (r *outboxRepository) Insert(ctx context.Context, message *models.OutboxMessage) error {
+	now := time.Now().UTC()
+	message.Status = constants.OutboxPending.String()
+	message.CreatedAt = now
+	message.NextAttemptAt = now
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	insertedResult, err := r.collection.InsertOne(ctx, message)
+
+	if err != nil {
+		slog.Error("Failed to insert outbox message", "error", err, "messageId", message.MessageId)
+		return err
+	}
+
+	slog.Debug("Inserted outbox message", "messageId", message.MessageId, "insertedResult", insertedResult)
+	return nil
+}
+
+// ClaimNext leases the oldest due pending message so that no other relay picks it up until the lease expires.
+// It returns nil when there is nothing to publish.
+func (r *outboxRepository) ClaimNext(lease time.Duration) (*models.OutboxMessage, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	filter := bson.M{
+		"status":        constants.OutboxPending.String(),
+		"nextAttemptAt": bson.M{"$lte": now},
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"nextAttemptAt": now.Add(lease),
+			},
+		},
+		{
+			Key: "$inc",
+			Value: bson.M{
+				"attempts": 1,
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var message models.OutboxMessage
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&message)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		slog.Error("Failed to claim outbox message", "error", err)
+		return nil, err
+	}
+
+	slog.Debug("Claimed outbox message", "messageId", message.MessageId, "attempts", message.Attempts)
+	return &message, nil
+
+}
+
+// MarkPublished drops the body along the way, it may hold verification codes and contact details
+// which have no business staying in the outbox until the published message expires
+func (r *outboxRepository) MarkPublished(id primitive.ObjectID) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"status":      constants.OutboxPublished.String(),
+				"publishedAt": time.Now().UTC(),
+			},
+		},
+		{
+			Key: "$unset",
+			Value: bson.M{
+				"lastError": "",
+				"body":      "",
+			},
+		},
+	}
+
+	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		slog.Error("Failed to mark outbox message as published", "error", err, "id", id)
+		return err
+	}
+
+	slog.Debug("Marked outbox message as published", "id", id, "updatedResult", updatedResult)
+	return nil
+
+}
+
+func (r *outboxRepository) MarkFailed(id primitive.ObjectID, nextAttemptAt time.Time, lastError string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id}
+	update := bson.D{{
+		Key: "$set",
+		Value: bson.M{
+			"nextAttemptAt": nextAttemptAt,
+			"lastError":     lastError,
+		},
+	}}
+
+	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		slog.Error("Failed to mark outbox message as failed", "error", err, "id", id)
+		return err
+	}
+
+	slog.Debug("Marked outbox message as failed", "id", id, "nextAttemptAt", nextAttemptAt, "updatedResult", updatedResult)
+	return nil
+
+}
+
+// MarkParked takes a message that kept failing out of the relay, it stays in the outbox to be looked into
+func (r *outboxRepository) MarkParked(id primitive.ObjectID, lastError string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id}
+	update := bson.D{{
+		Key: "$set",
+		Value: bson.M{
+			"status":    constants.OutboxParked.String(),
+			"parkedAt":  time.Now().UTC(),
+			"lastError": lastError,
+		},
+	}}
+
+	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		slog.Error("Failed to mark outbox message as parked", "error", err, "id", id)
+		return err
+	}
+
+	slog.Debug("Marked outbox message as parked", "id", id, "updatedResult", updatedResult)
+	return nil
+
+}
+
+// DeleteByUserId drops every message about the user, the unpublished ones still carry their contact details and codes
+func (r *outboxRepository) DeleteByUserId(ctx context.Context, userId string) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+
+	deletedResult, err := r.collection.DeleteMany(ctx, filter)
+
+	if err != nil {
+		slog.Error("Failed to delete outbox messages", "error", err, "userId", userId)
+		return 0, err
+	}
+
+	slog.Debug("Deleted outbox messages", "userId", userId, "deletedCount", deletedResult.DeletedCount)
+	return deletedResult.DeletedCount, nil
+
+}
+
+func (r *outboxRepository) MakeStatusNextAttemptAtIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating status nextAttemptAt index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created status nextAttemptAt index", "indexName", indexName)
+
+}
+
+func (r *outboxRepository) MakeUserIdIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "userId", Value: 1}},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating userId index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created userId index", "indexName", indexName)
+
+}
+
+func (r *outboxRepository) MakePublishedAtTTLIndex(expireAfter time.Duration) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "publishedAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(expireAfter.Seconds())),
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating publishedAt TTL index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created publishedAt TTL index", "indexName", indexName)
+
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/exp/slog"
+)
+
+// TransactionRepository runs a group of repository calls atomically.
+// Repository methods taking a context must be given the context passed to fn to take part in the transaction.
+type TransactionRepository interface {
+	WithTransaction(fn func(ctx context.Context) error) error
+}
+
+type transactionRepository struct {
+	client *mongo.Client
+}
+
+func NewTransactionRepository(client *mongo.Client) TransactionRepository {
+	return &transactionRepository{
+		client: client,
+	}
+}
+
+func (r *transactionRepository) WithTransaction(fn func(ctx context.Context) error) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, err := r.client.StartSession()
+	if err != nil {
+		slog.Error("Failed to start session", "error", err)
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+
+	if err != nil {
+		slog.Error("Failed to run transaction", "error", err)
+		return err
+	}
+
+	return nil
+
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
@@ -13,34 +14,56 @@ import (
 )
 
 type UserRepository interface {
-	Upsert(user *models.User) (bool, error)
+	Upsert(ctx context.Context, user *models.User) (*models.User, error)
 
 	FindByUserId(userId string) (*models.User, error)
+	FindForUpdate(ctx context.Context, userId string) (*models.User, error)
 
-	UpdateWhatsAppNumber(userId string, whatsAppNumber string) error
+	UpdateWhatsAppNumber(ctx context.Context, userId string, whatsAppNumber string) (*models.User, error)
 	FindWhatsAppNumber(userId string) (string, error)
 
-	UpdateDiscordId(userId string, discordId string) error
-	FindDiscordId(userId string) (string, error)
+	UpdateDiscordAccount(ctx context.Context, userId string, discordId string, discordUsername string) (*models.User, error)
+	RemoveDiscordAccount(ctx context.Context, userId string) (*models.User, error)
+	FindDiscordAccount(userId string) (string, string, error)
 
-	UpdateTelegramNumber(userId string, telegramNumber string) error
+	UpdateTelegramNumber(ctx context.Context, userId string, telegramNumber string) (*models.User, error)
 	FindTelegramNumber(userId string) (string, error)
+	UpdateTelegramChatId(ctx context.Context, userId string, telegramChatId int64) (*models.User, error)
 
-	UpdateNotificationInterfaces(userId string, notificationInterfaces []string) error
+	UpdateChannelVerification(ctx context.Context, userId string, channel string, verification *models.ChannelVerification) error
+	IncrementChannelVerificationAttempts(userId string, channel string, maxAttempts int) error
+	MarkChannelVerified(ctx context.Context, userId string, channel string, number string, codeHash string) error
+
+	UpdateNotificationInterfaces(ctx context.Context, userId string, notificationInterfaces []string) (*models.User, error)
 	FindNotificationInterfaces(userId string) ([]string, error)
 
-	InsertFCMtoken(userId string, FCMtoken string) error
-	RemoveFCMtoken(userId string, FCMtoken string) error
-	FindFCMtokens(userId string) ([]string, error)
+	UpdateNotificationPreferences(ctx context.Context, userId string, notificationPreferences map[string][]string) (*models.User, error)
+	FindNotificationPreferences(userId string) (map[string][]string, error)
+
+	RefreshFCMtoken(ctx context.Context, userId string, FCMtoken *models.FCMtoken, maxFCMtokens int) (*models.User, error)
+	RemoveFCMtoken(ctx context.Context, userId string, FCMtoken string) (*models.User, error)
+	RemoveStaleFCMtokens(ctx context.Context, userId string, refreshedBefore time.Time) (*models.User, error)
+	FindFCMtokens(userId string) ([]models.FCMtoken, error)
+	FindUserIdByFCMtoken(FCMtoken string) (string, error)
+	FindUserIdsWithStaleFCMtokens(refreshedBefore time.Time, limit int) ([]string, error)
+
+	InsertNotificationInterface(ctx context.Context, userId string, notificationInterface string) (*models.User, error)
+	RemoveNotificationInterface(ctx context.Context, userId string, notificationInterface string) (*models.User, error)
+
+	MarkDeleted(ctx context.Context, userId string) (*models.User, error)
+	Restore(ctx context.Context, userId string) (*models.User, error)
 
-	UpdateWebhooks(userId string, webhooks []string) error
-	FindWebhooks(userId string) ([]string, error)
+	Find(query *models.UserQuery, page int, limit int) ([]models.User, int64, error)
+	UpdateFields(ctx context.Context, userId string, fields bson.M) (*models.User, error)
+	LockExports(ctx context.Context, userId string) error
 
-	Delete(userId string) error
+	Delete(ctx context.Context, userId string) error
 }
 
 type UserRepositorySetup interface {
 	MakeUserIdUniqueIndex()
+	MakeFCMtokenIndexes()
+	MakeSearchIndexes()
 }
 
 type userRepository struct {
@@ -59,40 +82,65 @@ func NewUserRepositorySetup(collection *mongo.Collection) UserRepositorySetup {
 	}
 }
 
-func (r *userRepository) Upsert(user *models.User) (bool, error) {
+// Upsert records a login and refreshes the profile from the Firebase claims in user. The defaults in user, such as
+// the notification interfaces, are only written when the user is inserted, so a returning user keeps their channels
+// and signup date. It returns the user as it was before the login, or nil when the user was inserted.
+func (r *userRepository) Upsert(ctx context.Context, user *models.User) (*models.User, error) {
 	now := time.Now().UTC()
 	user.CreatedAt = now
 	user.UpdatedAt = now
+	user.LastLoginAt = now
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"userId": user.UserId}
-	update := bson.D{{
-		Key: "$set",
-		Value: bson.M{
-			"userId":                 user.UserId,
-			"notificationInterfaces": user.NotificationInterfaces,
-			"createdAt":              user.CreatedAt,
-			"updatedAt":              user.UpdatedAt,
+	update := bson.D{
+		{
+			Key: "$setOnInsert",
+			Value: bson.M{
+				"notificationInterfaces": user.NotificationInterfaces,
+				"createdAt":              user.CreatedAt,
+			},
 		},
-	}}
-	opts := options.Update().SetUpsert(true)
+		{
+			Key: "$set",
+			Value: bson.M{
+				"email":          user.Email,
+				"emailLower":     strings.ToLower(user.Email),
+				"emailVerified":  user.EmailVerified,
+				"displayName":    user.DisplayName,
+				"photoURL":       user.PhotoURL,
+				"signInProvider": user.SignInProvider,
+				"customClaims":   user.CustomClaims,
+				"updatedAt":      user.UpdatedAt,
+				"lastLoginAt":    user.LastLoginAt,
+			},
+		},
+		{
+			Key: "$inc",
+			Value: bson.M{
+				"loginCount": 1,
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
 
-	updatedResult, err := r.collection.UpdateOne(ctx, filter, update, opts)
+	var previous models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&previous)
 
-	if err != nil {
-		slog.Error("Failed to upsert", "error", err, "userId", user.UserId)
-		return false, err
+	if err == mongo.ErrNoDocuments {
+		slog.Debug("Upserted, inserted", "userId", user.UserId)
+		return nil, nil
 	}
 
-	slog.Debug("Upserted", "userId", user.UserId, "updatedResult", updatedResult)
-
-	if updatedResult.UpsertedCount == 1 {
-		return true, nil
+	if err != nil {
+		slog.Error("Failed to upsert", "error", err, "userId", user.UserId)
+		return nil, err
 	}
 
-	return false, nil
+	slog.Debug("Upserted, updated", "userId", user.UserId)
+	return &previous, nil
 }
 
 func (r *userRepository) FindByUserId(userId string) (*models.User, error) {
@@ -113,9 +161,31 @@ func (r *userRepository) FindByUserId(userId string) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *userRepository) UpdateWhatsAppNumber(userId string, whatsAppNumber string) error {
+// FindForUpdate reads the user inside the transaction about to change it, so the read shares the transaction's snapshot
+// and a concurrent change makes the later write conflict instead of being compared against a stale user
+func (r *userRepository) FindForUpdate(ctx context.Context, userId string) (*models.User, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+
+	var user models.User
+	err := r.collection.FindOne(ctx, filter).Decode(&user)
+
+	if err != nil {
+		slog.Error("Failed to find user for update", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Found user for update", "userId", userId)
+	return &user, nil
+
+}
+
+func (r *userRepository) UpdateWhatsAppNumber(ctx context.Context, userId string, whatsAppNumber string) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"userId": userId}
@@ -135,15 +205,18 @@ func (r *userRepository) UpdateWhatsAppNumber(userId string, whatsAppNumber stri
 		},
 	}
 
-	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
 
 	if err != nil {
 		slog.Error("Failed to update WhatsApp number", "error", err, "userId", userId, "whatsAppNumber", whatsAppNumber)
-		return err
+		return nil, err
 	}
 
-	slog.Debug("Updated WhatsApp number", "userId", userId, "whatsAppNumber", whatsAppNumber, "updatedResult", updatedResult)
-	return nil
+	slog.Debug("Updated WhatsApp number", "userId", userId, "whatsAppNumber", whatsAppNumber)
+	return &user, nil
 }
 
 func (r *userRepository) FindWhatsAppNumber(userId string) (string, error) {
@@ -165,9 +238,9 @@ func (r *userRepository) FindWhatsAppNumber(userId string) (string, error) {
 	return user.WhatsAppNumber, nil
 }
 
-func (r *userRepository) UpdateDiscordId(userId string, discordId string) error {
+func (r *userRepository) UpdateDiscordAccount(ctx context.Context, userId string, discordId string, discordUsername string) (*models.User, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"userId": userId}
@@ -175,8 +248,9 @@ func (r *userRepository) UpdateDiscordId(userId string, discordId string) error
 		{
 			Key: "$set",
 			Value: bson.M{
-				"discordId": discordId,
-				"updatedAt": time.Now().UTC(),
+				"discordId":       discordId,
+				"discordUsername": discordUsername,
+				"updatedAt":       time.Now().UTC(),
 			},
 		},
 		{
@@ -187,39 +261,83 @@ func (r *userRepository) UpdateDiscordId(userId string, discordId string) error
 		},
 	}
 
-	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
 
 	if err != nil {
-		slog.Error("Failed to update Discord ID", "error", err, "userId", userId, "discordId", discordId)
-		return err
+		slog.Error("Failed to update Discord account", "error", err, "userId", userId, "discordId", discordId)
+		return nil, err
 	}
 
-	slog.Debug("Updated Discord ID", "userId", userId, "discordId", discordId, "updatedResult", updatedResult)
-	return nil
+	slog.Debug("Updated Discord account", "userId", userId, "discordId", discordId)
+	return &user, nil
+}
+
+func (r *userRepository) RemoveDiscordAccount(ctx context.Context, userId string) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"updatedAt": time.Now().UTC(),
+			},
+		},
+		{
+			Key: "$unset",
+			Value: bson.M{
+				"discordId":       "",
+				"discordUsername": "",
+			},
+		},
+		{
+			Key: "$pull",
+			Value: bson.M{
+				"notificationInterfaces": constants.Discord.String(),
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
+
+	if err != nil {
+		slog.Error("Failed to remove Discord account", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Removed Discord account", "userId", userId)
+	return &user, nil
 }
 
-func (r *userRepository) FindDiscordId(userId string) (string, error) {
+func (r *userRepository) FindDiscordAccount(userId string) (string, string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"userId": userId}
-	opts := options.FindOne().SetProjection(bson.M{"discordId": 1})
+	opts := options.FindOne().SetProjection(bson.M{"discordId": 1, "discordUsername": 1})
 
 	var user models.User
 	err := r.collection.FindOne(ctx, filter, opts).Decode(&user)
 
 	if err != nil {
-		slog.Error("Failed to find Discord ID", "error", err, "userId", userId)
-		return "", err
+		slog.Error("Failed to find Discord account", "error", err, "userId", userId)
+		return "", "", err
 	}
 
-	slog.Debug("Found Discord ID", "userId", userId, "discordId", user.DiscordId)
-	return user.DiscordId, nil
+	slog.Debug("Found Discord account", "userId", userId, "discordId", user.DiscordId)
+	return user.DiscordId, user.DiscordUsername, nil
 }
 
-func (r *userRepository) UpdateTelegramNumber(userId string, telegramNumber string) error {
+func (r *userRepository) UpdateTelegramNumber(ctx context.Context, userId string, telegramNumber string) (*models.User, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"userId": userId}
@@ -239,15 +357,18 @@ func (r *userRepository) UpdateTelegramNumber(userId string, telegramNumber stri
 		},
 	}
 
-	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
 
 	if err != nil {
 		slog.Error("Failed to update WhatsApp number", "error", err, "userId", userId, "telegramNumber", telegramNumber)
-		return err
+		return nil, err
 	}
 
-	slog.Debug("Updated WhatsApp number", "userId", userId, "telegramNumber", telegramNumber, "updatedResult", updatedResult)
-	return nil
+	slog.Debug("Updated WhatsApp number", "userId", userId, "telegramNumber", telegramNumber)
+	return &user, nil
 
 }
 
@@ -272,11 +393,168 @@ func (r *userRepository) FindTelegramNumber(userId string) (string, error) {
 
 }
 
-func (r *userRepository) UpdateNotificationInterfaces(userId string, notificationInterfaces []string) error {
+// UpdateTelegramChatId stores the chat the bot reached the user in and enables Telegram notifications
+func (r *userRepository) UpdateTelegramChatId(ctx context.Context, userId string, telegramChatId int64) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"telegramChatId": telegramChatId,
+				"updatedAt":      time.Now().UTC(),
+			},
+		},
+		{
+			Key: "$addToSet",
+			Value: bson.M{
+				"notificationInterfaces": constants.Telegram.String(),
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
+
+	if err != nil {
+		slog.Error("Failed to update Telegram chat id", "error", err, "userId", userId, "telegramChatId", telegramChatId)
+		return nil, err
+	}
+
+	slog.Debug("Updated Telegram chat id", "userId", userId, "telegramChatId", telegramChatId)
+	return &user, nil
+
+}
+
+// UpdateChannelVerification starts a new verification for the channel, keeping the previously verified number until it is confirmed
+func (r *userRepository) UpdateChannelVerification(ctx context.Context, userId string, channel string, verification *models.ChannelVerification) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+	update := bson.D{{
+		Key: "$set",
+		Value: bson.M{
+			"channelVerifications." + channel + ".number":      verification.Number,
+			"channelVerifications." + channel + ".status":      verification.Status,
+			"channelVerifications." + channel + ".codeHash":    verification.CodeHash,
+			"channelVerifications." + channel + ".attempts":    verification.Attempts,
+			"channelVerifications." + channel + ".expiresAt":   verification.ExpiresAt,
+			"channelVerifications." + channel + ".requestedAt": verification.RequestedAt,
+			"updatedAt": time.Now().UTC(),
+		},
+	}}
+
+	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		slog.Error("Failed to update channel verification", "error", err, "userId", userId, "channel", channel)
+		return err
+	}
+
+	if updatedResult.MatchedCount == 0 {
+		slog.Error("Failed to update channel verification, user not found", "userId", userId, "channel", channel)
+		return mongo.ErrNoDocuments
+	}
+
+	slog.Debug("Updated channel verification", "userId", userId, "channel", channel, "updatedResult", updatedResult)
+	return nil
+
+}
+
+// IncrementChannelVerificationAttempts uses up one attempt of the pending verification,
+// it returns mongo.ErrNoDocuments once maxAttempts have been used
+func (r *userRepository) IncrementChannelVerificationAttempts(userId string, channel string, maxAttempts int) error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	filter := bson.M{
+		"userId": userId,
+		"channelVerifications." + channel + ".status":   constants.VerificationPending.String(),
+		"channelVerifications." + channel + ".attempts": bson.M{"$lt": maxAttempts},
+	}
+	update := bson.D{{
+		Key: "$inc",
+		Value: bson.M{
+			"channelVerifications." + channel + ".attempts": 1,
+		},
+	}}
+
+	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		slog.Error("Failed to increment channel verification attempts", "error", err, "userId", userId, "channel", channel)
+		return err
+	}
+
+	if updatedResult.MatchedCount == 0 {
+		slog.Error("Failed to increment channel verification attempts, no attempts left", "userId", userId, "channel", channel)
+		return mongo.ErrNoDocuments
+	}
+
+	slog.Debug("Incremented channel verification attempts", "userId", userId, "channel", channel, "updatedResult", updatedResult)
+	return nil
+
+}
+
+// MarkChannelVerified only matches while the verification the code was checked against is still pending,
+// so a number replaced in the meantime is never marked verified
+func (r *userRepository) MarkChannelVerified(ctx context.Context, userId string, channel string, number string, codeHash string) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"userId": userId,
+		"channelVerifications." + channel + ".status":   constants.VerificationPending.String(),
+		"channelVerifications." + channel + ".codeHash": codeHash,
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"channelVerifications." + channel + ".status":         constants.VerificationVerified.String(),
+				"channelVerifications." + channel + ".verifiedNumber": number,
+				"channelVerifications." + channel + ".verifiedAt":     time.Now().UTC(),
+			},
+		},
+		{
+			Key: "$unset",
+			Value: bson.M{
+				"channelVerifications." + channel + ".codeHash":  "",
+				"channelVerifications." + channel + ".expiresAt": "",
+			},
+		},
+	}
+
+	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		slog.Error("Failed to mark channel verified", "error", err, "userId", userId, "channel", channel)
+		return err
+	}
+
+	if updatedResult.MatchedCount == 0 {
+		slog.Error("Failed to mark channel verified, no matching pending verification", "userId", userId, "channel", channel)
+		return mongo.ErrNoDocuments
+	}
+
+	slog.Debug("Marked channel verified", "userId", userId, "channel", channel, "updatedResult", updatedResult)
+	return nil
+
+}
+
+func (r *userRepository) UpdateNotificationInterfaces(ctx context.Context, userId string, notificationInterfaces []string) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
 	filter := bson.M{"userId": userId}
 	update := bson.D{{
 		Key: "$set",
@@ -286,15 +564,18 @@ func (r *userRepository) UpdateNotificationInterfaces(userId string, notificatio
 		},
 	}}
 
-	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
 
 	if err != nil {
 		slog.Error("Failed to update WhatsApp number", "error", err, "userId", userId, "notificationInterfaces", notificationInterfaces)
-		return err
+		return nil, err
 	}
 
-	slog.Debug("Updated WhatsApp number", "userId", userId, "notificationInterfaces", notificationInterfaces, "updatedResult", updatedResult)
-	return nil
+	slog.Debug("Updated WhatsApp number", "userId", userId, "notificationInterfaces", notificationInterfaces)
+	return &user, nil
 
 }
 
@@ -319,23 +600,100 @@ func (r *userRepository) FindNotificationInterfaces(userId string) ([]string, er
 
 }
 
-func (r *userRepository) InsertFCMtoken(userId string, FCMtoken string) error {
+func (r *userRepository) UpdateNotificationPreferences(ctx context.Context, userId string, notificationPreferences map[string][]string) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+	update := bson.D{{
+		Key: "$set",
+		Value: bson.M{
+			"notificationPreferences": notificationPreferences,
+			"updatedAt":               time.Now().UTC(),
+		},
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
+
+	if err != nil {
+		slog.Error("Failed to update Notification Preferences", "error", err, "userId", userId, "notificationPreferences", notificationPreferences)
+		return nil, err
+	}
+
+	slog.Debug("Updated Notification Preferences", "userId", userId, "notificationPreferences", notificationPreferences)
+	return &user, nil
+
+}
+
+func (r *userRepository) FindNotificationPreferences(userId string) (map[string][]string, error) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"userId": userId}
-	update := bson.D{
+	opts := options.FindOne().SetProjection(bson.M{"notificationPreferences": 1})
+
+	var user models.User
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&user)
+
+	if err != nil {
+		slog.Error("Failed to find Notification Preferences", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Found Notification Preferences", "userId", userId, "notificationPreferences", user.NotificationPreferences)
+	return user.NotificationPreferences, nil
+
+}
+
+// RefreshFCMtoken bumps lastRefreshedAt and the device metadata of a known token, or adds a new one.
+// Only the maxFCMtokens most recently refreshed tokens are kept, the least recently refreshed are evicted.
+// The token is only pushed while it is absent, a refresh racing another one for the same new token refreshes it instead.
+func (r *userRepository) RefreshFCMtoken(ctx context.Context, userId string, FCMtoken *models.FCMtoken, maxFCMtokens int) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	FCMtoken.CreatedAt = now
+	FCMtoken.LastRefreshedAt = now
+
+	refresh := bson.D{
 		{
 			Key: "$set",
 			Value: bson.M{
-				"updatedAt": time.Now().UTC(),
+				"fcmTokens.$.deviceLabel":     FCMtoken.DeviceLabel,
+				"fcmTokens.$.platform":        FCMtoken.Platform,
+				"fcmTokens.$.userAgent":       FCMtoken.UserAgent,
+				"fcmTokens.$.lastRefreshedAt": now,
+				"updatedAt":                   now,
 			},
 		},
 		{
 			Key: "$addToSet",
 			Value: bson.M{
-				"FCMtokens": FCMtoken,
+				"notificationInterfaces": constants.UI.String(),
+			},
+		},
+	}
+	insert := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"updatedAt": now,
+			},
+		},
+		{
+			Key: "$push",
+			Value: bson.M{
+				"fcmTokens": bson.M{
+					"$each":  []*models.FCMtoken{FCMtoken},
+					"$sort":  bson.M{"lastRefreshedAt": 1},
+					"$slice": -maxFCMtokens,
+				},
 			},
 		},
 		{
@@ -346,21 +704,53 @@ func (r *userRepository) InsertFCMtoken(userId string, FCMtoken string) error {
 		},
 	}
 
-	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
-	if err != nil {
-		slog.Error("Failed to insert FCM token", "error", err, "userId", userId, "FCMtoken", FCMtoken)
-		return err
+	var user models.User
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		err = r.collection.FindOneAndUpdate(ctx, bson.M{"userId": userId, "fcmTokens.token": FCMtoken.Token}, refresh, opts).Decode(&user)
+		if err == nil {
+			slog.Debug("Refreshed FCM token", "userId", userId, "FCMtoken", FCMtoken.Token)
+			return &user, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			slog.Error("Failed to refresh FCM token", "error", err, "userId", userId, "FCMtoken", FCMtoken.Token)
+			return nil, err
+		}
+
+		err = r.collection.FindOneAndUpdate(ctx, bson.M{"userId": userId, "fcmTokens.token": bson.M{"$ne": FCMtoken.Token}}, insert, opts).Decode(&user)
+		if err == nil {
+			slog.Debug("Inserted FCM token", "userId", userId, "FCMtoken", FCMtoken.Token)
+			return &user, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			slog.Error("Failed to insert FCM token", "error", err, "userId", userId, "FCMtoken", FCMtoken.Token)
+			return nil, err
+		}
+		// either the user does not exist or the token was added since the refresh above, which the next round refreshes
 	}
 
-	slog.Debug("Inserted FCM token", "userId", userId, "FCMtoken", FCMtoken, "updatedResult", updatedResult)
-	return nil
+	slog.Error("Failed to refresh FCM token, user not found", "userId", userId, "FCMtoken", FCMtoken.Token)
+	return nil, err
 
 }
 
-func (r *userRepository) RemoveFCMtoken(userId string, FCMtoken string) error {
+func (r *userRepository) RemoveFCMtoken(ctx context.Context, userId string, FCMtoken string) (*models.User, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	return r.pullFCMtokens(ctx, userId, bson.M{"token": FCMtoken})
+
+}
+
+func (r *userRepository) RemoveStaleFCMtokens(ctx context.Context, userId string, refreshedBefore time.Time) (*models.User, error) {
+
+	return r.pullFCMtokens(ctx, userId, bson.M{"lastRefreshedAt": bson.M{"$lt": refreshedBefore}})
+
+}
+
+func (r *userRepository) pullFCMtokens(ctx context.Context, userId string, condition bson.M) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"userId": userId}
@@ -374,30 +764,33 @@ func (r *userRepository) RemoveFCMtoken(userId string, FCMtoken string) error {
 		{
 			Key: "$pull",
 			Value: bson.M{
-				"FCMtokens": FCMtoken,
+				"fcmTokens": condition,
 			},
 		},
 	}
 
-	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
 
 	if err != nil {
-		slog.Error("Failed to remove FCM token", "error", err, "userId", userId, "FCMtoken", FCMtoken)
-		return err
+		slog.Error("Failed to remove FCM tokens", "error", err, "userId", userId, "condition", condition)
+		return nil, err
 	}
 
-	slog.Debug("Removed FCM token", "userId", userId, "FCMtoken", FCMtoken, "updatedResult", updatedResult)
-	return nil
+	slog.Debug("Removed FCM tokens", "userId", userId, "condition", condition)
+	return &user, nil
 
 }
 
-func (r *userRepository) FindFCMtokens(userId string) ([]string, error) {
+func (r *userRepository) FindFCMtokens(userId string) ([]models.FCMtoken, error) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"userId": userId}
-	opts := options.FindOne().SetProjection(bson.M{"FCMtokens": 1})
+	opts := options.FindOne().SetProjection(bson.M{"fcmTokens": 1})
 
 	var user models.User
 	err := r.collection.FindOne(ctx, filter, opts).Decode(&user)
@@ -407,69 +800,345 @@ func (r *userRepository) FindFCMtokens(userId string) ([]string, error) {
 		return nil, err
 	}
 
-	slog.Debug("Found FCM tokens", "userId", userId, "FCMtokens", user.FCMtokens)
+	if user.FCMtokens == nil {
+		user.FCMtokens = []models.FCMtoken{}
+	}
+
+	slog.Debug("Found FCM tokens", "userId", userId, "count", len(user.FCMtokens))
 	return user.FCMtokens, nil
 
 }
 
-func (r *userRepository) UpdateWebhooks(userId string, webhooks []string) error {
+func (r *userRepository) FindUserIdByFCMtoken(FCMtoken string) (string, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"fcmTokens.token": FCMtoken}
+	opts := options.FindOne().SetProjection(bson.M{"userId": 1})
+
+	var user models.User
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&user)
+
+	if err != nil {
+		slog.Error("Failed to find user by FCM token", "error", err, "FCMtoken", FCMtoken)
+		return "", err
+	}
+
+	slog.Debug("Found user by FCM token", "userId", user.UserId, "FCMtoken", FCMtoken)
+	return user.UserId, nil
+
+}
+
+func (r *userRepository) FindUserIdsWithStaleFCMtokens(refreshedBefore time.Time, limit int) ([]string, error) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	filter := bson.M{"fcmTokens.lastRefreshedAt": bson.M{"$lt": refreshedBefore}}
+	opts := options.Find().SetProjection(bson.M{"userId": 1}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		slog.Error("Failed to find users with stale FCM tokens", "error", err)
+		return nil, err
+	}
+
+	var users []models.User
+	err = cursor.All(ctx, &users)
+
+	if err != nil {
+		slog.Error("Failed to decode users with stale FCM tokens", "error", err)
+		return nil, err
+	}
+
+	userIds := make([]string, 0, len(users))
+	for _, user := range users {
+		userIds = append(userIds, user.UserId)
+	}
+
+	slog.Debug("Found users with stale FCM tokens", "count", len(userIds))
+	return userIds, nil
+
+}
+
+func (r *userRepository) InsertNotificationInterface(ctx context.Context, userId string, notificationInterface string) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
 	filter := bson.M{"userId": userId}
 	update := bson.D{
 		{
 			Key: "$set",
 			Value: bson.M{
-				"webhooks":  webhooks,
 				"updatedAt": time.Now().UTC(),
 			},
 		},
 		{
 			Key: "$addToSet",
 			Value: bson.M{
-				"notificationInterfaces": constants.Webhooks.String(),
+				"notificationInterfaces": notificationInterface,
 			},
 		},
 	}
 
-	updatedResult, err := r.collection.UpdateOne(ctx, filter, update)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
 
 	if err != nil {
-		slog.Error("Failed to update WhatsApp number", "error", err, "userId", userId, "webhooks", webhooks)
-		return err
+		slog.Error("Failed to insert notification interface", "error", err, "userId", userId, "notificationInterface", notificationInterface)
+		return nil, err
 	}
 
-	slog.Debug("Updated WhatsApp number", "userId", userId, "webhooks", webhooks, "updatedResult", updatedResult)
-	return nil
+	slog.Debug("Inserted notification interface", "userId", userId, "notificationInterface", notificationInterface)
+	return &user, nil
 
 }
 
-func (r *userRepository) FindWebhooks(userId string) ([]string, error) {
+// RemoveNotificationInterface disables the notification interface and drops its verification,
+// so a disabled WhatsApp or Telegram number has to be confirmed again before it is used
+func (r *userRepository) RemoveNotificationInterface(ctx context.Context, userId string, notificationInterface string) (*models.User, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"userId": userId}
-	opts := options.FindOne().SetProjection(bson.M{"webhooks": 1})
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"updatedAt": time.Now().UTC(),
+			},
+		},
+		{
+			Key: "$unset",
+			Value: bson.M{
+				"channelVerifications." + notificationInterface: "",
+			},
+		},
+		{
+			Key: "$pull",
+			Value: bson.M{
+				"notificationInterfaces": notificationInterface,
+			},
+		},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
 	var user models.User
-	err := r.collection.FindOne(ctx, filter, opts).Decode(&user)
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
 
 	if err != nil {
-		slog.Error("Failed to find Webhooks", "error", err, "userId", userId)
+		slog.Error("Failed to remove notification interface", "error", err, "userId", userId, "notificationInterface", notificationInterface)
 		return nil, err
 	}
 
-	slog.Debug("Found Webhooks", "userId", userId, "webhooks", user.Webhooks)
-	return user.Webhooks, nil
+	slog.Debug("Removed notification interface", "userId", userId, "notificationInterface", notificationInterface)
+	return &user, nil
 
 }
 
-func (r *userRepository) Delete(userId string) error {
+// Find returns a page of the users matching the query, newest first, along with the total count
+func (r *userRepository) Find(query *models.UserQuery, page int, limit int) ([]models.User, int64, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if query.UserId != "" {
+		filter["userId"] = query.UserId
+	}
+	if query.Email != "" {
+		// emails are matched case-insensitively against the lowercased copy kept next to the email
+		filter["emailLower"] = strings.ToLower(query.Email)
+	}
+	if query.Phone != "" {
+		filter["$or"] = bson.A{
+			bson.M{"whatsAppNumber": query.Phone},
+			bson.M{"telegramNumber": query.Phone},
+		}
+	}
+	if query.DiscordId != "" {
+		filter["discordId"] = query.DiscordId
+	}
+	if query.NotificationInterface != "" {
+		filter["notificationInterfaces"] = query.NotificationInterface
+	}
+	if !query.CreatedFrom.IsZero() || !query.CreatedTo.IsZero() {
+		createdAt := bson.M{}
+		if !query.CreatedFrom.IsZero() {
+			createdAt["$gte"] = query.CreatedFrom
+		}
+		if !query.CreatedTo.IsZero() {
+			createdAt["$lt"] = query.CreatedTo
+		}
+		filter["createdAt"] = createdAt
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		slog.Error("Failed to count users", "error", err, "query", query)
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		slog.Error("Failed to find users", "error", err, "query", query)
+		return nil, 0, err
+	}
+
+	users := []models.User{}
+	err = cursor.All(ctx, &users)
+
+	if err != nil {
+		slog.Error("Failed to decode users", "error", err, "query", query)
+		return nil, 0, err
+	}
+
+	slog.Debug("Found users", "query", query, "page", page, "count", len(users))
+	return users, total, nil
+
+}
+
+// UpdateFields sets the given fields, by their bson name, for operators editing a user
+func (r *userRepository) UpdateFields(ctx context.Context, userId string, fields bson.M) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	fields["updatedAt"] = time.Now().UTC()
+
+	filter := bson.M{"userId": userId}
+	update := bson.D{{Key: "$set", Value: fields}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
+
+	if err != nil {
+		slog.Error("Failed to update user", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Updated user", "userId", userId)
+	return &user, nil
+
+}
+
+// MarkDeleted soft deletes the user ahead of the erasure, it returns mongo.ErrNoDocuments
+// when the user does not exist or is already deleted
+func (r *userRepository) MarkDeleted(ctx context.Context, userId string) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	filter := bson.M{
+		"userId":    userId,
+		"deletedAt": bson.M{"$exists": false},
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"deletedAt": now,
+				"updatedAt": now,
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
+
+	if err != nil {
+		slog.Error("Failed to mark user deleted", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Marked user deleted", "userId", userId)
+	return &user, nil
+
+}
+
+// Restore undoes a soft delete
+func (r *userRepository) Restore(ctx context.Context, userId string) (*models.User, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"userId":    userId,
+		"deletedAt": bson.M{"$exists": true},
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"updatedAt": time.Now().UTC(),
+			},
+		},
+		{
+			Key: "$unset",
+			Value: bson.M{
+				"deletedAt": "",
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
+
+	if err != nil {
+		slog.Error("Failed to restore user", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Restored user", "userId", userId)
+	return &user, nil
+
+}
+
+// LockExports writes the user's exportLockedAt so that export requests in concurrent transactions conflict,
+// leaving updatedAt alone as nothing about the user changed. It returns mongo.ErrNoDocuments when the user does not exist.
+func (r *userRepository) LockExports(ctx context.Context, userId string) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+	update := bson.M{"$set": bson.M{"exportLockedAt": time.Now().UTC()}}
+
+	updateResult, err := r.collection.UpdateOne(ctx, filter, update)
+
+	if err != nil {
+		slog.Error("Failed to lock exports", "error", err, "userId", userId)
+		return err
+	}
+
+	if updateResult.MatchedCount == 0 {
+		slog.Error("Failed to lock exports, user not found", "userId", userId)
+		return mongo.ErrNoDocuments
+	}
+
+	slog.Debug("Locked exports", "userId", userId)
+	return nil
+
+}
+
+func (r *userRepository) Delete(ctx context.Context, userId string) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"userId": userId}
@@ -481,6 +1150,11 @@ func (r *userRepository) Delete(userId string) error {
 		return err
 	}
 
+	if deletedResult.DeletedCount == 0 {
+		slog.Error("Failed to delete, user not found", "userId", userId)
+		return mongo.ErrNoDocuments
+	}
+
 	slog.Debug("Deleted", "userId", userId, "deletedResult", deletedResult)
 	return nil
 
@@ -507,3 +1181,50 @@ func (r *userRepository) MakeUserIdUniqueIndex() {
 	slog.Debug("Created userId index", "indexName", indexName)
 
 }
+
+func (r *userRepository) MakeFCMtokenIndexes() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexNames, err := r.collection.Indexes().CreateMany(
+		ctx,
+		[]mongo.IndexModel{
+			{Keys: bson.D{{Key: "fcmTokens.token", Value: 1}}},
+			{Keys: bson.D{{Key: "fcmTokens.lastRefreshedAt", Value: 1}}},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating FCM token indexes", "indexNames", indexNames)
+		panic(err)
+	}
+
+	slog.Debug("Created FCM token indexes", "indexNames", indexNames)
+
+}
+
+func (r *userRepository) MakeSearchIndexes() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexNames, err := r.collection.Indexes().CreateMany(
+		ctx,
+		[]mongo.IndexModel{
+			{Keys: bson.D{{Key: "emailLower", Value: 1}}},
+			{Keys: bson.D{{Key: "whatsAppNumber", Value: 1}}},
+			{Keys: bson.D{{Key: "telegramNumber", Value: 1}}},
+			{Keys: bson.D{{Key: "discordId", Value: 1}}},
+			{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating search indexes", "indexNames", indexNames)
+		panic(err)
+	}
+
+	slog.Debug("Created search indexes", "indexNames", indexNames)
+
+}
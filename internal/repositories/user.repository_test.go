@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newTestCollection connects to MONGO_TEST_URI and hands out a collection in a database of its own, dropped after the test.
+// Without MONGO_TEST_URI the integration tests are skipped.
+func newTestCollection(t *testing.T, name string) *mongo.Collection {
+
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	database := client.Database("test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		database.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	return database.Collection(name)
+
+}
+
+func TestUpsertKeepsReturningUser(t *testing.T) {
+
+	collection := newTestCollection(t, "users")
+	repository := NewUserRepository(collection)
+	ctx := context.Background()
+
+	previous, err := repository.Upsert(ctx, &models.User{UserId: "user-1", Email: "first@example.com", NotificationInterfaces: []string{constants.Email.String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous != nil {
+		t.Fatal("first upsert found an existing user")
+	}
+
+	inserted, err := repository.FindByUserId("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inserted.LoginCount != 1 {
+		t.Errorf("got loginCount %d after the first login, want 1", inserted.LoginCount)
+	}
+
+	channels := []string{constants.Email.String(), constants.WhatsApp.String(), constants.Webhooks.String()}
+	_, err = repository.UpdateNotificationInterfaces(ctx, "user-1", channels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// login again, the frontend sends the default channels every time
+	previous, err = repository.Upsert(ctx, &models.User{UserId: "user-1", Email: "second@example.com", NotificationInterfaces: []string{constants.Email.String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous == nil {
+		t.Fatal("second upsert inserted the user again")
+	}
+
+	user, err := repository.FindByUserId("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(user.NotificationInterfaces, channels) {
+		t.Errorf("got notificationInterfaces %v, want %v", user.NotificationInterfaces, channels)
+	}
+	if !user.CreatedAt.Equal(inserted.CreatedAt) {
+		t.Errorf("createdAt moved from %v to %v", inserted.CreatedAt, user.CreatedAt)
+	}
+	if user.LoginCount != 2 {
+		t.Errorf("got loginCount %d, want 2", user.LoginCount)
+	}
+	if user.LastLoginAt.Before(inserted.LastLoginAt) {
+		t.Errorf("lastLoginAt went back from %v to %v", inserted.LastLoginAt, user.LastLoginAt)
+	}
+	if user.Email != "second@example.com" {
+		t.Errorf("got email %q, want the refreshed one", user.Email)
+	}
+
+}
+
+func TestFindMatchesOnlyEmailCaseInsensitively(t *testing.T) {
+
+	collection := newTestCollection(t, "users")
+	repository := NewUserRepository(collection)
+	ctx := context.Background()
+
+	for _, user := range []*models.User{
+		{UserId: "user-1", Email: "Nelly@Example.com"},
+		{UserId: "User-1", Email: "other@example.com"},
+	} {
+		_, err := repository.Upsert(ctx, user)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	users, total, err := repository.Find(&models.UserQuery{Email: "nelly@example.COM"}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(users) != 1 || users[0].UserId != "user-1" {
+		t.Errorf("email search found %v, want user-1", users)
+	}
+
+	users, total, err = repository.Find(&models.UserQuery{UserId: "user-1"}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(users) != 1 || users[0].UserId != "user-1" {
+		t.Errorf("userId search found %v, want only user-1", users)
+	}
+
+}
@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slog"
+)
+
+type WebhookDeliveryRepository interface {
+	Insert(delivery *models.WebhookDelivery) error
+	FindByWebhookId(userId string, webhookId primitive.ObjectID, page int, limit int) ([]models.WebhookDelivery, int64, error)
+	FindByUserId(userId string, limit int) ([]models.WebhookDelivery, error)
+	DeleteByUserId(userId string) (int64, error)
+}
+
+type WebhookDeliveryRepositorySetup interface {
+	MakeWebhookIdCreatedAtIndex()
+	MakeCreatedAtTTLIndex(expireAfter time.Duration)
+}
+
+type webhookDeliveryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookDeliveryRepository(collection *mongo.Collection) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{
+		collection: collection,
+	}
+}
+
+func NewWebhookDeliveryRepositorySetup(collection *mongo.Collection) WebhookDeliveryRepositorySetup {
+	return &webhookDeliveryRepository{
+		collection: collection,
+	}
+}
+
+func (r *webhookDeliveryRepository) Insert(delivery *models.WebhookDelivery) error {
+	delivery.Id = primitive.NewObjectID()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	insertedResult, err := r.collection.InsertOne(ctx, delivery)
+
+	if err != nil {
+		slog.Error("Failed to insert webhook delivery", "error", err, "webhookId", delivery.WebhookId)
+		return err
+	}
+
+	slog.Debug("Inserted webhook delivery", "webhookId", delivery.WebhookId, "insertedResult", insertedResult)
+	return nil
+}
+
+// FindByWebhookId returns a page of the webhook's deliveries, newest first, along with the total count
+func (r *webhookDeliveryRepository) FindByWebhookId(userId string, webhookId primitive.ObjectID, page int, limit int) ([]models.WebhookDelivery, int64, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId, "webhookId": webhookId}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		slog.Error("Failed to count webhook deliveries", "error", err, "webhookId", webhookId)
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(int64(page-1) * int64(limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		slog.Error("Failed to find webhook deliveries", "error", err, "webhookId", webhookId)
+		return nil, 0, err
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	err = cursor.All(ctx, &deliveries)
+
+	if err != nil {
+		slog.Error("Failed to decode webhook deliveries", "error", err, "webhookId", webhookId)
+		return nil, 0, err
+	}
+
+	slog.Debug("Found webhook deliveries", "webhookId", webhookId, "page", page, "count", len(deliveries))
+	return deliveries, total, nil
+
+}
+
+// FindByUserId returns every delivery kept for the user's webhooks, newest first
+// FindByUserId returns up to limit of the user's deliveries, newest first
+func (r *webhookDeliveryRepository) FindByUserId(userId string, limit int) ([]models.WebhookDelivery, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		slog.Error("Failed to find webhook deliveries", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	err = cursor.All(ctx, &deliveries)
+
+	if err != nil {
+		slog.Error("Failed to decode webhook deliveries", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Found webhook deliveries", "userId", userId, "count", len(deliveries))
+	return deliveries, nil
+
+}
+
+func (r *webhookDeliveryRepository) DeleteByUserId(userId string) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+
+	deletedResult, err := r.collection.DeleteMany(ctx, filter)
+
+	if err != nil {
+		slog.Error("Failed to delete webhook deliveries", "error", err, "userId", userId)
+		return 0, err
+	}
+
+	slog.Debug("Deleted webhook deliveries", "userId", userId, "deletedCount", deletedResult.DeletedCount)
+	return deletedResult.DeletedCount, nil
+
+}
+
+func (r *webhookDeliveryRepository) MakeWebhookIdCreatedAtIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "webhookId", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating webhookId createdAt index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created webhookId createdAt index", "indexName", indexName)
+
+}
+
+func (r *webhookDeliveryRepository) MakeCreatedAtTTLIndex(expireAfter time.Duration) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(expireAfter.Seconds())),
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating createdAt TTL index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created createdAt TTL index", "indexName", indexName)
+
+}
@@ -0,0 +1,397 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slog"
+)
+
+type WebhookRepository interface {
+	Insert(ctx context.Context, webhook *models.Webhook) error
+	FindByUserId(userId string) ([]models.Webhook, error)
+	FindById(userId string, id primitive.ObjectID) (*models.Webhook, error)
+	FindForUpdate(ctx context.Context, userId string, id primitive.ObjectID) (*models.Webhook, error)
+	CountByUserId(ctx context.Context, userId string) (int64, error)
+	Update(ctx context.Context, userId string, id primitive.ObjectID, fields bson.M) (*models.Webhook, error)
+	UpdateSecrets(ctx context.Context, userId string, id primitive.ObjectID, secrets []models.WebhookSecret) (*models.Webhook, error)
+	ClaimTest(userId string, id primitive.ObjectID, interval time.Duration) (*models.Webhook, error)
+	Delete(ctx context.Context, userId string, id primitive.ObjectID) error
+	DeleteByUserId(ctx context.Context, userId string) (int64, error)
+	ClaimNextVerification(lease time.Duration) (*models.Webhook, error)
+	UpdateVerification(ctx context.Context, id primitive.ObjectID, url string, verification models.WebhookVerification) (*models.Webhook, error)
+}
+
+type WebhookRepositorySetup interface {
+	MakeUserIdIndex()
+	MakeVerificationIndex()
+}
+
+type webhookRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookRepository(collection *mongo.Collection) WebhookRepository {
+	return &webhookRepository{
+		collection: collection,
+	}
+}
+
+func NewWebhookRepositorySetup(collection *mongo.Collection) WebhookRepositorySetup {
+	return &webhookRepository{
+		collection: collection,
+	}
+}
+
+func (r *webhookRepository) Insert(ctx context.Context, webhook *models.Webhook) error {
+	now := time.Now().UTC()
+	webhook.Id = primitive.NewObjectID()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	insertedResult, err := r.collection.InsertOne(ctx, webhook)
+
+	if err != nil {
+		slog.Error("Failed to insert webhook", "error", err, "userId", webhook.UserId)
+		return err
+	}
+
+	slog.Debug("Inserted webhook", "userId", webhook.UserId, "insertedResult", insertedResult)
+	return nil
+}
+
+func (r *webhookRepository) FindByUserId(userId string) ([]models.Webhook, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		slog.Error("Failed to find webhooks", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	webhooks := []models.Webhook{}
+	err = cursor.All(ctx, &webhooks)
+
+	if err != nil {
+		slog.Error("Failed to decode webhooks", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Found webhooks", "userId", userId, "count", len(webhooks))
+	return webhooks, nil
+
+}
+
+func (r *webhookRepository) FindById(userId string, id primitive.ObjectID) (*models.Webhook, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "userId": userId}
+
+	var webhook models.Webhook
+	err := r.collection.FindOne(ctx, filter).Decode(&webhook)
+
+	if err != nil {
+		slog.Error("Failed to find webhook", "error", err, "userId", userId, "id", id)
+		return nil, err
+	}
+
+	slog.Debug("Found webhook", "userId", userId, "id", id)
+	return &webhook, nil
+
+}
+
+// FindForUpdate reads the webhook inside the transaction about to change it, like the user's FindForUpdate
+func (r *webhookRepository) FindForUpdate(ctx context.Context, userId string, id primitive.ObjectID) (*models.Webhook, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "userId": userId}
+
+	var webhook models.Webhook
+	err := r.collection.FindOne(ctx, filter).Decode(&webhook)
+
+	if err != nil {
+		slog.Error("Failed to find webhook for update", "error", err, "userId", userId, "id", id)
+		return nil, err
+	}
+
+	slog.Debug("Found webhook for update", "userId", userId, "id", id)
+	return &webhook, nil
+
+}
+
+func (r *webhookRepository) CountByUserId(ctx context.Context, userId string) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+
+	count, err := r.collection.CountDocuments(ctx, filter)
+
+	if err != nil {
+		slog.Error("Failed to count webhooks", "error", err, "userId", userId)
+		return 0, err
+	}
+
+	slog.Debug("Counted webhooks", "userId", userId, "count", count)
+	return count, nil
+
+}
+
+func (r *webhookRepository) Update(ctx context.Context, userId string, id primitive.ObjectID, fields bson.M) (*models.Webhook, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	fields["updatedAt"] = time.Now().UTC()
+
+	filter := bson.M{"_id": id, "userId": userId}
+	update := bson.D{{Key: "$set", Value: fields}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var webhook models.Webhook
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&webhook)
+
+	if err != nil {
+		slog.Error("Failed to update webhook", "error", err, "userId", userId, "id", id)
+		return nil, err
+	}
+
+	slog.Debug("Updated webhook", "userId", userId, "id", id)
+	return &webhook, nil
+
+}
+
+func (r *webhookRepository) UpdateSecrets(ctx context.Context, userId string, id primitive.ObjectID, secrets []models.WebhookSecret) (*models.Webhook, error) {
+
+	return r.Update(ctx, userId, id, bson.M{"secrets": secrets})
+
+}
+
+// ClaimTest records a test delivery to the enabled and verified webhook, it returns mongo.ErrNoDocuments
+// when the webhook is disabled, not verified or was tested less than interval ago
+func (r *webhookRepository) ClaimTest(userId string, id primitive.ObjectID, interval time.Duration) (*models.Webhook, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	filter := bson.M{
+		"_id":                 id,
+		"userId":              userId,
+		"enabled":             true,
+		"verification.status": constants.WebhookVerificationVerified.String(),
+		"$or": bson.A{
+			bson.M{"lastTestedAt": bson.M{"$exists": false}},
+			bson.M{"lastTestedAt": bson.M{"$lte": now.Add(-interval)}},
+		},
+	}
+	update := bson.D{{
+		Key: "$set",
+		Value: bson.M{
+			"lastTestedAt": now,
+		},
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var webhook models.Webhook
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&webhook)
+
+	if err != nil {
+		slog.Error("Failed to claim webhook test", "error", err, "userId", userId, "id", id)
+		return nil, err
+	}
+
+	slog.Debug("Claimed webhook test", "userId", userId, "id", id)
+	return &webhook, nil
+
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, userId string, id primitive.ObjectID) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "userId": userId}
+
+	deletedResult, err := r.collection.DeleteOne(ctx, filter)
+
+	if err != nil {
+		slog.Error("Failed to delete webhook", "error", err, "userId", userId, "id", id)
+		return err
+	}
+
+	if deletedResult.DeletedCount == 0 {
+		slog.Error("Failed to delete webhook, not found", "userId", userId, "id", id)
+		return mongo.ErrNoDocuments
+	}
+
+	slog.Debug("Deleted webhook", "userId", userId, "id", id, "deletedResult", deletedResult)
+	return nil
+
+}
+
+func (r *webhookRepository) DeleteByUserId(ctx context.Context, userId string) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": userId}
+
+	deletedResult, err := r.collection.DeleteMany(ctx, filter)
+
+	if err != nil {
+		slog.Error("Failed to delete webhooks", "error", err, "userId", userId)
+		return 0, err
+	}
+
+	slog.Debug("Deleted webhooks", "userId", userId, "deletedCount", deletedResult.DeletedCount)
+	return deletedResult.DeletedCount, nil
+
+}
+
+// ClaimNextVerification leases the oldest due pending verification and counts the attempt.
+// It returns nil when there is nothing to verify.
+func (r *webhookRepository) ClaimNextVerification(lease time.Duration) (*models.Webhook, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	filter := bson.M{
+		"verification.status":        constants.WebhookVerificationPending.String(),
+		"verification.nextAttemptAt": bson.M{"$lte": now},
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"verification.nextAttemptAt": now.Add(lease),
+			},
+		},
+		{
+			Key: "$inc",
+			Value: bson.M{
+				"verification.attempts": 1,
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "verification.nextAttemptAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var webhook models.Webhook
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&webhook)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		slog.Error("Failed to claim webhook verification", "error", err)
+		return nil, err
+	}
+
+	slog.Debug("Claimed webhook verification", "id", webhook.Id, "attempts", webhook.Verification.Attempts)
+	return &webhook, nil
+
+}
+
+// UpdateVerification records the outcome of a handshake. It only applies while the webhook still points at the
+// verified url and is pending, otherwise it returns mongo.ErrNoDocuments.
+func (r *webhookRepository) UpdateVerification(ctx context.Context, id primitive.ObjectID, url string, verification models.WebhookVerification) (*models.Webhook, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":                 id,
+		"url":                 url,
+		"verification.status": constants.WebhookVerificationPending.String(),
+	}
+	update := bson.D{
+		{
+			Key: "$set",
+			Value: bson.M{
+				"verification": verification,
+				"updatedAt":    time.Now().UTC(),
+			},
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var webhook models.Webhook
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&webhook)
+
+	if err != nil {
+		slog.Error("Failed to update webhook verification", "error", err, "id", id)
+		return nil, err
+	}
+
+	slog.Debug("Updated webhook verification", "id", id, "status", verification.Status)
+	return &webhook, nil
+
+}
+
+func (r *webhookRepository) MakeUserIdIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "userId", Value: 1}},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating userId index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created userId index", "indexName", indexName)
+
+}
+
+func (r *webhookRepository) MakeVerificationIndex() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	indexName, err := r.collection.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "verification.status", Value: 1},
+				{Key: "verification.nextAttemptAt", Value: 1},
+			},
+		},
+	)
+
+	if err != nil {
+		slog.Error("Error creating verification index", "indexName", indexName)
+		panic(err)
+	}
+
+	slog.Debug("Created verification index", "indexName", indexName)
+
+}
@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/middlewares"
+)
+
+var (
+	self    = []constants.Role{constants.RoleUser}
+	support = []constants.Role{constants.RoleSupport}
+	admin   = []constants.Role{constants.RoleAdmin}
+)
+
+// GetAccessPolicy lists every route behind the Firebase authorization with the roles that may call it.
+// Self-service routes act on the caller's own account and are open to every user,
+// support staff can look up other users while only admins can change them.
+func GetAccessPolicy() middlewares.AccessPolicy {
+	return middlewares.AccessPolicy{
+		"GET /api/user/test": self,
+
+		"PUT /api/user/":    self,
+		"GET /api/user/":    self,
+		"DELETE /api/user/": self,
+
+		"GET /api/user/erasure":       self,
+		"POST /api/user/erasure/undo": self,
+
+		"POST /api/user/export":    self,
+		"GET /api/user/export/:id": self,
+
+		"GET /api/user/audit": self,
+
+		"PUT /api/user/whatsapp":         self,
+		"POST /api/user/whatsapp/verify": self,
+		"GET /api/user/whatsapp":         self,
+
+		"GET /api/user/discord":      self,
+		"GET /api/user/discord/link": self,
+		"DELETE /api/user/discord":   self,
+
+		"PUT /api/user/telegram":         self,
+		"POST /api/user/telegram/verify": self,
+		"GET /api/user/telegram":         self,
+		"POST /api/user/telegram/bind":   self,
+
+		"PUT /api/user/notificationInterfaces":  self,
+		"GET /api/user/notificationInterfaces":  self,
+		"PUT /api/user/notificationPreferences": self,
+		"GET /api/user/notificationPreferences": self,
+
+		"PUT /api/user/fcmTokens":    self,
+		"DELETE /api/user/fcmTokens": self,
+		"GET /api/user/fcmTokens":    self,
+
+		"POST /api/user/webhooks":                   self,
+		"GET /api/user/webhooks":                    self,
+		"GET /api/user/webhooks/:id":                self,
+		"PATCH /api/user/webhooks/:id":              self,
+		"DELETE /api/user/webhooks/:id":             self,
+		"POST /api/user/webhooks/:id/secret/rotate": self,
+		"POST /api/user/webhooks/:id/verify":        self,
+		"POST /api/user/webhooks/:id/test":          self,
+		"GET /api/user/webhooks/:id/deliveries":     self,
+
+		"GET /api/admin/users":                                    support,
+		"GET /api/admin/users/:userId":                            support,
+		"PATCH /api/admin/users/:userId":                          admin,
+		"POST /api/admin/users/:userId/channels/:channel/disable": admin,
+		"DELETE /api/admin/users/:userId":                         admin,
+		"GET /api/admin/users/:userId/erasure":                    support,
+
+		"GET /api/admin/audit": support,
+
+		"GET /api/admin/dead-letters":             support,
+		"GET /api/admin/dead-letters/:id":         support,
+		"POST /api/admin/dead-letters/:id/replay": admin,
+	}
+}
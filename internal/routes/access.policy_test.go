@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// TestAccessPolicyCoversRoutes registers every route group the way app does and checks that each route behind
+// the role authorization has a policy entry, and that the policy lists no route that is not registered
+func TestAccessPolicyCoversRoutes(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	guarded := map[string]struct{}{}
+	pass := func(c *gin.Context) {}
+	roleAuthorization := func(c *gin.Context) {
+		guarded[c.Request.Method+" "+c.FullPath()] = struct{}{}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+
+	// routes outside the role authorization reach their controllers, which have no services here
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard))
+	RegisterUserRoutes(router.Group("/api/user"), pass, roleAuthorization, controllers.NewUserController(nil))
+	RegisterWebhookRoutes(router.Group("/api/user/webhooks"), pass, roleAuthorization, controllers.NewWebhookController(nil))
+	RegisterDiscordRoutes(router.Group("/api/user/discord"), router.Group("/api/discord"), pass, roleAuthorization, controllers.NewDiscordController(nil))
+	RegisterTelegramRoutes(router.Group("/api/user/telegram"), router.Group("/internal/telegram"), pass, roleAuthorization, pass, controllers.NewTelegramController(nil))
+	RegisterAdminRoutes(router.Group("/api/admin/users"), pass, roleAuthorization, controllers.NewAdminController(nil))
+	RegisterErasureRoutes(router.Group("/api/user"), router.Group("/api/admin/users"), pass, roleAuthorization, controllers.NewErasureController(nil))
+	RegisterAuditRoutes(router.Group("/api/user/audit"), router.Group("/api/admin/audit"), pass, roleAuthorization, controllers.NewAuditController(nil))
+	RegisterExportRoutes(router.Group("/api/user/export"), router.Group("/api/export"), pass, roleAuthorization, controllers.NewExportController(nil))
+	RegisterInternalRoutes(router.Group("/internal"), pass, controllers.NewInternalController(nil))
+	RegisterDeadLetterRoutes(router.Group("/api/admin/dead-letters"), pass, roleAuthorization, controllers.NewDeadLetterController(nil))
+	RegisterHealthRoutes(router.Group(""), controllers.NewHealthController(nil))
+
+	for _, route := range router.Routes() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(route.Method, route.Path, nil))
+	}
+
+	policy := GetAccessPolicy()
+	for route := range guarded {
+		if _, ok := policy[route]; !ok {
+			t.Errorf("%s has no access policy entry", route)
+		}
+	}
+	for route := range policy {
+		if _, ok := guarded[route]; !ok {
+			t.Errorf("access policy lists %s, which is not registered behind the role authorization", route)
+		}
+	}
+
+}
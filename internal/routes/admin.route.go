@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminRoutes(router *gin.RouterGroup, authorization gin.HandlerFunc, roleAuthorization gin.HandlerFunc, controller controllers.AdminController) {
+
+	router.Use(authorization, roleAuthorization)
+
+	router.GET("", controller.SearchUsers)
+	router.GET("/:userId", controller.GetUser)
+	router.PATCH("/:userId", controller.EditUser)
+	router.POST("/:userId/channels/:channel/disable", controller.DisableChannel)
+	router.DELETE("/:userId", controller.DeleteUser)
+
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAuditRoutes(router *gin.RouterGroup, adminRouter *gin.RouterGroup, authorization gin.HandlerFunc, roleAuthorization gin.HandlerFunc, controller controllers.AuditController) {
+
+	router.Use(authorization, roleAuthorization)
+
+	router.GET("", controller.GetAudit)
+
+	adminRouter.Use(authorization, roleAuthorization)
+
+	adminRouter.GET("", controller.SearchAudit)
+
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterDeadLetterRoutes(router *gin.RouterGroup, authorization gin.HandlerFunc, roleAuthorization gin.HandlerFunc, controller controllers.DeadLetterController) {
+
+	router.Use(authorization, roleAuthorization)
+
+	router.GET("", controller.ListDeadLetters)
+	router.GET("/:id", controller.GetDeadLetter)
+	router.POST("/:id/replay", controller.ReplayDeadLetter)
+
+}
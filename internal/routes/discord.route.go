@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterDiscordRoutes(router *gin.RouterGroup, callbackRouter *gin.RouterGroup, authorization gin.HandlerFunc, roleAuthorization gin.HandlerFunc, controller controllers.DiscordController) {
+
+	callbackRouter.GET("/callback", controller.Callback)
+
+	router.Use(authorization, roleAuthorization)
+
+	router.GET("/link", controller.StartLink)
+	router.DELETE("", controller.Unlink)
+
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterErasureRoutes(router *gin.RouterGroup, adminRouter *gin.RouterGroup, authorization gin.HandlerFunc, roleAuthorization gin.HandlerFunc, controller controllers.ErasureController) {
+
+	router.Use(authorization, roleAuthorization)
+
+	router.DELETE("/", controller.RequestErasure)
+	router.GET("/erasure", controller.GetErasure)
+	router.POST("/erasure/undo", controller.CancelErasure)
+
+	adminRouter.Use(authorization, roleAuthorization)
+
+	adminRouter.GET("/:userId/erasure", controller.GetUserErasure)
+
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterExportRoutes(router *gin.RouterGroup, downloadRouter *gin.RouterGroup, authorization gin.HandlerFunc, roleAuthorization gin.HandlerFunc, controller controllers.ExportController) {
+
+	downloadRouter.GET("/:token", controller.Download)
+
+	router.Use(authorization, roleAuthorization)
+
+	router.POST("", controller.RequestExport)
+	router.GET("/:id", controller.GetExport)
+
+}
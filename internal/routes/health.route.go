@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterHealthRoutes registers the probes without any authorization, they are called by the orchestrator
+func RegisterHealthRoutes(router *gin.RouterGroup, controller controllers.HealthController) {
+
+	router.GET("/healthz", controller.Live)
+	router.GET("/readyz", controller.Ready)
+
+}
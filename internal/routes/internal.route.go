@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterInternalRoutes(router *gin.RouterGroup, authorization gin.HandlerFunc, controller controllers.InternalController) {
+
+	router.Use(authorization)
+
+	router.GET("/users/:userId/deliveryTargets", controller.GetDeliveryTargets)
+
+}
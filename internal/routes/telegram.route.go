@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTelegramRoutes(router *gin.RouterGroup, internalRouter *gin.RouterGroup, authorization gin.HandlerFunc, roleAuthorization gin.HandlerFunc, serviceAuthorization gin.HandlerFunc, controller controllers.TelegramController) {
+
+	internalRouter.Use(serviceAuthorization)
+	internalRouter.POST("/bind", controller.CompleteBind)
+
+	router.Use(authorization, roleAuthorization)
+	router.POST("/bind", controller.StartBind)
+
+}
@@ -13,33 +13,32 @@ func testController(c *gin.Context) {
 	})
 }
 
-func RegisterUserRoutes(router *gin.RouterGroup, authorization gin.HandlerFunc, controller controllers.UserController) {
+func RegisterUserRoutes(router *gin.RouterGroup, authorization gin.HandlerFunc, roleAuthorization gin.HandlerFunc, controller controllers.UserController) {
 
-	router.Use(authorization)
+	router.Use(authorization, roleAuthorization)
 	router.GET("/test", testController)
 
 	router.PUT("/", controller.UpsertUser)
 	router.GET("/", controller.GetUser)
 
 	router.PUT("/whatsapp", controller.EditWhatsAppNumber)
+	router.POST("/whatsapp/verify", controller.ConfirmWhatsAppNumber)
 	router.GET("/whatsapp", controller.GetWhatsAppNumber)
 
-	router.PUT("/discord", controller.EditDiscordId)
-	router.GET("/discord", controller.GetDiscordId)
+	router.GET("/discord", controller.GetDiscordAccount)
 
 	router.PUT("/telegram", controller.EditTelegramNumber)
+	router.POST("/telegram/verify", controller.ConfirmTelegramNumber)
 	router.GET("/telegram", controller.GetTelegramNumber)
 
 	router.PUT("/notificationInterfaces", controller.EditNotificationInterfaces)
 	router.GET("/notificationInterfaces", controller.GetNotificationInterfaces)
 
+	router.PUT("/notificationPreferences", controller.EditNotificationPreferences)
+	router.GET("/notificationPreferences", controller.GetNotificationPreferences)
+
 	router.PUT("/fcmTokens", controller.AddFCMtoken)
 	router.DELETE("/fcmTokens", controller.DeleteFCMtoken)
 	router.GET("/fcmTokens", controller.GetFCMtokens)
 
-	router.PUT("/webhooks", controller.EditWebhooks)
-	router.GET("/webhooks", controller.GetWebhooks)
-
-	router.DELETE("/", controller.DeleteUser)
-
 }
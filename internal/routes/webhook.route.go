@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWebhookRoutes(router *gin.RouterGroup, authorization gin.HandlerFunc, roleAuthorization gin.HandlerFunc, controller controllers.WebhookController) {
+
+	router.Use(authorization, roleAuthorization)
+
+	router.POST("", controller.CreateWebhook)
+	router.GET("", controller.GetWebhooks)
+	router.GET("/:id", controller.GetWebhook)
+	router.PATCH("/:id", controller.UpdateWebhook)
+	router.DELETE("/:id", controller.DeleteWebhook)
+	router.POST("/:id/secret/rotate", controller.RotateSecret)
+	router.POST("/:id/verify", controller.RequestVerification)
+	router.POST("/:id/test", controller.TestWebhook)
+	router.GET("/:id/deliveries", controller.GetDeliveries)
+
+}
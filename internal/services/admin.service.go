@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/exp/slog"
+)
+
+// AdminService lets operators look up and fix any user's record, every change still goes out as a user event
+type AdminService interface {
+	SearchUsers(query *models.UserQuery) (*models.UsersResponse, error)
+	GetUser(userId string) (*models.User, error)
+	EditUser(actor *models.Actor, userId string, request *models.AdminUserRequest) (*models.User, error)
+	DisableChannel(actor *models.Actor, userId string, channel string) (*models.User, error)
+	DeleteUser(actor *models.Actor, userId string) (*models.ErasureJob, error)
+}
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrNothingToUpdate = errors.New("nothing to update")
+	ErrInvalidChannel  = errors.New("invalid notification interface")
+)
+
+type adminService struct {
+	userRepository  repositories.UserRepository
+	erasureService  ErasureService
+	changes         *userChanges
+	defaultPageSize int
+}
+
+func NewAdminService(userRepository repositories.UserRepository, outboxRepository repositories.OutboxRepository, auditRepository repositories.AuditRepository, transactionRepository repositories.TransactionRepository, erasureService ErasureService) AdminService {
+	return &adminService{
+		userRepository:  userRepository,
+		erasureService:  erasureService,
+		changes:         newUserChanges(userRepository, outboxRepository, auditRepository, transactionRepository),
+		defaultPageSize: config.GetEnvInt("ADMIN_USERS_PAGE_SIZE", 20),
+	}
+}
+
+func (s *adminService) SearchUsers(query *models.UserQuery) (*models.UsersResponse, error) {
+
+	page, limit := query.Page, query.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = s.defaultPageSize
+	}
+
+	users, total, err := s.userRepository.Find(query, page, limit)
+	if err != nil {
+		slog.Error("Failed to search users", "error", err)
+		return nil, err
+	}
+
+	return &models.UsersResponse{
+		Users: users,
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	}, nil
+
+}
+
+func (s *adminService) GetUser(userId string) (*models.User, error) {
+
+	user, err := s.userRepository.FindByUserId(userId)
+	if err != nil {
+		slog.Error("Failed to get user", "error", err, "userId", userId)
+		return nil, userError(err)
+	}
+
+	return user, nil
+
+}
+
+func (s *adminService) EditUser(actor *models.Actor, userId string, request *models.AdminUserRequest) (*models.User, error) {
+
+	fields := bson.M{}
+	if request.WhatsAppNumber != nil {
+		fields["whatsAppNumber"] = *request.WhatsAppNumber
+	}
+	if request.TelegramNumber != nil {
+		fields["telegramNumber"] = *request.TelegramNumber
+	}
+	if request.TelegramChatId != nil {
+		fields["telegramChatId"] = *request.TelegramChatId
+	}
+	if request.DiscordId != nil {
+		fields["discordId"] = *request.DiscordId
+	}
+	if request.DiscordUsername != nil {
+		fields["discordUsername"] = *request.DiscordUsername
+	}
+	if request.NotificationInterfaces != nil {
+		fields["notificationInterfaces"] = *request.NotificationInterfaces
+	}
+	if request.NotificationPreferences != nil {
+		fields["notificationPreferences"] = *request.NotificationPreferences
+	}
+
+	if len(fields) == 0 {
+		return nil, ErrNothingToUpdate
+	}
+
+	// the bson and json names of the editable fields are the same, so they double as the changed fields of the event
+	changedFields := make([]string, 0, len(fields))
+	for field := range fields {
+		changedFields = append(changedFields, field)
+	}
+
+	user, err := s.changes.update(actor, userId, func(ctx context.Context) (*models.User, error) {
+		return s.userRepository.UpdateFields(ctx, userId, fields)
+	}, changedFields...)
+	if err != nil {
+		slog.Error("Failed to edit user", "error", err, "userId", userId)
+		return nil, userError(err)
+	}
+
+	slog.Info("Edited user", "userId", userId, "fields", changedFields)
+	return user, nil
+
+}
+
+func (s *adminService) DisableChannel(actor *models.Actor, userId string, channel string) (*models.User, error) {
+
+	if _, ok := constants.GetNotificationInterfaceSet()[constants.NotificationInterface(channel)]; !ok {
+		return nil, ErrInvalidChannel
+	}
+
+	user, err := s.changes.update(actor, userId, func(ctx context.Context) (*models.User, error) {
+		return s.userRepository.RemoveNotificationInterface(ctx, userId, channel)
+	}, "notificationInterfaces")
+	if err != nil {
+		slog.Error("Failed to disable channel", "error", err, "userId", userId, "channel", channel)
+		return nil, userError(err)
+	}
+
+	slog.Info("Disabled channel", "userId", userId, "channel", channel)
+	return user, nil
+
+}
+
+// DeleteUser erases the user right away through the erasure service, so webhooks, exports, the Firebase account
+// and every other service's copy of the user go with it
+func (s *adminService) DeleteUser(actor *models.Actor, userId string) (*models.ErasureJob, error) {
+
+	job, err := s.erasureService.EraseNow(actor, userId)
+	if err != nil {
+		slog.Error("Failed to delete user", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Info("Deleting user", "userId", userId, "erasureId", job.Id)
+	return job, nil
+
+}
+
+func userError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrUserNotFound
+	}
+	return err
+}
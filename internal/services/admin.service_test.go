@@ -0,0 +1,43 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+)
+
+// fakeErasureService records the users erased right away
+type fakeErasureService struct {
+	ErasureService
+	erased []string
+}
+
+func (s *fakeErasureService) EraseNow(actor *models.Actor, userId string) (*models.ErasureJob, error) {
+	if userId != "user-1" {
+		return nil, ErrUserNotFound
+	}
+	s.erased = append(s.erased, userId)
+	return &models.ErasureJob{UserId: userId}, nil
+}
+
+func TestAdminDeleteUserErases(t *testing.T) {
+
+	// the user repository fake has no Delete, a hard delete past the erasure would panic
+	erasureService := &fakeErasureService{}
+	service := &adminService{userRepository: &fakeUserRepository{user: &models.User{UserId: "user-1"}}, erasureService: erasureService}
+
+	job, err := service.DeleteUser(&models.Actor{UserId: "admin-1"}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.UserId != "user-1" || len(erasureService.erased) != 1 {
+		t.Errorf("erased %v, want user-1 once", erasureService.erased)
+	}
+
+	_, err = service.DeleteUser(&models.Actor{UserId: "admin-1"}, "user-2")
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("got error %v, want %v", err, ErrUserNotFound)
+	}
+
+}
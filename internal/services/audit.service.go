@@ -0,0 +1,81 @@
+package services
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"golang.org/x/exp/slog"
+)
+
+// AuditService reads the audit trail, the entries themselves are written by the services making the changes
+type AuditService interface {
+	GetUserAudit(actor *models.Actor, userId string, query *models.AuditQuery) (*models.AuditResponse, error)
+	SearchAudit(query *models.AuditQuery) (*models.AuditResponse, error)
+}
+
+type auditService struct {
+	auditRepository repositories.AuditRepository
+	defaultPageSize int
+}
+
+func NewAuditService(auditRepository repositories.AuditRepository) AuditService {
+	return &auditService{
+		auditRepository: auditRepository,
+		defaultPageSize: config.GetEnvInt("AUDIT_PAGE_SIZE", 20),
+	}
+}
+
+// GetUserAudit returns the changes made to the user's own account, whoever made them.
+// Callers who are not staff only learn the role of whoever made a change, not who they are or where from.
+func (s *auditService) GetUserAudit(actor *models.Actor, userId string, query *models.AuditQuery) (*models.AuditResponse, error) {
+
+	query.UserId = userId
+	response, err := s.SearchAudit(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isStaff(actor) {
+		redactAuditActors(response.Entries)
+	}
+
+	return response, nil
+
+}
+
+func (s *auditService) SearchAudit(query *models.AuditQuery) (*models.AuditResponse, error) {
+
+	page, limit := query.Page, query.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = s.defaultPageSize
+	}
+
+	entries, total, err := s.auditRepository.Find(query, page, limit)
+	if err != nil {
+		slog.Error("Failed to search audit entries", "error", err, "userId", query.UserId)
+		return nil, err
+	}
+
+	return &models.AuditResponse{
+		Entries: entries,
+		Page:    page,
+		Limit:   limit,
+		Total:   total,
+	}, nil
+
+}
+
+func isStaff(actor *models.Actor) bool {
+	return actor.Role == constants.RoleSupport.String() || actor.Role == constants.RoleAdmin.String()
+}
+
+// redactAuditActors keeps only the role of the actor of each entry
+func redactAuditActors(entries []models.AuditEntry) {
+	for i := range entries {
+		entries[i].Actor = models.Actor{Role: entries[i].Actor.Role}
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+)
+
+type fakeAuditSearchRepository struct {
+	repositories.AuditRepository
+}
+
+func (r *fakeAuditSearchRepository) Find(query *models.AuditQuery, page int, limit int) ([]models.AuditEntry, int64, error) {
+	actor := models.Actor{UserId: "support-1", Role: constants.RoleSupport.String(), RequestId: "request-1", IP: "203.0.113.7"}
+	return []models.AuditEntry{{UserId: query.UserId, Actor: actor, Field: "name"}}, 1, nil
+}
+
+func TestGetUserAuditRedactsActor(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		role     constants.Role
+		redacted bool
+	}{
+		{"user", constants.RoleUser, true},
+		{"support", constants.RoleSupport, false},
+		{"admin", constants.RoleAdmin, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := &auditService{auditRepository: &fakeAuditSearchRepository{}, defaultPageSize: 20}
+
+			response, err := service.GetUserAudit(&models.Actor{UserId: "user-1", Role: test.role.String()}, "user-1", &models.AuditQuery{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actor := response.Entries[0].Actor
+			if actor.Role != constants.RoleSupport.String() {
+				t.Errorf("got role %q, want %q", actor.Role, constants.RoleSupport)
+			}
+			if redacted := actor.UserId == "" && actor.RequestId == "" && actor.IP == ""; redacted != test.redacted {
+				t.Errorf("got actor %+v, redacted %v, want redacted %v", actor, redacted, test.redacted)
+			}
+		})
+	}
+
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/producers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	ErrDeadLetterNotFound        = errors.New("dead letter not found")
+	ErrDeadLetterAlreadyReplayed = errors.New("dead letter was replayed already")
+)
+
+// DeadLetterService keeps the messages collected from the dead-letter queues so that operators can inspect them
+// and replay them once whatever made them fail is fixed. The bodies and headers it hands out are redacted, see redactDeadLetter.
+type DeadLetterService interface {
+	StoreDeadLetter(deadLetter *models.DeadLetter) error
+	ListDeadLetters(query *models.DeadLetterQuery) (*models.DeadLetterResponse, error)
+	GetDeadLetter(id string) (*models.DeadLetter, error)
+	ReplayDeadLetter(actor *models.Actor, id string) (*models.DeadLetter, error)
+}
+
+type deadLetterService struct {
+	deadLetterRepository repositories.DeadLetterRepository
+	producer             producers.DeadLetterProducer
+	defaultPageSize      int
+}
+
+func NewDeadLetterService(deadLetterRepository repositories.DeadLetterRepository, producer producers.DeadLetterProducer) DeadLetterService {
+	return &deadLetterService{
+		deadLetterRepository: deadLetterRepository,
+		producer:             producer,
+		defaultPageSize:      config.GetEnvInt("DEAD_LETTER_PAGE_SIZE", 20),
+	}
+}
+
+func (s *deadLetterService) StoreDeadLetter(deadLetter *models.DeadLetter) error {
+
+	err := s.deadLetterRepository.Insert(deadLetter)
+	if err != nil {
+		return err
+	}
+
+	slog.Warn("Collected dead letter", "id", deadLetter.Id, "queue", deadLetter.Queue, "reason", deadLetter.Reason, "routingKey", deadLetter.RoutingKey, "messageId", deadLetter.MessageId)
+	return nil
+
+}
+
+func (s *deadLetterService) ListDeadLetters(query *models.DeadLetterQuery) (*models.DeadLetterResponse, error) {
+
+	page, limit := query.Page, query.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = s.defaultPageSize
+	}
+
+	deadLetters, total, err := s.deadLetterRepository.Find(query, page, limit)
+	if err != nil {
+		slog.Error("Failed to list dead letters", "error", err)
+		return nil, err
+	}
+
+	for i := range deadLetters {
+		redactDeadLetter(&deadLetters[i])
+	}
+
+	return &models.DeadLetterResponse{
+		DeadLetters: deadLetters,
+		Page:        page,
+		Limit:       limit,
+		Total:       total,
+	}, nil
+
+}
+
+func (s *deadLetterService) GetDeadLetter(id string) (*models.DeadLetter, error) {
+
+	deadLetter, err := s.getDeadLetter(id)
+	if err != nil {
+		return nil, err
+	}
+
+	redactDeadLetter(deadLetter)
+	return deadLetter, nil
+
+}
+
+func (s *deadLetterService) getDeadLetter(id string) (*models.DeadLetter, error) {
+
+	deadLetterId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrDeadLetterNotFound
+	}
+
+	deadLetter, err := s.deadLetterRepository.FindById(deadLetterId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrDeadLetterNotFound
+	}
+
+	return deadLetter, err
+
+}
+
+// ReplayDeadLetter publishes the dead letter back to its original routing key and marks it as replayed.
+// Two operators replaying it at once may both publish it, consumers drop the second copy by its message id.
+func (s *deadLetterService) ReplayDeadLetter(actor *models.Actor, id string) (*models.DeadLetter, error) {
+
+	deadLetter, err := s.getDeadLetter(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadLetter.Status == constants.DeadLetterReplayed.String() {
+		return nil, ErrDeadLetterAlreadyReplayed
+	}
+
+	err = s.producer.Replay(context.Background(), deadLetter)
+	if err != nil {
+		slog.Error("Failed to replay dead letter", "error", err, "id", deadLetter.Id, "exchange", deadLetter.Exchange, "routingKey", deadLetter.RoutingKey)
+		return nil, err
+	}
+
+	replayed, err := s.deadLetterRepository.MarkReplayed(deadLetter.Id, actor)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrDeadLetterAlreadyReplayed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Replayed dead letter", "id", deadLetter.Id, "exchange", deadLetter.Exchange, "routingKey", deadLetter.RoutingKey, "actor", actor.UserId)
+	redactDeadLetter(replayed)
+	return replayed, nil
+
+}
+
+// deadLetterFields are the body fields whose values are shown as they are, they say which message it was without saying anything about the user
+var deadLetterFields = map[string]bool{
+	"eventId":    true,
+	"eventType":  true,
+	"version":    true,
+	"occurredAt": true,
+	"userId":     true,
+	"erasureId":  true,
+	"service":    true,
+	"status":     true,
+	"channel":    true,
+	"expiresAt":  true,
+}
+
+// deadLetterHeaders are the headers whose values are shown as they are, the ones the broker and the retries set
+var deadLetterHeaders = map[string]bool{
+	"x-death":                          true,
+	"x-first-death-exchange":           true,
+	"x-first-death-queue":              true,
+	"x-first-death-reason":             true,
+	constants.AttemptsHeader:           true,
+	constants.MaxAttemptsHeader:        true,
+	constants.OriginalExchangeHeader:   true,
+	constants.OriginalRoutingKeyHeader: true,
+	constants.UserIdHeader:             true,
+}
+
+// redactDeadLetter keeps the shape of a JSON body but replaces the values of any other field, bodies carry verification codes,
+// numbers, tokens and changed contact details. A body that is not JSON is left out altogether.
+// The headers are redacted the same way, the publisher may have put anything in them.
+func redactDeadLetter(deadLetter *models.DeadLetter) {
+
+	if deadLetter.Headers != nil {
+		headers := make(map[string]interface{}, len(deadLetter.Headers))
+		for key, value := range deadLetter.Headers {
+			if deadLetterHeaders[key] {
+				headers[key] = value
+			} else {
+				headers[key] = "[redacted]"
+			}
+		}
+		deadLetter.Headers = headers
+	}
+
+	var body interface{}
+	err := json.Unmarshal(deadLetter.Body, &body)
+	if err != nil {
+		deadLetter.Body = nil
+		return
+	}
+
+	deadLetter.Body, err = json.Marshal(redactValue("", body))
+	if err != nil {
+		deadLetter.Body = nil
+	}
+
+}
+
+func redactValue(key string, value interface{}) interface{} {
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range value {
+			value[field] = redactValue(field, fieldValue)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactValue(key, item)
+		}
+		return value
+	case nil:
+		return nil
+	default:
+		if deadLetterFields[key] {
+			return value
+		}
+		return "[redacted]"
+	}
+
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/producers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type fakeDeadLetterRepository struct {
+	repositories.DeadLetterRepository
+	deadLetters []models.DeadLetter
+}
+
+func (r *fakeDeadLetterRepository) Find(query *models.DeadLetterQuery, page int, limit int) ([]models.DeadLetter, int64, error) {
+	deadLetters := append([]models.DeadLetter{}, r.deadLetters...)
+	return deadLetters, int64(len(deadLetters)), nil
+}
+
+func (r *fakeDeadLetterRepository) FindById(id primitive.ObjectID) (*models.DeadLetter, error) {
+	for _, deadLetter := range r.deadLetters {
+		if deadLetter.Id == id {
+			return &deadLetter, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *fakeDeadLetterRepository) MarkReplayed(id primitive.ObjectID, actor *models.Actor) (*models.DeadLetter, error) {
+	deadLetter, err := r.FindById(id)
+	if err != nil {
+		return nil, err
+	}
+	deadLetter.Status = constants.DeadLetterReplayed.String()
+	return deadLetter, nil
+}
+
+func (r *fakeDeadLetterRepository) DeleteByUserId(ctx context.Context, userId string) (int64, error) {
+	kept := []models.DeadLetter{}
+	for _, deadLetter := range r.deadLetters {
+		if deadLetter.UserId != userId {
+			kept = append(kept, deadLetter)
+		}
+	}
+	deleted := int64(len(r.deadLetters) - len(kept))
+	r.deadLetters = kept
+	return deleted, nil
+}
+
+type fakeDeadLetterProducer struct {
+	replayed []*models.DeadLetter
+}
+
+func (p *fakeDeadLetterProducer) Replay(ctx context.Context, deadLetter *models.DeadLetter) error {
+	p.replayed = append(p.replayed, deadLetter)
+	return nil
+}
+
+func TestDeadLetterBodiesAreRedacted(t *testing.T) {
+
+	verification, err := producers.NewVerificationCodeMessage("user-1", constants.WhatsApp.String(), "+911234567890", "123456", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := producers.NewUserEventMessage(constants.UserUpdated, "user-1", map[string]interface{}{"email": "nelly@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	welcome := producers.NewWelcomeMessage("user-1")
+
+	repository := &fakeDeadLetterRepository{}
+	for _, message := range []*models.OutboxMessage{verification, event, welcome} {
+		repository.deadLetters = append(repository.deadLetters, models.DeadLetter{
+			Id:          primitive.NewObjectID(),
+			UserId:      "user-1",
+			RoutingKey:  message.RoutingKey,
+			ContentType: message.ContentType,
+			Body:        message.Body,
+			Headers:     map[string]interface{}{constants.UserIdHeader: "user-1", constants.AttemptsHeader: int64(3), "authorization": "Bearer secret"},
+			Status:      constants.DeadLetterPending.String(),
+		})
+	}
+	producer := &fakeDeadLetterProducer{}
+	service := NewDeadLetterService(repository, producer)
+
+	redacted := func(body []byte) map[string]interface{} {
+		t.Helper()
+		var fields map[string]interface{}
+		err := json.Unmarshal(body, &fields)
+		if err != nil {
+			t.Fatalf("redacted body %q is not JSON: %v", body, err)
+		}
+		return fields
+	}
+
+	inspected, err := service.GetDeadLetter(repository.deadLetters[0].Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := redacted(inspected.Body)
+	if fields["code"] != "[redacted]" || fields["number"] != "[redacted]" {
+		t.Errorf("verification code or number shown: %v", fields)
+	}
+	if fields["userId"] != "user-1" || fields["channel"] != constants.WhatsApp.String() {
+		t.Errorf("user or channel redacted: %v", fields)
+	}
+	if inspected.Headers["authorization"] != "[redacted]" {
+		t.Errorf("header not on the allow-list shown: %v", inspected.Headers)
+	}
+	if inspected.Headers[constants.UserIdHeader] != "user-1" || inspected.Headers[constants.AttemptsHeader] != int64(3) {
+		t.Errorf("user or attempts header redacted: %v", inspected.Headers)
+	}
+
+	listed, err := service.ListDeadLetters(&models.DeadLetterQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changedFields, _ := redacted(listed.DeadLetters[1].Body)["changedFields"].(map[string]interface{})
+	if changedFields["email"] != "[redacted]" {
+		t.Errorf("changed email shown: %v", changedFields)
+	}
+	if listed.DeadLetters[2].Body != nil {
+		t.Errorf("body %q that is not JSON shown", listed.DeadLetters[2].Body)
+	}
+
+	// the replay publishes the message as it was collected, only the response is redacted
+	replayed, err := service.ReplayDeadLetter(&models.Actor{UserId: "admin-1"}, repository.deadLetters[0].Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(producer.replayed[0].Body) != string(verification.Body) {
+		t.Errorf("replayed body %s, want %s", producer.replayed[0].Body, verification.Body)
+	}
+	if producer.replayed[0].Headers["authorization"] != "Bearer secret" {
+		t.Errorf("replayed headers %v, want them as collected", producer.replayed[0].Headers)
+	}
+	if redacted(replayed.Body)["code"] != "[redacted]" || replayed.Headers["authorization"] != "[redacted]" {
+		t.Error("verification code or header shown after the replay")
+	}
+
+}
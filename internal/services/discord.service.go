@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/exp/slog"
+)
+
+type DiscordService interface {
+	StartLink(userId string) (string, error)
+	CompleteLink(actor *models.Actor, state string, code string) (string, error)
+	Unlink(actor *models.Actor, userId string) error
+}
+
+// ErrInvalidLinkState is returned for a state that was never issued, is used up or has expired
+var ErrInvalidLinkState = errors.New("invalid or expired link state")
+
+type discordService struct {
+	userRepository        repositories.UserRepository
+	linkStateRepository   repositories.LinkStateRepository
+	outboxRepository      repositories.OutboxRepository
+	transactionRepository repositories.TransactionRepository
+	changes               *userChanges
+	discordClient         config.DiscordOAuthClient
+	linkStateTTL          time.Duration
+}
+
+func NewDiscordService(userRepository repositories.UserRepository, linkStateRepository repositories.LinkStateRepository, outboxRepository repositories.OutboxRepository, auditRepository repositories.AuditRepository, transactionRepository repositories.TransactionRepository, discordClient config.DiscordOAuthClient) DiscordService {
+	return &discordService{
+		userRepository:        userRepository,
+		linkStateRepository:   linkStateRepository,
+		outboxRepository:      outboxRepository,
+		transactionRepository: transactionRepository,
+		changes:               newUserChanges(userRepository, outboxRepository, auditRepository, transactionRepository),
+		discordClient:         discordClient,
+		linkStateTTL:          config.GetEnvDuration("DISCORD_LINK_STATE_TTL", 10*time.Minute),
+	}
+}
+
+// StartLink stores a state bound to the user and returns the Discord authorization URL carrying it
+func (s *discordService) StartLink(userId string) (string, error) {
+
+	state, err := utils.GenerateToken(32)
+	if err != nil {
+		slog.Error("Failed to generate Discord link state", "error", err, "userId", userId)
+		return "", err
+	}
+
+	err = s.linkStateRepository.Insert(&models.LinkState{
+		State:     state,
+		UserId:    userId,
+		Provider:  constants.Discord.String(),
+		ExpiresAt: time.Now().UTC().Add(s.linkStateTTL),
+	})
+	if err != nil {
+		slog.Error("Failed to start Discord link", "error", err, "userId", userId)
+		return "", err
+	}
+
+	slog.Debug("Started Discord link", "userId", userId)
+	return s.discordClient.AuthCodeURL(state), nil
+
+}
+
+// CompleteLink resolves the user the state was issued to, exchanges the code and stores the verified Discord account.
+// It returns the id of the linked user, ErrInvalidLinkState for a bad state and config.ErrDiscordUnavailable when Discord fails.
+// The callback carries no Firebase token, the change is audited as made by the user the state was issued to.
+func (s *discordService) CompleteLink(actor *models.Actor, state string, code string) (string, error) {
+
+	linkState, err := s.linkStateRepository.Consume(state, constants.Discord.String())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		slog.Error("Failed to complete Discord link, invalid state", "error", err)
+		return "", ErrInvalidLinkState
+	}
+	if err != nil {
+		slog.Error("Failed to complete Discord link", "error", err)
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	discordId, discordUsername, err := s.discordClient.Exchange(ctx, code)
+	if err != nil {
+		slog.Error("Failed to complete Discord link", "error", err, "userId", linkState.UserId)
+		return "", err
+	}
+
+	linkActor := *actor
+	linkActor.UserId = linkState.UserId
+	linkActor.Role = constants.RoleUser.String()
+
+	_, err = s.changes.update(&linkActor, linkState.UserId, func(ctx context.Context) (*models.User, error) {
+		return s.userRepository.UpdateDiscordAccount(ctx, linkState.UserId, discordId, discordUsername)
+	}, "discordId", "discordUsername", "notificationInterfaces")
+	if err != nil {
+		slog.Error("Failed to complete Discord link", "error", err, "userId", linkState.UserId, "discordId", discordId)
+		return "", err
+	}
+
+	slog.Debug("Completed Discord link", "userId", linkState.UserId, "discordId", discordId)
+	return linkState.UserId, nil
+
+}
+
+func (s *discordService) Unlink(actor *models.Actor, userId string) error {
+
+	_, err := s.changes.update(actor, userId, func(ctx context.Context) (*models.User, error) {
+		return s.userRepository.RemoveDiscordAccount(ctx, userId)
+	}, "discordId", "discordUsername", "notificationInterfaces")
+	if err != nil {
+		slog.Error("Failed to unlink Discord", "error", err, "userId", userId)
+		return err
+	}
+
+	slog.Debug("Unlinked Discord", "userId", userId)
+	return nil
+
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeLinkStateRepository hands out a state once and only until it expires, as the Mongo filter does
+type fakeLinkStateRepository struct {
+	repositories.LinkStateRepository
+	states map[string]*models.LinkState
+}
+
+func (r *fakeLinkStateRepository) Consume(state string, provider string) (*models.LinkState, error) {
+	linkState, ok := r.states[state]
+	if !ok || linkState.Provider != provider || !linkState.ExpiresAt.After(time.Now()) {
+		return nil, mongo.ErrNoDocuments
+	}
+	delete(r.states, state)
+	return linkState, nil
+}
+
+type fakeDiscordUserRepository struct {
+	fakeUserRepository
+}
+
+func (r *fakeDiscordUserRepository) UpdateDiscordAccount(ctx context.Context, userId string, discordId string, discordUsername string) (*models.User, error) {
+	r.user.DiscordId = discordId
+	r.user.DiscordUsername = discordUsername
+	user := *r.user
+	return &user, nil
+}
+
+// newDiscordStandIn answers the token exchange for the code "valid" only and then describes the account behind the token
+func newDiscordStandIn(t *testing.T) *httptest.Server {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("code") != "valid" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "discord-token", "token_type": "Bearer", "expires_in": 3600})
+	})
+	mux.HandleFunc("/users/@me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer discord-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"id": "80351110224678912", "username": "nelly"})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+
+}
+
+func TestCompleteDiscordLink(t *testing.T) {
+
+	server := newDiscordStandIn(t)
+	t.Setenv("DISCORD_API_BASE_URL", server.URL)
+
+	tests := []struct {
+		name      string
+		state     string
+		code      string
+		err       error
+		discordId string
+	}{
+		{"linked", "issued", "valid", nil, "80351110224678912"},
+		{"unknown state", "forged", "valid", ErrInvalidLinkState, ""},
+		{"expired state", "expired", "valid", ErrInvalidLinkState, ""},
+		{"token exchange refused", "issued", "revoked", config.ErrDiscordUnavailable, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			linkStateRepository := &fakeLinkStateRepository{states: map[string]*models.LinkState{
+				"issued":  {State: "issued", UserId: "user-1", Provider: constants.Discord.String(), ExpiresAt: time.Now().Add(time.Minute)},
+				"expired": {State: "expired", UserId: "user-1", Provider: constants.Discord.String(), ExpiresAt: time.Now().Add(-time.Minute)},
+			}}
+			userRepository := &fakeDiscordUserRepository{fakeUserRepository{user: &models.User{UserId: "user-1"}}}
+			outboxRepository := &fakeOutboxRepository{}
+			service := NewDiscordService(userRepository, linkStateRepository, outboxRepository, &fakeAuditRepository{}, fakeTransactionRepository{}, config.NewDiscordOAuthClient())
+
+			userId, err := service.CompleteLink(&models.Actor{}, test.state, test.code)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if test.err != nil {
+				if userRepository.user.DiscordId != "" || len(outboxRepository.messages) != 0 {
+					t.Error("failed link changed the user")
+				}
+				return
+			}
+			if userId != "user-1" || userRepository.user.DiscordId != test.discordId {
+				t.Errorf("linked %q to %q, want %q to user-1", userRepository.user.DiscordId, userId, test.discordId)
+			}
+			if len(outboxRepository.messages) != 1 {
+				t.Errorf("queued %d events, want 1", len(outboxRepository.messages))
+			}
+		})
+	}
+
+}
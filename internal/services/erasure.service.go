@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/exp/slog"
+)
+
+// ErasureService deletes users in stages: a soft delete the user can undo during the grace period,
+// then the hard delete here along with a user.erasure_requested event, then the acknowledgements of every
+// service that erased its own copy of the user's data
+type ErasureService interface {
+	RequestErasure(actor *models.Actor, userId string) (*models.ErasureJob, error)
+	EraseNow(actor *models.Actor, userId string) (*models.ErasureJob, error)
+	CancelErasure(actor *models.Actor, userId string) error
+	GetErasure(userId string) (*models.ErasureJob, error)
+	EraseNext() (bool, error)
+	RecordAcknowledgement(message *models.ErasureAcknowledgementMessage) error
+}
+
+var (
+	ErrErasureNotFound         = errors.New("erasure not found")
+	ErrErasureAlreadyRequested = errors.New("erasure already requested")
+	ErrErasureNotCancellable   = errors.New("erasure is no longer scheduled and cannot be undone")
+	ErrInvalidAcknowledgement  = errors.New("invalid erasure acknowledgement")
+)
+
+type erasureService struct {
+	userRepository            repositories.UserRepository
+	webhookRepository         repositories.WebhookRepository
+	webhookDeliveryRepository repositories.WebhookDeliveryRepository
+	exportRepository          repositories.ExportRepository
+	erasureRepository         repositories.ErasureRepository
+	outboxRepository          repositories.OutboxRepository
+	auditRepository           repositories.AuditRepository
+	deadLetterRepository      repositories.DeadLetterRepository
+	transactionRepository     repositories.TransactionRepository
+	changes                   *userChanges
+	firebaseClient            config.FirebaseClient
+	gracePeriod               time.Duration
+	lease                     time.Duration
+	services                  []string
+}
+
+func NewErasureService(userRepository repositories.UserRepository, webhookRepository repositories.WebhookRepository, webhookDeliveryRepository repositories.WebhookDeliveryRepository, exportRepository repositories.ExportRepository, erasureRepository repositories.ErasureRepository, outboxRepository repositories.OutboxRepository, auditRepository repositories.AuditRepository, deadLetterRepository repositories.DeadLetterRepository, transactionRepository repositories.TransactionRepository, firebaseClient config.FirebaseClient) ErasureService {
+	return &erasureService{
+		userRepository:            userRepository,
+		webhookRepository:         webhookRepository,
+		webhookDeliveryRepository: webhookDeliveryRepository,
+		exportRepository:          exportRepository,
+		erasureRepository:         erasureRepository,
+		outboxRepository:          outboxRepository,
+		auditRepository:           auditRepository,
+		deadLetterRepository:      deadLetterRepository,
+		transactionRepository:     transactionRepository,
+		changes:                   newUserChanges(userRepository, outboxRepository, auditRepository, transactionRepository),
+		firebaseClient:            firebaseClient,
+		gracePeriod:               config.GetEnvDuration("ERASURE_GRACE_PERIOD", 7*24*time.Hour),
+		lease:                     config.GetEnvDuration("ERASURE_LEASE", 5*time.Minute),
+		services:                  strings.Split(config.GetEnv("ERASURE_SERVICES", "video-processing,notification"), ","),
+	}
+}
+
+func (s *erasureService) RequestErasure(actor *models.Actor, userId string) (*models.ErasureJob, error) {
+	return s.requestErasure(actor, userId, s.gracePeriod)
+}
+
+// EraseNow skips the grace period for operators deleting a user, the erasure job then runs the same cascade as for
+// a user deleting themselves. A user who already asked for erasure has theirs brought forward.
+func (s *erasureService) EraseNow(actor *models.Actor, userId string) (*models.ErasureJob, error) {
+
+	job, err := s.requestErasure(actor, userId, 0)
+	if !errors.Is(err, ErrErasureAlreadyRequested) {
+		return job, err
+	}
+
+	job, err = s.erasureRepository.Expedite(userId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// the erasure is already running or done
+		return s.GetErasure(userId)
+	}
+
+	if err != nil {
+		slog.Error("Failed to expedite erasure", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Info("Expedited erasure", "userId", userId, "id", job.Id, "actorUserId", actor.UserId)
+	return job, nil
+
+}
+
+func (s *erasureService) requestErasure(actor *models.Actor, userId string, gracePeriod time.Duration) (*models.ErasureJob, error) {
+
+	now := time.Now().UTC()
+	job := &models.ErasureJob{
+		UserId:       userId,
+		RequestedAt:  now,
+		ScheduledFor: now.Add(gracePeriod),
+	}
+
+	_, err := s.changes.update(actor, userId, func(ctx context.Context) (*models.User, error) {
+		user, err := s.userRepository.MarkDeleted(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.erasureRepository.Insert(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+
+		return user, nil
+	}, "deletedAt")
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// the user is either already deleted or does not exist at all
+		_, findErr := s.userRepository.FindByUserId(userId)
+		if findErr == nil {
+			return nil, ErrErasureAlreadyRequested
+		}
+		return nil, userError(findErr)
+	}
+
+	if err != nil {
+		slog.Error("Failed to request erasure", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Info("Requested erasure", "userId", userId, "id", job.Id, "scheduledFor", job.ScheduledFor)
+	return job, nil
+
+}
+
+func (s *erasureService) CancelErasure(actor *models.Actor, userId string) error {
+
+	_, err := s.changes.update(actor, userId, func(ctx context.Context) (*models.User, error) {
+		_, err := s.erasureRepository.Cancel(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.userRepository.Restore(ctx, userId)
+	}, "deletedAt")
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrErasureNotCancellable
+	}
+
+	if err != nil {
+		slog.Error("Failed to cancel erasure", "error", err, "userId", userId)
+		return err
+	}
+
+	slog.Info("Cancelled erasure", "userId", userId)
+	return nil
+
+}
+
+func (s *erasureService) GetErasure(userId string) (*models.ErasureJob, error) {
+
+	job, err := s.erasureRepository.FindLatestByUserId(userId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrErasureNotFound
+	}
+
+	if err != nil {
+		slog.Error("Failed to get erasure", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	return job, nil
+
+}
+
+// EraseNext hard deletes the next user whose grace period is over and reports whether there was one.
+// The user, its webhooks, its pending outbox messages, its dead letters and the events telling the other services it is gone
+// and asking them to follow are written in one transaction, the delivery log, exports and the Firebase account are deleted first
+// and a failure there leaves the job to be retried.
+func (s *erasureService) EraseNext() (bool, error) {
+
+	job, err := s.erasureRepository.ClaimNext(s.lease)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	_, err = s.webhookDeliveryRepository.DeleteByUserId(job.UserId)
+	if err != nil {
+		slog.Error("Failed to erase webhook deliveries", "error", err, "userId", job.UserId, "id", job.Id)
+		return true, err
+	}
+
+	_, err = s.exportRepository.DeleteByUserId(job.UserId)
+	if err != nil {
+		slog.Error("Failed to erase exports", "error", err, "userId", job.UserId, "id", job.Id)
+		return true, err
+	}
+
+	firebaseAcknowledgement := &models.ErasureAcknowledgement{
+		Status:         constants.ErasureAcknowledgementErased.String(),
+		AcknowledgedAt: time.Now().UTC(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = s.firebaseClient.DeleteUser(ctx, job.UserId)
+	if err != nil {
+		slog.Error("Failed to erase Firebase account", "error", err, "userId", job.UserId, "id", job.Id)
+		return true, err
+	}
+
+	acknowledgements := map[string]*models.ErasureAcknowledgement{
+		constants.FirebaseErasureService: firebaseAcknowledgement,
+	}
+	for _, service := range s.services {
+		acknowledgements[service] = &models.ErasureAcknowledgement{Status: constants.ErasureAcknowledgementPending.String()}
+	}
+
+	err = s.transactionRepository.WithTransaction(func(ctx context.Context) error {
+		err := s.userRepository.Delete(ctx, job.UserId)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		_, err = s.webhookRepository.DeleteByUserId(ctx, job.UserId)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.auditRepository.DeleteByUserId(ctx, job.UserId)
+		if err != nil {
+			return err
+		}
+
+		// messages still waiting for the relay would otherwise publish the user's contact details after the erasure
+		_, err = s.outboxRepository.DeleteByUserId(ctx, job.UserId)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.deadLetterRepository.DeleteByUserId(ctx, job.UserId)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.erasureRepository.MarkErased(ctx, job.Id, acknowledgements)
+		if err != nil {
+			return err
+		}
+
+		// consumers of the lifecycle events learn that the user is gone, the erasure event asks them to erase their own data
+		err = insertUserEvent(ctx, s.outboxRepository, job.Id.Hex(), constants.UserDeleted, job.UserId, nil)
+		if err != nil {
+			return err
+		}
+
+		return insertUserEvent(ctx, s.outboxRepository, job.Id.Hex(), constants.UserErasureRequested, job.UserId, map[string]interface{}{
+			"erasureId": job.Id.Hex(),
+		})
+	})
+	if err != nil {
+		slog.Error("Failed to erase user", "error", err, "userId", job.UserId, "id", job.Id)
+		return true, err
+	}
+
+	slog.Info("Erased user", "userId", job.UserId, "id", job.Id)
+	return true, nil
+
+}
+
+// RecordAcknowledgement stores a service's answer and completes the job once every service erased the user
+func (s *erasureService) RecordAcknowledgement(message *models.ErasureAcknowledgementMessage) error {
+
+	id, err := primitive.ObjectIDFromHex(message.ErasureId)
+	if err != nil || !s.isExpectedService(message.Service) {
+		return ErrInvalidAcknowledgement
+	}
+
+	status := constants.ErasureAcknowledgementStatus(message.Status)
+	if status != constants.ErasureAcknowledgementErased && status != constants.ErasureAcknowledgementFailed {
+		return ErrInvalidAcknowledgement
+	}
+
+	job, err := s.erasureRepository.UpdateAcknowledgement(id, message.Service, &models.ErasureAcknowledgement{
+		Status:         status.String(),
+		Error:          truncate(message.Error, 512),
+		AcknowledgedAt: time.Now().UTC(),
+	})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrErasureNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Recorded erasure acknowledgement", "id", id, "userId", job.UserId, "service", message.Service, "status", status)
+
+	for _, acknowledgement := range job.Acknowledgements {
+		if acknowledgement.Status != constants.ErasureAcknowledgementErased.String() {
+			return nil
+		}
+	}
+
+	if job.Status == constants.ErasureCompleted.String() {
+		return nil
+	}
+
+	err = s.erasureRepository.MarkCompleted(id)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Completed erasure", "id", id, "userId", job.UserId)
+	return nil
+
+}
+
+// isExpectedService reports whether the service is one of ERASURE_SERVICES, the only ones an erasure waits for
+func (s *erasureService) isExpectedService(service string) bool {
+
+	for _, expected := range s.services {
+		if service == expected {
+			return true
+		}
+	}
+
+	return false
+
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/producers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeErasureRepository hands out its job once, as ClaimNext leases it
+type fakeErasureRepository struct {
+	repositories.ErasureRepository
+	job    *models.ErasureJob
+	erased bool
+}
+
+func (r *fakeErasureRepository) ClaimNext(lease time.Duration) (*models.ErasureJob, error) {
+	job := r.job
+	r.job = nil
+	return job, nil
+}
+
+func (r *fakeErasureRepository) MarkErased(ctx context.Context, id primitive.ObjectID, acknowledgements map[string]*models.ErasureAcknowledgement) (*models.ErasureJob, error) {
+	r.erased = true
+	return &models.ErasureJob{Id: id, Status: constants.ErasureErased.String(), Acknowledgements: acknowledgements}, nil
+}
+
+func (r *fakeErasureRepository) IsErased(ctx context.Context, userId string) (bool, error) {
+	return r.erased, nil
+}
+
+type fakeErasureUserRepository struct {
+	fakeUserRepository
+	deleted  bool
+	upserted bool
+}
+
+func (r *fakeErasureUserRepository) Delete(ctx context.Context, userId string) error {
+	r.deleted = true
+	return nil
+}
+
+func (r *fakeErasureUserRepository) Upsert(ctx context.Context, user *models.User) (*models.User, error) {
+	r.upserted = true
+	return nil, nil
+}
+
+type fakeFirebaseClient struct {
+	config.FirebaseClient
+}
+
+func (c *fakeFirebaseClient) DeleteUser(ctx context.Context, uid string) error {
+	return nil
+}
+
+func TestEraseNextPurgesOutbox(t *testing.T) {
+
+	verification, err := producers.NewVerificationCodeMessage("user-1", constants.WhatsApp.String(), "+911234567890", "123456", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	outboxRepository := &fakeOutboxRepository{messages: []*models.OutboxMessage{verification, producers.NewWelcomeMessage("user-2")}}
+	userRepository := &fakeErasureUserRepository{fakeUserRepository: fakeUserRepository{user: &models.User{UserId: "user-1"}}}
+	erasureRepository := &fakeErasureRepository{job: &models.ErasureJob{Id: primitive.NewObjectID(), UserId: "user-1"}}
+	auditRepository := &fakeAuditRepository{}
+	deadLetterRepository := &fakeDeadLetterRepository{deadLetters: []models.DeadLetter{
+		{Id: primitive.NewObjectID(), UserId: "user-1", Body: verification.Body},
+		{Id: primitive.NewObjectID(), UserId: "user-2"},
+	}}
+
+	service := &erasureService{
+		userRepository:            userRepository,
+		webhookRepository:         &fakeWebhookRepository{webhooks: map[primitive.ObjectID]*models.Webhook{}},
+		webhookDeliveryRepository: &fakeWebhookDeliveryRepository{},
+		exportRepository:          &fakeExportRepository{},
+		erasureRepository:         erasureRepository,
+		outboxRepository:          outboxRepository,
+		auditRepository:           auditRepository,
+		deadLetterRepository:      deadLetterRepository,
+		transactionRepository:     fakeTransactionRepository{},
+		changes:                   newUserChanges(userRepository, outboxRepository, auditRepository, fakeTransactionRepository{}),
+		firebaseClient:            &fakeFirebaseClient{},
+	}
+
+	erased, err := service.EraseNext()
+	if err != nil || !erased {
+		t.Fatalf("got %v, %v, want the user erased", erased, err)
+	}
+
+	if len(outboxRepository.messages) != 3 {
+		t.Fatalf("got %d outbox messages, want user-2's welcome and the deletion and erasure events", len(outboxRepository.messages))
+	}
+	events := map[string]bool{}
+	for _, message := range outboxRepository.messages {
+		if message.UserId != "user-1" {
+			continue
+		}
+		if message.RoutingKey != constants.UserDeleted.String() && message.RoutingKey != constants.UserErasureRequested.String() {
+			t.Errorf("kept the %s message of the erased user", message.RoutingKey)
+		}
+		events[message.RoutingKey] = true
+	}
+	if !events[constants.UserDeleted.String()] || !events[constants.UserErasureRequested.String()] {
+		t.Errorf("got events %v, want both %s and %s", events, constants.UserDeleted, constants.UserErasureRequested)
+	}
+
+	if len(deadLetterRepository.deadLetters) != 1 || deadLetterRepository.deadLetters[0].UserId != "user-2" {
+		t.Errorf("got dead letters %v, want only user-2's", deadLetterRepository.deadLetters)
+	}
+
+	// the ID token of the erased user is still valid for a while, logging in with it must not bring the user back
+	userService := &userService{
+		userRepository:        userRepository,
+		outboxRepository:      outboxRepository,
+		erasureRepository:     erasureRepository,
+		transactionRepository: fakeTransactionRepository{},
+		changes:               service.changes,
+	}
+
+	_, err = userService.UpsertUser(&models.Actor{UserId: "user-1"}, &models.FirebaseIdentity{UID: "user-1"})
+	if !errors.Is(err, ErrUserErased) {
+		t.Errorf("got error %v, want %v", err, ErrUserErased)
+	}
+	if userRepository.upserted {
+		t.Error("recreated the erased user")
+	}
+
+}
+
+type fakeAcknowledgementRepository struct {
+	repositories.ErasureRepository
+	updated []string
+}
+
+func (r *fakeAcknowledgementRepository) UpdateAcknowledgement(id primitive.ObjectID, service string, acknowledgement *models.ErasureAcknowledgement) (*models.ErasureJob, error) {
+	r.updated = append(r.updated, service)
+	return &models.ErasureJob{Id: id, UserId: "user-1", Acknowledgements: map[string]*models.ErasureAcknowledgement{
+		constants.FirebaseErasureService: {Status: constants.ErasureAcknowledgementErased.String()},
+		service:                          acknowledgement,
+		"notification":                   {Status: constants.ErasureAcknowledgementPending.String()},
+	}}, nil
+}
+
+func TestRecordAcknowledgementOnlyFromExpectedServices(t *testing.T) {
+
+	tests := []struct {
+		service string
+		err     error
+	}{
+		{"video-processing", nil},
+		{"notification", nil},
+		{"billing", ErrInvalidAcknowledgement},
+		{constants.FirebaseErasureService, ErrInvalidAcknowledgement},
+		{"", ErrInvalidAcknowledgement},
+	}
+
+	for _, test := range tests {
+		t.Run(test.service, func(t *testing.T) {
+			erasureRepository := &fakeAcknowledgementRepository{}
+			service := &erasureService{erasureRepository: erasureRepository, services: []string{"video-processing", "notification"}}
+
+			err := service.RecordAcknowledgement(&models.ErasureAcknowledgementMessage{
+				ErasureId: primitive.NewObjectID().Hex(),
+				Service:   test.service,
+				Status:    constants.ErasureAcknowledgementErased.String(),
+			})
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if recorded := len(erasureRepository.updated) > 0; recorded != (test.err == nil) {
+				t.Errorf("recorded %v, want %v", recorded, test.err == nil)
+			}
+		})
+	}
+
+}
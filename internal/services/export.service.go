@@ -0,0 +1,380 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/exp/slog"
+)
+
+// ExportService builds a copy of everything this service holds about a user in the background,
+// the user polls the export and downloads it through a short-lived link
+type ExportService interface {
+	RequestExport(userId string, request *models.ExportRequest) (*models.ExportJob, error)
+	GetExport(userId string, id string) (*models.ExportResponse, error)
+	Download(token string) (*models.ExportJob, error)
+	ExportNext() (bool, error)
+}
+
+var (
+	ErrExportNotFound          = errors.New("export not found")
+	ErrExportRateLimited       = errors.New("too many exports requested, try again later")
+	ErrExportNotAvailable      = errors.New("export link is invalid or expired")
+	ErrExportTooLarge          = errors.New("export is too large")
+	ErrExportRetentionTooShort = errors.New("EXPORT_RETENTION has to be at least EXPORT_LIMIT_WINDOW")
+)
+
+// exportFileName is the name of the json document, on its own or inside the zip archive
+const exportFileName = "vqe-user-export.json"
+
+type exportService struct {
+	userRepository            repositories.UserRepository
+	webhookRepository         repositories.WebhookRepository
+	webhookDeliveryRepository repositories.WebhookDeliveryRepository
+	exportRepository          repositories.ExportRepository
+	auditRepository           repositories.AuditRepository
+	transactionRepository     repositories.TransactionRepository
+	maxExports                int
+	exportWindow              time.Duration
+	retention                 time.Duration
+	linkTTL                   time.Duration
+	downloadURL               string
+	lease                     time.Duration
+	maxAttempts               int
+	maxDeliveries             int
+	maxAuditEntries           int
+	maxSize                   int
+}
+
+func NewExportService(userRepository repositories.UserRepository, webhookRepository repositories.WebhookRepository, webhookDeliveryRepository repositories.WebhookDeliveryRepository, exportRepository repositories.ExportRepository, auditRepository repositories.AuditRepository, transactionRepository repositories.TransactionRepository) ExportService {
+
+	exportWindow := config.GetEnvDuration("EXPORT_LIMIT_WINDOW", 24*time.Hour)
+	retention := config.GetEnvDuration("EXPORT_RETENTION", 48*time.Hour)
+
+	// the rate limit counts the exports still stored, ones deleted before the window is over would not count
+	if retention < exportWindow {
+		slog.Error("Failed to set up export service", "error", ErrExportRetentionTooShort, "retention", retention, "window", exportWindow)
+		panic(ErrExportRetentionTooShort)
+	}
+
+	return &exportService{
+		userRepository:            userRepository,
+		webhookRepository:         webhookRepository,
+		webhookDeliveryRepository: webhookDeliveryRepository,
+		exportRepository:          exportRepository,
+		auditRepository:           auditRepository,
+		transactionRepository:     transactionRepository,
+		maxExports:                config.GetEnvInt("EXPORT_LIMIT", 3),
+		exportWindow:              exportWindow,
+		retention:                 retention,
+		linkTTL:                   config.GetEnvDuration("EXPORT_LINK_TTL", 15*time.Minute),
+		downloadURL:               config.GetEnv("EXPORT_DOWNLOAD_URL", "/api/export"),
+		lease:                     config.GetEnvDuration("EXPORT_LEASE", 5*time.Minute),
+		maxAttempts:               config.GetEnvInt("EXPORT_MAX_ATTEMPTS", 5),
+		maxDeliveries:             config.GetEnvInt("EXPORT_MAX_DELIVERIES", 1000),
+		maxAuditEntries:           config.GetEnvInt("EXPORT_MAX_AUDIT_ENTRIES", 5000),
+		// the archive is stored in the job document, which has to stay below Mongo's 16MB limit
+		maxSize: config.GetEnvInt("EXPORT_MAX_SIZE", 15*1024*1024),
+	}
+
+}
+
+// RequestExport counts and inserts inside one transaction that first writes the user's export lock, so concurrent requests
+// conflict on the user instead of each counting the others out
+func (s *exportService) RequestExport(userId string, request *models.ExportRequest) (*models.ExportJob, error) {
+
+	format := request.Format
+	if format == "" {
+		format = constants.ExportJSON.String()
+	}
+
+	now := time.Now().UTC()
+	job := &models.ExportJob{
+		UserId:      userId,
+		Format:      format,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(s.retention),
+	}
+
+	err := s.transactionRepository.WithTransaction(func(ctx context.Context) error {
+		err := s.userRepository.LockExports(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		count, err := s.exportRepository.CountRequestedSince(ctx, userId, now.Add(-s.exportWindow))
+		if err != nil {
+			return err
+		}
+
+		if count >= int64(s.maxExports) {
+			slog.Warn("Export rate limited", "userId", userId, "count", count)
+			return ErrExportRateLimited
+		}
+
+		return s.exportRepository.Insert(ctx, job)
+	})
+	if errors.Is(err, ErrExportRateLimited) {
+		return nil, err
+	}
+	if err != nil {
+		slog.Error("Failed to request export", "error", err, "userId", userId)
+		return nil, userError(err)
+	}
+
+	slog.Info("Requested export", "userId", userId, "id", job.Id, "format", format)
+	return job, nil
+
+}
+
+// GetExport returns the status of the export and, once it is ready, a new download link replacing any earlier one
+func (s *exportService) GetExport(userId string, id string) (*models.ExportResponse, error) {
+
+	exportId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrExportNotFound
+	}
+
+	job, err := s.exportRepository.FindById(userId, exportId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ExportResponse{ExportJob: job}
+	if job.Status != constants.ExportReady.String() {
+		return response, nil
+	}
+
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().UTC().Add(s.linkTTL)
+	if expiresAt.After(job.ExpiresAt) {
+		expiresAt = job.ExpiresAt
+	}
+
+	err = s.exportRepository.SetDownloadToken(userId, exportId, utils.HashToken(token), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	response.DownloadURL = s.downloadURL + "/" + token
+	response.DownloadExpiresAt = expiresAt
+	return response, nil
+
+}
+
+func (s *exportService) Download(token string) (*models.ExportJob, error) {
+
+	job, err := s.exportRepository.FindByDownloadToken(utils.HashToken(token))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrExportNotAvailable
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Downloaded export", "userId", job.UserId, "id", job.Id)
+	return job, nil
+
+}
+
+// ExportNext builds the archive of the oldest pending export and reports whether there was one.
+// A failed build goes back to pending until it used up its attempts, then the export fails.
+func (s *exportService) ExportNext() (bool, error) {
+
+	job, err := s.exportRepository.ClaimNext(s.lease)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	// an export whose lease keeps running out never gets to fail below
+	if job.Attempts > s.maxAttempts {
+		slog.Error("Failed to build export, out of attempts", "userId", job.UserId, "id", job.Id, "attempts", job.Attempts)
+		return true, s.exportRepository.MarkFailed(job.Id, "export was not built within its lease")
+	}
+
+	archive, err := s.buildArchive(job)
+	if err == nil {
+		err = s.exportRepository.MarkReady(job.Id, archive)
+	}
+	if err != nil {
+		return true, s.failExport(job, err)
+	}
+
+	slog.Info("Built export", "userId", job.UserId, "id", job.Id, "size", len(archive))
+	return true, nil
+
+}
+
+// failExport fails the export for good when retrying cannot help or it is out of attempts, otherwise it is released to be retried
+func (s *exportService) failExport(job *models.ExportJob, err error) error {
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = ErrUserNotFound
+	}
+
+	slog.Error("Failed to build export", "error", err, "userId", job.UserId, "id", job.Id, "attempts", job.Attempts)
+
+	if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrExportTooLarge) || job.Attempts >= s.maxAttempts {
+		markErr := s.exportRepository.MarkFailed(job.Id, err.Error())
+		if markErr != nil {
+			return markErr
+		}
+		return err
+	}
+
+	releaseErr := s.exportRepository.Release(job.Id, err.Error())
+	if releaseErr != nil {
+		return releaseErr
+	}
+	return err
+
+}
+
+func (s *exportService) buildArchive(job *models.ExportJob) ([]byte, error) {
+
+	user, err := s.userRepository.FindByUserId(job.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.webhookRepository.FindByUserId(job.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	// one more than the cap is read to tell whether anything was left out
+	deliveries, err := s.webhookDeliveryRepository.FindByUserId(job.UserId, s.maxDeliveries+1)
+	if err != nil {
+		return nil, err
+	}
+
+	auditEntries, err := s.auditRepository.FindByUserId(job.UserId, s.maxAuditEntries+1)
+	if err != nil {
+		return nil, err
+	}
+
+	truncated := []string{}
+	if len(deliveries) > s.maxDeliveries {
+		deliveries = deliveries[:s.maxDeliveries]
+		truncated = append(truncated, "webhookDeliveries")
+	}
+	if len(auditEntries) > s.maxAuditEntries {
+		auditEntries = auditEntries[len(auditEntries)-s.maxAuditEntries:]
+		truncated = append(truncated, "auditEntries")
+	}
+
+	userExport := newUserExport(user, webhooks, deliveries, auditEntries)
+	userExport.Truncated = truncated
+
+	// webhook secrets never leave the service, the json encoding of a webhook leaves them out
+	document, err := json.MarshalIndent(userExport, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Format != constants.ExportZIP.String() {
+		return s.checkSize(document)
+	}
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+
+	file, err := writer.Create(exportFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = file.Write(document)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.checkSize(archive.Bytes())
+
+}
+
+func (s *exportService) checkSize(archive []byte) ([]byte, error) {
+
+	if len(archive) > s.maxSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrExportTooLarge, len(archive))
+	}
+
+	return archive, nil
+
+}
+
+func newUserExport(user *models.User, webhooks []models.Webhook, deliveries []models.WebhookDelivery, auditEntries []models.AuditEntry) *models.UserExport {
+
+	fcmDevices := user.FCMtokens
+	if fcmDevices == nil {
+		fcmDevices = []models.FCMtoken{}
+	}
+
+	// the export goes to the user, who like on GET /api/user/audit only learns the role behind each change
+	redactAuditActors(auditEntries)
+
+	// the preference history is the part of the audit trail about which channels receive what
+	preferenceHistory := []models.AuditEntry{}
+	for _, entry := range auditEntries {
+		if entry.Field == "notificationInterfaces" || entry.Field == "notificationPreferences" {
+			preferenceHistory = append(preferenceHistory, entry)
+		}
+	}
+
+	return &models.UserExport{
+		ExportedAt: time.Now().UTC(),
+		Profile: models.ExportProfile{
+			UserId:         user.UserId,
+			Email:          user.Email,
+			EmailVerified:  user.EmailVerified,
+			DisplayName:    user.DisplayName,
+			PhotoURL:       user.PhotoURL,
+			SignInProvider: user.SignInProvider,
+			CustomClaims:   user.CustomClaims,
+			LastLoginAt:    user.LastLoginAt,
+			LoginCount:     user.LoginCount,
+			CreatedAt:      user.CreatedAt,
+			UpdatedAt:      user.UpdatedAt,
+		},
+		Channels: models.ExportChannels{
+			NotificationInterfaces:  user.NotificationInterfaces,
+			NotificationPreferences: user.NotificationPreferences,
+			WhatsAppNumber:          user.WhatsAppNumber,
+			DiscordId:               user.DiscordId,
+			DiscordUsername:         user.DiscordUsername,
+			TelegramNumber:          user.TelegramNumber,
+			TelegramChatId:          user.TelegramChatId,
+			ChannelVerifications:    user.ChannelVerifications,
+		},
+		FCMdevices:        fcmDevices,
+		Webhooks:          webhooks,
+		WebhookDeliveries: deliveries,
+		PreferenceHistory: preferenceHistory,
+		AuditEntries:      auditEntries,
+	}
+
+}
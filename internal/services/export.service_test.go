@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeExportRepository claims the first pending job, counting the attempt as ClaimNext does
+type fakeExportRepository struct {
+	repositories.ExportRepository
+	jobs []*models.ExportJob
+}
+
+func (r *fakeExportRepository) Insert(ctx context.Context, job *models.ExportJob) error {
+	job.Id = primitive.NewObjectID()
+	job.Status = constants.ExportPending.String()
+	r.jobs = append(r.jobs, job)
+	return nil
+}
+
+func (r *fakeExportRepository) CountRequestedSince(ctx context.Context, userId string, since time.Time) (int64, error) {
+	var count int64
+	for _, job := range r.jobs {
+		if job.UserId == userId && !job.RequestedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeExportRepository) ClaimNext(lease time.Duration) (*models.ExportJob, error) {
+	for _, job := range r.jobs {
+		if job.Status == constants.ExportPending.String() {
+			job.Status = constants.ExportProcessing.String()
+			job.Attempts++
+			claimed := *job
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeExportRepository) complete(id primitive.ObjectID, status string, archive []byte, lastError string) {
+	for _, job := range r.jobs {
+		if job.Id == id {
+			job.Status = status
+			job.Archive = archive
+			job.Error = lastError
+		}
+	}
+}
+
+func (r *fakeExportRepository) MarkReady(id primitive.ObjectID, archive []byte) error {
+	r.complete(id, constants.ExportReady.String(), archive, "")
+	return nil
+}
+
+func (r *fakeExportRepository) MarkFailed(id primitive.ObjectID, lastError string) error {
+	r.complete(id, constants.ExportFailed.String(), nil, lastError)
+	return nil
+}
+
+func (r *fakeExportRepository) Release(id primitive.ObjectID, lastError string) error {
+	r.complete(id, constants.ExportPending.String(), nil, lastError)
+	return nil
+}
+
+func (r *fakeExportRepository) DeleteByUserId(userId string) (int64, error) {
+	return 0, nil
+}
+
+type fakeExportUserRepository struct {
+	fakeUserRepository
+	err error
+}
+
+func (r *fakeExportUserRepository) FindByUserId(userId string) (*models.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	user := *r.user
+	return &user, nil
+}
+
+func (r *fakeExportUserRepository) LockExports(ctx context.Context, userId string) error {
+	return nil
+}
+
+type fakeExportWebhookRepository struct {
+	repositories.WebhookRepository
+}
+
+func (r *fakeExportWebhookRepository) FindByUserId(userId string) ([]models.Webhook, error) {
+	return []models.Webhook{}, nil
+}
+
+type fakeExportDeliveryRepository struct {
+	repositories.WebhookDeliveryRepository
+	deliveries int
+}
+
+func (r *fakeExportDeliveryRepository) FindByUserId(userId string, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+	for i := 0; i < r.deliveries && i < limit; i++ {
+		deliveries = append(deliveries, models.WebhookDelivery{UserId: userId})
+	}
+	return deliveries, nil
+}
+
+type fakeExportAuditRepository struct {
+	fakeAuditRepository
+}
+
+func (r *fakeExportAuditRepository) FindByUserId(userId string, limit int) ([]models.AuditEntry, error) {
+	return r.entries, nil
+}
+
+func newTestExportService(userErr error, deliveries int) (*exportService, *fakeExportRepository) {
+
+	exportRepository := &fakeExportRepository{}
+
+	return &exportService{
+		userRepository:            &fakeExportUserRepository{fakeUserRepository: fakeUserRepository{user: &models.User{UserId: "user-1"}}, err: userErr},
+		webhookRepository:         &fakeExportWebhookRepository{},
+		webhookDeliveryRepository: &fakeExportDeliveryRepository{deliveries: deliveries},
+		exportRepository:          exportRepository,
+		auditRepository:           &fakeExportAuditRepository{},
+		transactionRepository:     fakeTransactionRepository{},
+		maxExports:                2,
+		exportWindow:              24 * time.Hour,
+		retention:                 48 * time.Hour,
+		maxAttempts:               3,
+		maxDeliveries:             2,
+		maxAuditEntries:           10,
+		maxSize:                   1024 * 1024,
+	}, exportRepository
+
+}
+
+func TestRequestExportLimit(t *testing.T) {
+
+	service, _ := newTestExportService(nil, 0)
+
+	for i := 0; i < 2; i++ {
+		_, err := service.RequestExport("user-1", &models.ExportRequest{})
+		if err != nil {
+			t.Fatalf("export %d: %v", i+1, err)
+		}
+	}
+
+	_, err := service.RequestExport("user-1", &models.ExportRequest{})
+	if !errors.Is(err, ErrExportRateLimited) {
+		t.Errorf("got error %v, want %v", err, ErrExportRateLimited)
+	}
+
+}
+
+func TestExportNextRetriesThenFails(t *testing.T) {
+
+	service, exportRepository := newTestExportService(errors.New("server selection timeout"), 0)
+
+	_, err := service.RequestExport("user-1", &models.ExportRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		exported, err := service.ExportNext()
+		if !exported || err == nil {
+			t.Fatalf("attempt %d: got %v, %v, want the failure", attempt, exported, err)
+		}
+
+		job := exportRepository.jobs[0]
+		want := constants.ExportPending.String()
+		if attempt == 3 {
+			want = constants.ExportFailed.String()
+		}
+		if job.Status != want {
+			t.Errorf("attempt %d: got status %s, want %s", attempt, job.Status, want)
+		}
+	}
+
+	exported, err := service.ExportNext()
+	if exported || err != nil {
+		t.Errorf("failed export was claimed again: %v, %v", exported, err)
+	}
+
+}
+
+func TestExportNextTruncatesDeliveries(t *testing.T) {
+
+	service, exportRepository := newTestExportService(nil, 5)
+
+	_, err := service.RequestExport("user-1", &models.ExportRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = service.ExportNext()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var userExport models.UserExport
+	err = json.Unmarshal(exportRepository.jobs[0].Archive, &userExport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(userExport.WebhookDeliveries) != 2 {
+		t.Errorf("exported %d deliveries, want 2", len(userExport.WebhookDeliveries))
+	}
+	if len(userExport.Truncated) != 1 || userExport.Truncated[0] != "webhookDeliveries" {
+		t.Errorf("got truncated %v, want the deliveries", userExport.Truncated)
+	}
+
+}
+
+func TestExportNextTooLarge(t *testing.T) {
+
+	service, exportRepository := newTestExportService(nil, 0)
+	service.maxSize = 16
+
+	_, err := service.RequestExport("user-1", &models.ExportRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = service.ExportNext()
+	if !errors.Is(err, ErrExportTooLarge) {
+		t.Fatalf("got error %v, want %v", err, ErrExportTooLarge)
+	}
+	if exportRepository.jobs[0].Status != constants.ExportFailed.String() {
+		t.Errorf("got status %s, a too large export is not retried", exportRepository.jobs[0].Status)
+	}
+
+}
+
+func TestNewExportServiceRetention(t *testing.T) {
+
+	t.Setenv("EXPORT_LIMIT_WINDOW", "24h")
+	t.Setenv("EXPORT_RETENTION", "12h")
+
+	defer func() {
+		if recover() != ErrExportRetentionTooShort {
+			t.Error("retention shorter than the rate limit window was accepted")
+		}
+	}()
+
+	NewExportService(nil, nil, nil, nil, nil, nil)
+
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"golang.org/x/exp/slog"
+)
+
+// HealthService answers the liveness and readiness probes
+type HealthService interface {
+	Live() *models.HealthResponse
+	Ready() (*models.HealthResponse, bool)
+}
+
+// HealthCheck probes a single dependency, returning why it is unusable
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type healthService struct {
+	isReady  func() bool
+	checks   []HealthCheck
+	timeout  time.Duration
+	cacheTTL time.Duration
+	mutex    sync.Mutex
+	cached   *models.HealthResponse
+}
+
+// NewHealthService reports ready while isReady holds and every check passes
+func NewHealthService(isReady func() bool, checks ...HealthCheck) HealthService {
+	return &healthService{
+		isReady:  isReady,
+		checks:   checks,
+		timeout:  config.GetEnvDuration("HEALTH_PROBE_TIMEOUT", 2*time.Second),
+		cacheTTL: config.GetEnvDuration("HEALTH_CACHE_TTL", 5*time.Second),
+	}
+}
+
+// Live only tells the process is running and serving requests, dependencies are left to Ready
+func (s *healthService) Live() *models.HealthResponse {
+	return &models.HealthResponse{
+		Status:    constants.HealthUp.String(),
+		CheckedAt: time.Now().UTC(),
+	}
+}
+
+// Ready reports down as soon as the shutdown starts, otherwise it probes the dependencies at most once per HEALTH_CACHE_TTL
+func (s *healthService) Ready() (*models.HealthResponse, bool) {
+
+	if !s.isReady() {
+		return &models.HealthResponse{
+			Status:    constants.HealthDown.String(),
+			Error:     "shutting down",
+			CheckedAt: time.Now().UTC(),
+		}, false
+	}
+
+	// probes arriving together wait for the one in flight instead of probing again
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.cached == nil || time.Since(s.cached.CheckedAt) >= s.cacheTTL {
+		s.cached = s.probe()
+	}
+
+	return s.cached, s.cached.Status == constants.HealthUp.String()
+
+}
+
+// probe runs every check in parallel, each within HEALTH_PROBE_TIMEOUT, publishing only whether each dependency is up
+func (s *healthService) probe() *models.HealthResponse {
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	results := make([]models.DependencyHealth, len(s.checks))
+	errs := make([]error, len(s.checks))
+	latencies := make([]time.Duration, len(s.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			errs[i] = check.Check(ctx)
+			latencies[i] = time.Since(start)
+			results[i] = models.DependencyHealth{Status: constants.HealthUp.String()}
+			if errs[i] != nil {
+				results[i].Status = constants.HealthDown.String()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+
+	response := &models.HealthResponse{
+		Status:    constants.HealthUp.String(),
+		Checks:    make(map[string]models.DependencyHealth, len(s.checks)),
+		CheckedAt: time.Now().UTC(),
+	}
+	for i, check := range s.checks {
+		response.Checks[check.Name] = results[i]
+		if results[i].Status != constants.HealthUp.String() {
+			response.Status = constants.HealthDown.String()
+			// the probe is unauthenticated, so why the dependency is down only goes to the logs
+			slog.Error("Health check failed", "dependency", check.Name, "latency", latencies[i], "error", errs[i])
+		}
+	}
+
+	return response
+
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+)
+
+func TestReadyHidesDependencyErrors(t *testing.T) {
+
+	service := &healthService{
+		isReady: func() bool { return true },
+		checks: []HealthCheck{
+			{Name: "mongo", Check: func(ctx context.Context) error { return nil }},
+			{Name: "amqp", Check: func(ctx context.Context) error {
+				return errors.New("dial tcp rabbitmq.internal:5672: connection refused")
+			}},
+		},
+		timeout: time.Second,
+	}
+
+	response, ready := service.Ready()
+	if ready {
+		t.Fatal("got ready with a dependency down")
+	}
+	if response.Checks["mongo"].Status != constants.HealthUp.String() || response.Checks["amqp"].Status != constants.HealthDown.String() {
+		t.Errorf("got checks %+v", response.Checks)
+	}
+
+	body, _ := json.Marshal(response)
+	if strings.Contains(string(body), "rabbitmq.internal") {
+		t.Errorf("got the dependency error in the response %s", body)
+	}
+
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+)
+
+// resolveNotificationInterfaces answers which of the user's notification interfaces should receive the event type.
+// A preference for the event type narrows down the enabled notification interfaces, without one every enabled
+// interface receives it, except for opt-in event types which then go nowhere.
+// A user waiting for erasure receives nothing.
+func resolveNotificationInterfaces(user *models.User, eventType constants.NotificationEventType) []string {
+
+	if !user.DeletedAt.IsZero() {
+		return []string{}
+	}
+
+	preferred, hasPreference := user.NotificationPreferences[eventType.String()]
+	if !hasPreference && eventType.IsOptIn() {
+		return []string{}
+	}
+
+	preferredSet := make(map[string]struct{}, len(preferred))
+	for _, notificationInterface := range preferred {
+		preferredSet[notificationInterface] = struct{}{}
+	}
+
+	notificationInterfaces := make([]string, 0, len(user.NotificationInterfaces))
+	for _, notificationInterface := range user.NotificationInterfaces {
+		if _, ok := preferredSet[notificationInterface]; ok || !hasPreference {
+			notificationInterfaces = append(notificationInterfaces, notificationInterface)
+		}
+	}
+
+	return notificationInterfaces
+
+}
+
+// addDeliveryTarget copies the user's target for the notification interface into deliveryTargets
+// and reports whether the interface can actually be delivered to
+func addDeliveryTarget(deliveryTargets *models.DeliveryTargets, user *models.User, webhooks []models.Webhook, notificationInterface constants.NotificationInterface) bool {
+
+	switch notificationInterface {
+	case constants.Email:
+		// the address comes from the Firebase claims, only addresses Firebase verified are mailed
+		if user.Email == "" || !user.EmailVerified {
+			return false
+		}
+		deliveryTargets.Email = user.Email
+		return true
+	case constants.UI:
+		for _, FCMtoken := range user.FCMtokens {
+			deliveryTargets.FCMtokens = append(deliveryTargets.FCMtokens, FCMtoken.Token)
+		}
+		return len(deliveryTargets.FCMtokens) > 0
+	case constants.WhatsApp:
+		if !isChannelVerified(user, notificationInterface, user.WhatsAppNumber) {
+			return false
+		}
+		deliveryTargets.WhatsAppNumber = user.WhatsAppNumber
+		return true
+	case constants.Discord:
+		deliveryTargets.DiscordId = user.DiscordId
+		return user.DiscordId != ""
+	case constants.Telegram:
+		// the bot can only message a bound chat, the number is passed along when verified
+		if isChannelVerified(user, notificationInterface, user.TelegramNumber) {
+			deliveryTargets.TelegramNumber = user.TelegramNumber
+		}
+		deliveryTargets.TelegramChatId = user.TelegramChatId
+		return user.TelegramChatId != 0
+	case constants.Webhooks:
+		deliveryTargets.Webhooks = webhookTargets(webhooks, deliveryTargets.EventType)
+		return len(deliveryTargets.Webhooks) > 0
+	}
+
+	return false
+
+}
+
+// isChannelVerified reports whether number is the one the user last confirmed for the channel,
+// numbers stored before verification existed are not
+func isChannelVerified(user *models.User, channel constants.NotificationInterface, number string) bool {
+	verification, ok := user.ChannelVerifications[channel.String()]
+	return ok && number != "" && verification.VerifiedNumber == number
+}
+
+// webhookTargets keeps the enabled and verified webhooks subscribed to the event type, no event types meaning all of them,
+// along with the secrets the dispatcher should sign with, newest first
+func webhookTargets(webhooks []models.Webhook, eventType string) []models.WebhookTarget {
+
+	now := time.Now()
+	targets := []models.WebhookTarget{}
+
+	for _, webhook := range webhooks {
+		if !webhook.Enabled || webhook.Verification.Status != constants.WebhookVerificationVerified.String() || !isSubscribed(webhook.EventTypes, eventType) {
+			continue
+		}
+
+		targets = append(targets, models.WebhookTarget{
+			Id:                 webhook.Id.Hex(),
+			URL:                webhook.URL,
+			SignatureHeader:    constants.WebhookSignatureHeader,
+			SignatureAlgorithm: constants.WebhookSignatureAlgorithm,
+			Secrets:            activeWebhookSecrets(&webhook, now),
+		})
+	}
+
+	return targets
+
+}
+
+// activeWebhookSecrets returns the secrets that have not expired yet, newest first
+func activeWebhookSecrets(webhook *models.Webhook, now time.Time) []string {
+
+	secrets := []string{}
+	for i := len(webhook.Secrets) - 1; i >= 0; i-- {
+		if webhook.Secrets[i].ExpiresAt.IsZero() || webhook.Secrets[i].ExpiresAt.After(now) {
+			secrets = append(secrets, webhook.Secrets[i].Secret)
+		}
+	}
+
+	return secrets
+
+}
+
+func isSubscribed(eventTypes []string, eventType string) bool {
+
+	if len(eventTypes) == 0 {
+		return true
+	}
+
+	for _, subscribed := range eventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+
+	return false
+
+}
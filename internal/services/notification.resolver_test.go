@@ -0,0 +1,152 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeResolverWebhookRepository struct {
+	repositories.WebhookRepository
+	webhooks []models.Webhook
+}
+
+func (r *fakeResolverWebhookRepository) FindByUserId(userId string) ([]models.Webhook, error) {
+	return r.webhooks, nil
+}
+
+// TestResolveDeliveryTargets checks which notification interfaces are kept for a user, each case changing one thing
+// about a user who could otherwise be reached everywhere
+func TestResolveDeliveryTargets(t *testing.T) {
+
+	verified := func(number string) *models.ChannelVerification {
+		return &models.ChannelVerification{Status: constants.VerificationVerified.String(), VerifiedNumber: number}
+	}
+	webhook := func(enabled bool, status constants.WebhookVerificationStatus, eventTypes ...string) models.Webhook {
+		return models.Webhook{Id: primitive.NewObjectID(), URL: "https://example.com/hook", EventTypes: eventTypes, Enabled: enabled, Verification: models.WebhookVerification{Status: status.String()}}
+	}
+	newUser := func() *models.User {
+		return &models.User{
+			UserId:                 "user-1",
+			Email:                  "nelly@example.com",
+			EmailVerified:          true,
+			NotificationInterfaces: []string{constants.Email.String(), constants.WhatsApp.String(), constants.Webhooks.String()},
+			WhatsAppNumber:         "+911234567890",
+			ChannelVerifications:   map[string]*models.ChannelVerification{constants.WhatsApp.String(): verified("+911234567890")},
+		}
+	}
+	all := []string{constants.Email.String(), constants.WhatsApp.String(), constants.Webhooks.String()}
+
+	tests := []struct {
+		name                   string
+		eventType              constants.NotificationEventType
+		change                 func(user *models.User)
+		webhooks               []models.Webhook
+		notificationInterfaces []string
+		webhookTargets         int
+	}{
+		{
+			name:                   "reachable everywhere",
+			eventType:              constants.EnhancementCompleted,
+			webhooks:               []models.Webhook{webhook(true, constants.WebhookVerificationVerified)},
+			notificationInterfaces: all,
+			webhookTargets:         1,
+		},
+		{
+			name:                   "email not verified",
+			eventType:              constants.EnhancementCompleted,
+			change:                 func(user *models.User) { user.EmailVerified = false },
+			webhooks:               []models.Webhook{webhook(true, constants.WebhookVerificationVerified)},
+			notificationInterfaces: []string{constants.WhatsApp.String(), constants.Webhooks.String()},
+			webhookTargets:         1,
+		},
+		{
+			name:                   "whatsApp number not verified",
+			eventType:              constants.EnhancementCompleted,
+			change:                 func(user *models.User) { user.ChannelVerifications = nil },
+			webhooks:               []models.Webhook{webhook(true, constants.WebhookVerificationVerified)},
+			notificationInterfaces: []string{constants.Email.String(), constants.Webhooks.String()},
+			webhookTargets:         1,
+		},
+		{
+			name:                   "whatsApp number changed since verification",
+			eventType:              constants.EnhancementCompleted,
+			change:                 func(user *models.User) { user.WhatsAppNumber = "+919876543210" },
+			webhooks:               []models.Webhook{webhook(true, constants.WebhookVerificationVerified)},
+			notificationInterfaces: []string{constants.Email.String(), constants.Webhooks.String()},
+			webhookTargets:         1,
+		},
+		{
+			name:                   "webhook not verified",
+			eventType:              constants.EnhancementCompleted,
+			webhooks:               []models.Webhook{webhook(true, constants.WebhookVerificationPending)},
+			notificationInterfaces: []string{constants.Email.String(), constants.WhatsApp.String()},
+		},
+		{
+			name:                   "webhook disabled",
+			eventType:              constants.EnhancementCompleted,
+			webhooks:               []models.Webhook{webhook(false, constants.WebhookVerificationVerified), webhook(true, constants.WebhookVerificationVerified)},
+			notificationInterfaces: all,
+			webhookTargets:         1,
+		},
+		{
+			name:                   "webhook subscribed to other event types",
+			eventType:              constants.EnhancementCompleted,
+			webhooks:               []models.Webhook{webhook(true, constants.WebhookVerificationVerified, constants.EnhancementFailed.String())},
+			notificationInterfaces: []string{constants.Email.String(), constants.WhatsApp.String()},
+		},
+		{
+			name:      "preference narrows down",
+			eventType: constants.EnhancementCompleted,
+			change: func(user *models.User) {
+				user.NotificationPreferences = map[string][]string{constants.EnhancementCompleted.String(): {constants.Email.String()}}
+			},
+			webhooks:               []models.Webhook{webhook(true, constants.WebhookVerificationVerified)},
+			notificationInterfaces: []string{constants.Email.String()},
+		},
+		{
+			name:                   "opt-in event type without preference",
+			eventType:              constants.Marketing,
+			webhooks:               []models.Webhook{webhook(true, constants.WebhookVerificationVerified)},
+			notificationInterfaces: []string{},
+		},
+		{
+			name:                   "waiting for erasure",
+			eventType:              constants.EnhancementCompleted,
+			change:                 func(user *models.User) { user.DeletedAt = time.Now() },
+			webhooks:               []models.Webhook{webhook(true, constants.WebhookVerificationVerified)},
+			notificationInterfaces: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			user := newUser()
+			if test.change != nil {
+				test.change(user)
+			}
+			service := &userService{
+				userRepository:    &fakeExportUserRepository{fakeUserRepository: fakeUserRepository{user: user}},
+				webhookRepository: &fakeResolverWebhookRepository{webhooks: test.webhooks},
+			}
+
+			deliveryTargets, err := service.ResolveDeliveryTargets("user-1", test.eventType.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(deliveryTargets.NotificationInterfaces, test.notificationInterfaces) {
+				t.Errorf("got notification interfaces %v, want %v", deliveryTargets.NotificationInterfaces, test.notificationInterfaces)
+			}
+			if len(deliveryTargets.Webhooks) != test.webhookTargets {
+				t.Errorf("got %d webhook targets, want %d", len(deliveryTargets.Webhooks), test.webhookTargets)
+			}
+		})
+	}
+
+}
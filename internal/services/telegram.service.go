@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"golang.org/x/exp/slog"
+)
+
+type TelegramService interface {
+	StartBind(userId string) (*models.TelegramBindResponse, error)
+	CompleteBind(actor *models.Actor, token string, chatId int64) error
+}
+
+type telegramService struct {
+	userRepository        repositories.UserRepository
+	linkStateRepository   repositories.LinkStateRepository
+	outboxRepository      repositories.OutboxRepository
+	transactionRepository repositories.TransactionRepository
+	changes               *userChanges
+	botUsername           string
+	bindTokenTTL          time.Duration
+}
+
+func NewTelegramService(userRepository repositories.UserRepository, linkStateRepository repositories.LinkStateRepository, outboxRepository repositories.OutboxRepository, auditRepository repositories.AuditRepository, transactionRepository repositories.TransactionRepository) TelegramService {
+	return &telegramService{
+		userRepository:        userRepository,
+		linkStateRepository:   linkStateRepository,
+		outboxRepository:      outboxRepository,
+		transactionRepository: transactionRepository,
+		changes:               newUserChanges(userRepository, outboxRepository, auditRepository, transactionRepository),
+		botUsername:           os.Getenv("TELEGRAM_BOT_USERNAME"),
+		bindTokenTTL:          config.GetEnvDuration("TELEGRAM_BIND_TOKEN_TTL", 10*time.Minute),
+	}
+}
+
+// StartBind issues a short-lived token for the user and the bot deep link carrying it as the start parameter
+func (s *telegramService) StartBind(userId string) (*models.TelegramBindResponse, error) {
+
+	token, err := utils.GenerateToken(24)
+	if err != nil {
+		slog.Error("Failed to generate Telegram bind token", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	expiresAt := time.Now().UTC().Add(s.bindTokenTTL)
+	err = s.linkStateRepository.Insert(&models.LinkState{
+		State:     token,
+		UserId:    userId,
+		Provider:  constants.Telegram.String(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		slog.Error("Failed to start Telegram bind", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Started Telegram bind", "userId", userId)
+	return &models.TelegramBindResponse{
+		Token:     token,
+		URL:       "https://t.me/" + url.PathEscape(s.botUsername) + "?start=" + token,
+		ExpiresAt: expiresAt,
+	}, nil
+
+}
+
+// CompleteBind is called with the token the bot received in the /start update and the chat it came from
+func (s *telegramService) CompleteBind(actor *models.Actor, token string, chatId int64) error {
+
+	linkState, err := s.linkStateRepository.Consume(token, constants.Telegram.String())
+	if err != nil {
+		slog.Error("Failed to complete Telegram bind, invalid token", "error", err, "chatId", chatId)
+		return err
+	}
+
+	_, err = s.changes.update(actor, linkState.UserId, func(ctx context.Context) (*models.User, error) {
+		return s.userRepository.UpdateTelegramChatId(ctx, linkState.UserId, chatId)
+	}, "telegramChatId", "notificationInterfaces")
+	if err != nil {
+		slog.Error("Failed to complete Telegram bind", "error", err, "userId", linkState.UserId, "chatId", chatId)
+		return err
+	}
+
+	slog.Debug("Completed Telegram bind", "userId", linkState.UserId, "chatId", chatId)
+	return nil
+
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+)
+
+// userChanges writes a change to a user together with the user event announcing it
+// and the audit entries recording who made it, all in the same transaction
+type userChanges struct {
+	userRepository        repositories.UserRepository
+	outboxRepository      repositories.OutboxRepository
+	auditRepository       repositories.AuditRepository
+	transactionRepository repositories.TransactionRepository
+}
+
+func newUserChanges(userRepository repositories.UserRepository, outboxRepository repositories.OutboxRepository, auditRepository repositories.AuditRepository, transactionRepository repositories.TransactionRepository) *userChanges {
+	return &userChanges{
+		userRepository:        userRepository,
+		outboxRepository:      outboxRepository,
+		auditRepository:       auditRepository,
+		transactionRepository: transactionRepository,
+	}
+}
+
+// update runs update and records the given fields, by their json name, of the updated user as changed
+func (c *userChanges) update(actor *models.Actor, userId string, update func(ctx context.Context) (*models.User, error), fields ...string) (*models.User, error) {
+
+	var user *models.User
+	err := c.transactionRepository.WithTransaction(func(ctx context.Context) error {
+		previous, err := c.userRepository.FindForUpdate(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		user, err = update(ctx)
+		if err != nil {
+			return err
+		}
+
+		return c.record(ctx, actor, constants.UserUpdated, previous, user, fields...)
+	})
+
+	return user, err
+
+}
+
+// record stores the event and the audit entries of a change made in the transaction of ctx, previous is nil for a new user.
+// The event carries every given field while only the fields that actually changed are audited.
+func (c *userChanges) record(ctx context.Context, actor *models.Actor, eventType constants.UserEventType, previous *models.User, current *models.User, fields ...string) error {
+
+	currentFields := userEventFields(current, fields...)
+	err := insertUserEvent(ctx, c.outboxRepository, actor.RequestId, eventType, current.UserId, currentFields)
+	if err != nil {
+		return err
+	}
+
+	previousFields := map[string]interface{}{}
+	if previous != nil {
+		previousFields = userEventFields(previous, fields...)
+	}
+
+	return c.auditValues(ctx, actor, current.UserId, previousFields, currentFields, fields...)
+
+}
+
+// auditValues appends an audit entry for each of the given fields whose value differs between previous and current
+func (c *userChanges) auditValues(ctx context.Context, actor *models.Actor, userId string, previous map[string]interface{}, current map[string]interface{}, fields ...string) error {
+
+	entries := make([]models.AuditEntry, 0, len(fields))
+	for _, field := range fields {
+		if reflect.DeepEqual(previous[field], current[field]) {
+			continue
+		}
+		entries = append(entries, newAuditEntry(actor, userId, field, previous[field], current[field]))
+	}
+
+	return c.auditRepository.Insert(ctx, entries)
+
+}
+
+// audit appends a single audit entry for changes that are not a field of the user, like a pending verification
+func (c *userChanges) audit(ctx context.Context, actor *models.Actor, userId string, field string, oldValue interface{}, newValue interface{}) error {
+	return c.auditRepository.Insert(ctx, []models.AuditEntry{newAuditEntry(actor, userId, field, oldValue, newValue)})
+}
+
+func newAuditEntry(actor *models.Actor, userId string, field string, oldValue interface{}, newValue interface{}) models.AuditEntry {
+	return models.AuditEntry{
+		UserId:    userId,
+		Actor:     *actor,
+		Field:     field,
+		OldValue:  maskAuditValue(oldValue),
+		NewValue:  maskAuditValue(newValue),
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// maskAuditValue hides contact details and tokens while keeping enough of them to tell values apart.
+// Flags, timestamps and the notification settings, which only hold known names, are kept as they are.
+func maskAuditValue(value interface{}) interface{} {
+
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case string:
+		if value == "" {
+			return nil
+		}
+		return maskString(value)
+	case int64:
+		if value == 0 {
+			return nil
+		}
+		return maskString(strconv.FormatInt(value, 10))
+	case []models.FCMtoken:
+		tokens := make([]string, len(value))
+		for i, token := range value {
+			tokens[i] = maskString(token.Token)
+		}
+		return tokens
+	case bool, time.Time, []string, map[string][]string:
+		return value
+	default:
+		return "****"
+	}
+
+}
+
+// maskString keeps the first character and the domain of an email address and the last four characters of anything else
+func maskString(value string) string {
+
+	if at := strings.LastIndex(value, "@"); at > 0 {
+		local := []rune(value[:at])
+		return string(local[0]) + strings.Repeat("*", len(local)-1) + value[at:]
+	}
+
+	runes := []rune(value)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+
+	return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+
+}
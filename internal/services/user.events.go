@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/producers"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+)
+
+// userEventFields picks the given fields, by their json name, out of the user so they can be sent as changed fields
+func userEventFields(user *models.User, fields ...string) map[string]interface{} {
+
+	var deletedAt interface{}
+	if !user.DeletedAt.IsZero() {
+		deletedAt = user.DeletedAt
+	}
+
+	values := map[string]interface{}{
+		"email":                   user.Email,
+		"emailVerified":           user.EmailVerified,
+		"displayName":             user.DisplayName,
+		"photoURL":                user.PhotoURL,
+		"notificationInterfaces":  user.NotificationInterfaces,
+		"notificationPreferences": user.NotificationPreferences,
+		"fcmTokens":               user.FCMtokens,
+		"whatsAppNumber":          user.WhatsAppNumber,
+		"discordId":               user.DiscordId,
+		"discordUsername":         user.DiscordUsername,
+		"telegramNumber":          user.TelegramNumber,
+		"telegramChatId":          user.TelegramChatId,
+		"deletedAt":               deletedAt,
+	}
+
+	changedFields := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		changedFields[field] = values[field]
+	}
+
+	return changedFields
+
+}
+
+// profileFields are refreshed from the Firebase claims on every login
+var profileFields = []string{"email", "emailVerified", "displayName", "photoURL"}
+
+// changedProfileFields lists the profile fields that differ between the user before and after a login
+func changedProfileFields(previous *models.User, current *models.User) []string {
+
+	previousFields := userEventFields(previous, profileFields...)
+	currentFields := userEventFields(current, profileFields...)
+
+	changedFields := []string{}
+	for _, field := range profileFields {
+		if previousFields[field] != currentFields[field] {
+			changedFields = append(changedFields, field)
+		}
+	}
+
+	return changedFields
+
+}
+
+// insertUserEvent stores the event in the outbox, ctx must be the transaction context of the change it describes.
+// The correlation id ties the event to the request that caused it, jobs leave it empty.
+func insertUserEvent(ctx context.Context, outboxRepository repositories.OutboxRepository, correlationId string, eventType constants.UserEventType, userId string, changedFields map[string]interface{}) error {
+
+	message, err := producers.NewUserEventMessage(eventType, userId, changedFields)
+	if err != nil {
+		return err
+	}
+	message.CorrelationId = correlationId
+
+	return outboxRepository.Insert(ctx, message)
+
+}
@@ -1,72 +1,160 @@
 package services
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/producers"
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/exp/slog"
 )
 
 type UserService interface {
-	UpsertUser(userId string) (bool, error)
+	UpsertUser(actor *models.Actor, identity *models.FirebaseIdentity) (bool, error)
 
 	GetUser(userId string) (*models.User, error)
 
-	EditWhatsAppNumber(userId string, whatsAppNumber string) error
+	EditWhatsAppNumber(actor *models.Actor, userId string, whatsAppNumber string) (*models.ChannelVerification, error)
+	ConfirmWhatsAppNumber(actor *models.Actor, userId string, code string) error
 	GetWhatsAppNumber(userId string) (string, error)
 
-	EditDiscordId(userId string, discordId string) error
-	GetDiscordId(userId string) (string, error)
+	GetDiscordAccount(userId string) (string, string, error)
 
-	EditTelegramNumber(userId string, telegramNumber string) error
+	EditTelegramNumber(actor *models.Actor, userId string, telegramNumber string) (*models.ChannelVerification, error)
+	ConfirmTelegramNumber(actor *models.Actor, userId string, code string) error
 	GetTelegramNumber(userId string) (string, error)
 
-	EditNotificationInterfaces(userId string, notificationInterfaces []string) error
+	EditNotificationInterfaces(actor *models.Actor, userId string, notificationInterfaces []string) error
 	GetNotificationInterfaces(userId string) ([]string, error)
 
-	AddFCMtoken(userId string, FCMtoken string) error
-	DeleteFCMtoken(userId string, FCMtoken string) error
-	GetFCMtokens(userId string) ([]string, error)
-
-	EditWebhooks(userId string, webhooks []string) error
-	GetWebhooks(userId string) ([]string, error)
+	EditNotificationPreferences(actor *models.Actor, userId string, notificationPreferences map[string][]string) error
+	GetNotificationPreferences(userId string) (map[string][]string, error)
+	ResolveNotificationInterfaces(userId string, eventType string) ([]string, error)
+	ResolveDeliveryTargets(userId string, eventType string) (*models.DeliveryTargets, error)
 
-	DeleteUser(userId string) error
+	AddFCMtoken(actor *models.Actor, userId string, request *models.FCMtokenRequest) error
+	DeleteFCMtoken(actor *models.Actor, userId string, FCMtoken string) error
+	GetFCMtokens(userId string) ([]models.FCMtoken, error)
+	RemoveUnregisteredFCMtoken(userId string, FCMtoken string) error
+	PruneStaleFCMtokens(limit int) (int, error)
 }
 
+var (
+	ErrInvalidNotificationEventType = errors.New("invalid notification event type")
+	ErrNoPendingVerification        = errors.New("no pending verification")
+	ErrVerificationCodeExpired      = errors.New("verification code expired")
+	ErrInvalidVerificationCode      = errors.New("invalid verification code")
+	ErrTooManyVerificationAttempts  = errors.New("too many verification attempts, try again later")
+	ErrVerificationResendTooSoon    = errors.New("a verification code was sent recently, wait before requesting another")
+	ErrMissingVerificationSecret    = errors.New("VERIFICATION_CODE_SECRET is not set")
+	ErrUserErased                   = errors.New("user has been erased")
+)
+
 type userService struct {
-	userRepository repositories.UserRepository
-	producer       producers.WelcomeProducer
+	userRepository          repositories.UserRepository
+	webhookRepository       repositories.WebhookRepository
+	outboxRepository        repositories.OutboxRepository
+	erasureRepository       repositories.ErasureRepository
+	transactionRepository   repositories.TransactionRepository
+	changes                 *userChanges
+	verificationCodeSecret  string
+	verificationCodeTTL     time.Duration
+	verificationCooldown    time.Duration
+	verificationWindow      time.Duration
+	maxVerificationAttempts int
+	maxFCMtokens            int
+	fcmTokenStaleAfter      time.Duration
 }
 
-func NewUserService(userRepository repositories.UserRepository, producer producers.WelcomeProducer) UserService {
+func NewUserService(userRepository repositories.UserRepository, webhookRepository repositories.WebhookRepository, outboxRepository repositories.OutboxRepository, auditRepository repositories.AuditRepository, erasureRepository repositories.ErasureRepository, transactionRepository repositories.TransactionRepository) UserService {
+
+	verificationCodeSecret := os.Getenv("VERIFICATION_CODE_SECRET")
+	if verificationCodeSecret == "" {
+		slog.Error("Failed to set up user service", "error", ErrMissingVerificationSecret)
+		panic(ErrMissingVerificationSecret)
+	}
+
 	return &userService{
-		userRepository: userRepository,
-		producer:       producer,
+		userRepository:          userRepository,
+		webhookRepository:       webhookRepository,
+		outboxRepository:        outboxRepository,
+		erasureRepository:       erasureRepository,
+		transactionRepository:   transactionRepository,
+		changes:                 newUserChanges(userRepository, outboxRepository, auditRepository, transactionRepository),
+		verificationCodeSecret:  verificationCodeSecret,
+		verificationCodeTTL:     config.GetEnvDuration("VERIFICATION_CODE_TTL", 10*time.Minute),
+		verificationCooldown:    config.GetEnvDuration("VERIFICATION_RESEND_COOLDOWN", time.Minute),
+		verificationWindow:      config.GetEnvDuration("VERIFICATION_ATTEMPTS_WINDOW", 24*time.Hour),
+		maxVerificationAttempts: config.GetEnvInt("VERIFICATION_MAX_ATTEMPTS", 5),
+		maxFCMtokens:            config.GetEnvInt("FCM_TOKEN_LIMIT", 10),
+		fcmTokenStaleAfter:      config.GetEnvDuration("FCM_TOKEN_STALE_AFTER", 60*24*time.Hour),
 	}
+
 }
 
-func (s *userService) UpsertUser(userId string) (bool, error) {
+// UpsertUser records a login, refreshing the profile from the caller's Firebase claims.
+// An erased user is not recreated, their ID token stays valid for up to an hour after the Firebase account is gone.
+func (s *userService) UpsertUser(actor *models.Actor, identity *models.FirebaseIdentity) (bool, error) {
 
+	userId := identity.UID
 	user := &models.User{
-		UserId: userId,
+		UserId:         userId,
+		Email:          identity.Email,
+		EmailVerified:  identity.EmailVerified,
+		DisplayName:    identity.DisplayName,
+		PhotoURL:       identity.PhotoURL,
+		SignInProvider: identity.SignInProvider,
+		CustomClaims:   identity.CustomClaims,
 		NotificationInterfaces: []string{
 			constants.Email.String(),
 		},
 	}
-	isUpserted, err := s.userRepository.Upsert(user)
-	if err != nil {
-		slog.Error("Failed to upsert user", "error", err, "userId", userId)
-		return false, err
-	}
 
-	if isUpserted {
-		err := s.producer.Publish(userId)
+	// the welcome message is stored in the outbox together with the user, the relay publishes it later
+	var isUpserted bool
+	err := s.transactionRepository.WithTransaction(func(ctx context.Context) error {
+		isErased, err := s.erasureRepository.IsErased(ctx, userId)
+		if err != nil {
+			return err
+		}
+		if isErased {
+			return ErrUserErased
+		}
+
+		previous, err := s.userRepository.Upsert(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		isUpserted = previous == nil
+		if !isUpserted {
+			changedFields := changedProfileFields(previous, user)
+			if len(changedFields) == 0 {
+				return nil
+			}
+			return s.changes.record(ctx, actor, constants.UserUpdated, previous, user, changedFields...)
+		}
+
+		err = s.changes.record(ctx, actor, constants.UserCreated, nil, user, append(profileFields, "notificationInterfaces")...)
 		if err != nil {
-			slog.Error("Failed to publish welcome message", "error", err, "userId", userId)
-			return false, err
+			return err
 		}
+
+		message := producers.NewWelcomeMessage(userId)
+		message.CorrelationId = actor.RequestId
+		return s.outboxRepository.Insert(ctx, message)
+	})
+	if err != nil {
+		slog.Error("Failed to upsert user", "error", err, "userId", userId)
+		return false, err
 	}
 
 	slog.Debug("Upserted user", "userId", userId, "isUpserted", isUpserted)
@@ -87,15 +175,31 @@ func (s *userService) GetUser(userId string) (*models.User, error) {
 
 }
 
-func (s *userService) EditWhatsAppNumber(userId string, whatsAppNumber string) error {
+// EditWhatsAppNumber starts the verification of the number, it only replaces the current number once confirmed
+func (s *userService) EditWhatsAppNumber(actor *models.Actor, userId string, whatsAppNumber string) (*models.ChannelVerification, error) {
 
-	err := s.userRepository.UpdateWhatsAppNumber(userId, whatsAppNumber)
+	verification, err := s.requestChannelVerification(actor, userId, constants.WhatsApp, whatsAppNumber)
 	if err != nil {
 		slog.Error("Failed to edit WhatsApp number", "error", err, "userId", userId, "whatsAppNumber", whatsAppNumber)
-		return err
+		return nil, err
 	}
 
 	slog.Debug("Edited WhatsApp number", "userId", userId, "whatsAppNumber", whatsAppNumber)
+	return verification, nil
+
+}
+
+func (s *userService) ConfirmWhatsAppNumber(actor *models.Actor, userId string, code string) error {
+
+	err := s.confirmChannelVerification(actor, userId, constants.WhatsApp, code, func(ctx context.Context, whatsAppNumber string) (*models.User, error) {
+		return s.userRepository.UpdateWhatsAppNumber(ctx, userId, whatsAppNumber)
+	}, "whatsAppNumber", "notificationInterfaces")
+	if err != nil {
+		slog.Error("Failed to confirm WhatsApp number", "error", err, "userId", userId)
+		return err
+	}
+
+	slog.Debug("Confirmed WhatsApp number", "userId", userId)
 	return nil
 
 }
@@ -113,41 +217,44 @@ func (s *userService) GetWhatsAppNumber(userId string) (string, error) {
 
 }
 
-func (s *userService) EditDiscordId(userId string, discordId string) error {
+func (s *userService) GetDiscordAccount(userId string) (string, string, error) {
 
-	err := s.userRepository.UpdateDiscordId(userId, discordId)
+	discordId, discordUsername, err := s.userRepository.FindDiscordAccount(userId)
 	if err != nil {
-		slog.Error("Failed to edit Discord ID", "error", err, "userId", userId, "discordId", discordId)
-		return err
+		slog.Error("Failed to get Discord account", "error", err, "userId", userId)
+		return "", "", err
 	}
 
-	slog.Debug("Edited Discord ID", "userId", userId, "discordId", discordId)
-	return nil
+	slog.Debug("Got Discord account", "userId", userId)
+	return discordId, discordUsername, nil
 
 }
 
-func (s *userService) GetDiscordId(userId string) (string, error) {
+// EditTelegramNumber starts the verification of the number, it only replaces the current number once confirmed
+func (s *userService) EditTelegramNumber(actor *models.Actor, userId string, telegramNumber string) (*models.ChannelVerification, error) {
 
-	discordId, err := s.userRepository.FindDiscordId(userId)
+	verification, err := s.requestChannelVerification(actor, userId, constants.Telegram, telegramNumber)
 	if err != nil {
-		slog.Error("Failed to get Discord ID", "error", err, "userId", userId)
-		return "", err
+		slog.Error("Failed to edit Telegram number", "error", err, "userId", userId, "telegramNumber", telegramNumber)
+		return nil, err
 	}
 
-	slog.Debug("Got Discord ID", "userId", userId)
-	return discordId, nil
+	slog.Debug("Edited Telegram number", "userId", userId, "telegramNumber", telegramNumber)
+	return verification, nil
 
 }
 
-func (s *userService) EditTelegramNumber(userId string, telegramNumber string) error {
+func (s *userService) ConfirmTelegramNumber(actor *models.Actor, userId string, code string) error {
 
-	err := s.userRepository.UpdateTelegramNumber(userId, telegramNumber)
+	err := s.confirmChannelVerification(actor, userId, constants.Telegram, code, func(ctx context.Context, telegramNumber string) (*models.User, error) {
+		return s.userRepository.UpdateTelegramNumber(ctx, userId, telegramNumber)
+	}, "telegramNumber", "notificationInterfaces")
 	if err != nil {
-		slog.Error("Failed to edit Telegram number", "error", err, "userId", userId, "telegramNumber", telegramNumber)
+		slog.Error("Failed to confirm Telegram number", "error", err, "userId", userId)
 		return err
 	}
 
-	slog.Debug("Edited Telegram number", "userId", userId, "telegramNumber", telegramNumber)
+	slog.Debug("Confirmed Telegram number", "userId", userId)
 	return nil
 
 }
@@ -165,9 +272,11 @@ func (s *userService) GetTelegramNumber(userId string) (string, error) {
 
 }
 
-func (s *userService) EditNotificationInterfaces(userId string, notificationInterfaces []string) error {
+func (s *userService) EditNotificationInterfaces(actor *models.Actor, userId string, notificationInterfaces []string) error {
 
-	err := s.userRepository.UpdateNotificationInterfaces(userId, notificationInterfaces)
+	err := s.updateUser(actor, userId, func(ctx context.Context) (*models.User, error) {
+		return s.userRepository.UpdateNotificationInterfaces(ctx, userId, notificationInterfaces)
+	}, "notificationInterfaces")
 	if err != nil {
 		slog.Error("Failed to edit notification Interfaces", "error", err, "userId", userId, "notificationInterfaces", notificationInterfaces)
 		return err
@@ -191,22 +300,119 @@ func (s *userService) GetNotificationInterfaces(userId string) ([]string, error)
 
 }
 
-func (s *userService) AddFCMtoken(userId string, FCMtoken string) error {
+func (s *userService) EditNotificationPreferences(actor *models.Actor, userId string, notificationPreferences map[string][]string) error {
 
-	err := s.userRepository.InsertFCMtoken(userId, FCMtoken)
+	err := s.updateUser(actor, userId, func(ctx context.Context) (*models.User, error) {
+		return s.userRepository.UpdateNotificationPreferences(ctx, userId, notificationPreferences)
+	}, "notificationPreferences")
 	if err != nil {
-		slog.Error("Failed to add FCM token", "error", err, "userId", userId, "FCMtoken", FCMtoken)
+		slog.Error("Failed to edit notification Preferences", "error", err, "userId", userId, "notificationPreferences", notificationPreferences)
 		return err
 	}
 
-	slog.Debug("Added FCM token", "userId", userId, "FCMtoken", FCMtoken)
+	slog.Debug("Edited notification Preferences", "userId", userId, "notificationPreferences", notificationPreferences)
 	return nil
 
 }
 
-func (s *userService) DeleteFCMtoken(userId string, FCMtoken string) error {
+func (s *userService) GetNotificationPreferences(userId string) (map[string][]string, error) {
+
+	notificationPreferences, err := s.userRepository.FindNotificationPreferences(userId)
+	if err != nil {
+		slog.Error("Failed to get notification Preferences", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Got notification Preferences", "userId", userId)
+	return notificationPreferences, nil
+
+}
+
+func (s *userService) ResolveNotificationInterfaces(userId string, eventType string) ([]string, error) {
+
+	if _, ok := constants.GetNotificationEventTypeSet()[constants.NotificationEventType(eventType)]; !ok {
+		slog.Error("Failed to resolve notification Interfaces", "error", ErrInvalidNotificationEventType, "userId", userId, "eventType", eventType)
+		return nil, ErrInvalidNotificationEventType
+	}
 
-	err := s.userRepository.RemoveFCMtoken(userId, FCMtoken)
+	user, err := s.userRepository.FindByUserId(userId)
+	if err != nil {
+		slog.Error("Failed to resolve notification Interfaces", "error", err, "userId", userId, "eventType", eventType)
+		return nil, err
+	}
+
+	notificationInterfaces := resolveNotificationInterfaces(user, constants.NotificationEventType(eventType))
+
+	slog.Debug("Resolved notification Interfaces", "userId", userId, "eventType", eventType, "notificationInterfaces", notificationInterfaces)
+	return notificationInterfaces, nil
+
+}
+
+func (s *userService) ResolveDeliveryTargets(userId string, eventType string) (*models.DeliveryTargets, error) {
+
+	if _, ok := constants.GetNotificationEventTypeSet()[constants.NotificationEventType(eventType)]; !ok {
+		slog.Error("Failed to resolve delivery targets", "error", ErrInvalidNotificationEventType, "userId", userId, "eventType", eventType)
+		return nil, ErrInvalidNotificationEventType
+	}
+
+	user, err := s.userRepository.FindByUserId(userId)
+	if err != nil {
+		slog.Error("Failed to resolve delivery targets", "error", err, "userId", userId, "eventType", eventType)
+		return nil, err
+	}
+
+	webhooks, err := s.webhookRepository.FindByUserId(userId)
+	if err != nil {
+		slog.Error("Failed to resolve delivery targets", "error", err, "userId", userId, "eventType", eventType)
+		return nil, err
+	}
+
+	deliveryTargets := &models.DeliveryTargets{
+		UserId:                 userId,
+		EventType:              eventType,
+		NotificationInterfaces: []string{},
+	}
+
+	for _, notificationInterface := range resolveNotificationInterfaces(user, constants.NotificationEventType(eventType)) {
+		if !addDeliveryTarget(deliveryTargets, user, webhooks, constants.NotificationInterface(notificationInterface)) {
+			continue
+		}
+		deliveryTargets.NotificationInterfaces = append(deliveryTargets.NotificationInterfaces, notificationInterface)
+	}
+
+	slog.Debug("Resolved delivery targets", "userId", userId, "eventType", eventType, "notificationInterfaces", deliveryTargets.NotificationInterfaces)
+	return deliveryTargets, nil
+
+}
+
+// AddFCMtoken registers the token or, when it is already registered, refreshes it along with its device metadata
+func (s *userService) AddFCMtoken(actor *models.Actor, userId string, request *models.FCMtokenRequest) error {
+
+	FCMtoken := &models.FCMtoken{
+		Token:       request.FCMtoken,
+		DeviceLabel: request.DeviceLabel,
+		Platform:    request.Platform,
+		UserAgent:   truncate(request.UserAgent, 512),
+	}
+
+	err := s.updateUser(actor, userId, func(ctx context.Context) (*models.User, error) {
+		return s.userRepository.RefreshFCMtoken(ctx, userId, FCMtoken, s.maxFCMtokens)
+	}, "fcmTokens", "notificationInterfaces")
+	if err != nil {
+		slog.Error("Failed to add FCM token", "error", err, "userId", userId, "FCMtoken", FCMtoken.Token)
+		return err
+	}
+
+	slog.Debug("Added FCM token", "userId", userId, "FCMtoken", FCMtoken.Token)
+	return nil
+
+}
+
+func (s *userService) DeleteFCMtoken(actor *models.Actor, userId string, FCMtoken string) error {
+
+	err := s.updateUser(actor, userId, func(ctx context.Context) (*models.User, error) {
+		return s.userRepository.RemoveFCMtoken(ctx, userId, FCMtoken)
+	}, "fcmTokens")
 	if err != nil {
 		slog.Error("Failed to delete FCM token", "error", err, "userId", userId, "FCMtoken", FCMtoken)
 		return err
@@ -217,7 +423,7 @@ func (s *userService) DeleteFCMtoken(userId string, FCMtoken string) error {
 
 }
 
-func (s *userService) GetFCMtokens(userId string) ([]string, error) {
+func (s *userService) GetFCMtokens(userId string) ([]models.FCMtoken, error) {
 
 	FCMtokens, err := s.userRepository.FindFCMtokens(userId)
 	if err != nil {
@@ -230,41 +436,181 @@ func (s *userService) GetFCMtokens(userId string) ([]string, error) {
 
 }
 
-func (s *userService) EditWebhooks(userId string, webhooks []string) error {
+// RemoveUnregisteredFCMtoken drops a token the push sender could no longer deliver to.
+// The user is looked up by the token when the report does not name one, a token nobody holds is ignored.
+func (s *userService) RemoveUnregisteredFCMtoken(userId string, FCMtoken string) error {
 
-	err := s.userRepository.UpdateWebhooks(userId, webhooks)
+	if userId == "" {
+		var err error
+		userId, err = s.userRepository.FindUserIdByFCMtoken(FCMtoken)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			slog.Debug("Unregistered FCM token already removed", "FCMtoken", FCMtoken)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.DeleteFCMtoken(utils.NewSystemActor("fcm-token-error-consumer"), userId, FCMtoken)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		slog.Debug("Unregistered FCM token belongs to no user", "userId", userId, "FCMtoken", FCMtoken)
+		return nil
+	}
+
+	return err
+
+}
+
+// PruneStaleFCMtokens removes the tokens not refreshed within FCM_TOKEN_STALE_AFTER from up to limit users,
+// returning how many users it pruned so the caller knows when it is done
+func (s *userService) PruneStaleFCMtokens(limit int) (int, error) {
+
+	refreshedBefore := time.Now().UTC().Add(-s.fcmTokenStaleAfter)
+
+	userIds, err := s.userRepository.FindUserIdsWithStaleFCMtokens(refreshedBefore, limit)
 	if err != nil {
-		slog.Error("Failed to edit Webhooks", "error", err, "userId", userId, "webhooks", webhooks)
-		return err
+		slog.Error("Failed to find stale FCM tokens", "error", err)
+		return 0, err
 	}
 
-	slog.Debug("Edited Webhooks", "userId", userId, "webhooks", webhooks)
-	return nil
+	actor := utils.NewSystemActor("fcm-token-prune")
+	for i, userId := range userIds {
+		err := s.updateUser(actor, userId, func(ctx context.Context) (*models.User, error) {
+			return s.userRepository.RemoveStaleFCMtokens(ctx, userId, refreshedBefore)
+		}, "fcmTokens")
+		if err != nil {
+			slog.Error("Failed to prune stale FCM tokens", "error", err, "userId", userId)
+			return i, err
+		}
+	}
+
+	slog.Debug("Pruned stale FCM tokens", "users", len(userIds), "refreshedBefore", refreshedBefore)
+	return len(userIds), nil
 
 }
 
-func (s *userService) GetWebhooks(userId string) ([]string, error) {
+// updateUser runs update and records a user.updated event and the audit entries of the given fields in the same transaction
+func (s *userService) updateUser(actor *models.Actor, userId string, update func(ctx context.Context) (*models.User, error), fields ...string) error {
+
+	_, err := s.changes.update(actor, userId, update, fields...)
+	return err
+
+}
+
+// requestChannelVerification stores a pending verification for the number and, in the same transaction,
+// queues the message asking for the code to be sent to it. A new code is only sent once the cooldown since the last one
+// is over, and the attempts of a pending verification carry over to the new code until its window has passed,
+// so neither sending codes nor guessing them can be repeated by editing the number again.
+func (s *userService) requestChannelVerification(actor *models.Actor, userId string, channel constants.NotificationInterface, number string) (*models.ChannelVerification, error) {
+
+	code, err := utils.GenerateVerificationCode(6)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	verification := &models.ChannelVerification{
+		Number:      number,
+		Status:      constants.VerificationPending.String(),
+		CodeHash:    utils.HashVerificationCode(s.verificationCodeSecret, userId, channel.String(), code),
+		Attempts:    0,
+		ExpiresAt:   now.Add(s.verificationCodeTTL),
+		RequestedAt: now,
+	}
+
+	err = s.transactionRepository.WithTransaction(func(ctx context.Context) error {
+		previous, err := s.userRepository.FindForUpdate(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		var previousNumber interface{}
+		if previousVerification, ok := previous.ChannelVerifications[channel.String()]; ok {
+			previousNumber = previousVerification.Number
+
+			if now.Before(previousVerification.RequestedAt.Add(s.verificationCooldown)) {
+				return ErrVerificationResendTooSoon
+			}
 
-	webhooks, err := s.userRepository.FindWebhooks(userId)
+			if previousVerification.Status == constants.VerificationPending.String() && now.Before(previousVerification.RequestedAt.Add(s.verificationWindow)) {
+				if previousVerification.Attempts >= s.maxVerificationAttempts {
+					return ErrTooManyVerificationAttempts
+				}
+				verification.Attempts = previousVerification.Attempts
+			}
+		}
+
+		err = s.userRepository.UpdateChannelVerification(ctx, userId, channel.String(), verification)
+		if err != nil {
+			return err
+		}
+
+		err = s.changes.audit(ctx, actor, userId, "channelVerifications."+channel.String()+".number", previousNumber, number)
+		if err != nil {
+			return err
+		}
+
+		message, err := producers.NewVerificationCodeMessage(userId, channel.String(), number, code, verification.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		message.CorrelationId = actor.RequestId
+
+		return s.outboxRepository.Insert(ctx, message)
+	})
 	if err != nil {
-		slog.Error("Failed to get Webhooks", "error", err, "userId", userId)
 		return nil, err
 	}
 
-	slog.Debug("Got Webhooks", "userId", userId)
-	return webhooks, nil
+	return verification, nil
 
 }
 
-func (s *userService) DeleteUser(userId string) error {
+// confirmChannelVerification checks the code against the pending verification and, when it matches,
+// marks the channel verified and hands the number to confirm to store it as the channel's target
+func (s *userService) confirmChannelVerification(actor *models.Actor, userId string, channel constants.NotificationInterface, code string, confirm func(ctx context.Context, number string) (*models.User, error), fields ...string) error {
 
-	err := s.userRepository.Delete(userId)
+	user, err := s.userRepository.FindByUserId(userId)
 	if err != nil {
-		slog.Error("Failed to delete user", "error", err, "userId", userId)
 		return err
 	}
 
-	slog.Debug("Deleted user", "userId", userId)
-	return nil
+	verification, ok := user.ChannelVerifications[channel.String()]
+	if !ok || verification.Status != constants.VerificationPending.String() {
+		return ErrNoPendingVerification
+	}
+
+	if time.Now().After(verification.ExpiresAt) {
+		return ErrVerificationCodeExpired
+	}
+
+	// every check uses up an attempt before comparing, so parallel guesses cannot exceed the limit
+	err = s.userRepository.IncrementChannelVerificationAttempts(userId, channel.String(), s.maxVerificationAttempts)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrTooManyVerificationAttempts
+	}
+	if err != nil {
+		return err
+	}
+
+	codeHash := utils.HashVerificationCode(s.verificationCodeSecret, userId, channel.String(), code)
+	if subtle.ConstantTimeCompare([]byte(codeHash), []byte(verification.CodeHash)) != 1 {
+		return ErrInvalidVerificationCode
+	}
+
+	err = s.updateUser(actor, userId, func(ctx context.Context) (*models.User, error) {
+		err := s.userRepository.MarkChannelVerified(ctx, userId, channel.String(), verification.Number, codeHash)
+		if err != nil {
+			return nil, err
+		}
+
+		return confirm(ctx, verification.Number)
+	}, fields...)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNoPendingVerification
+	}
+
+	return err
 
 }
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+)
+
+// fakeTransactionRepository runs fn right away, the fakes below keep everything in memory
+type fakeTransactionRepository struct{}
+
+func (fakeTransactionRepository) WithTransaction(fn func(ctx context.Context) error) error {
+	return fn(context.Background())
+}
+
+type fakeOutboxRepository struct {
+	repositories.OutboxRepository
+	messages []*models.OutboxMessage
+}
+
+func (r *fakeOutboxRepository) Insert(ctx context.Context, message *models.OutboxMessage) error {
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func (r *fakeOutboxRepository) DeleteByUserId(ctx context.Context, userId string) (int64, error) {
+	kept := []*models.OutboxMessage{}
+	for _, message := range r.messages {
+		if message.UserId != userId {
+			kept = append(kept, message)
+		}
+	}
+	deleted := int64(len(r.messages) - len(kept))
+	r.messages = kept
+	return deleted, nil
+}
+
+type fakeAuditRepository struct {
+	repositories.AuditRepository
+	entries []models.AuditEntry
+}
+
+func (r *fakeAuditRepository) Insert(ctx context.Context, entries []models.AuditEntry) error {
+	r.entries = append(r.entries, entries...)
+	return nil
+}
+
+func (r *fakeAuditRepository) DeleteByUserId(ctx context.Context, userId string) (int64, error) {
+	kept := []models.AuditEntry{}
+	for _, entry := range r.entries {
+		if entry.UserId != userId {
+			kept = append(kept, entry)
+		}
+	}
+	deleted := int64(len(r.entries) - len(kept))
+	r.entries = kept
+	return deleted, nil
+}
+
+type fakeUserRepository struct {
+	repositories.UserRepository
+	user *models.User
+}
+
+func (r *fakeUserRepository) FindForUpdate(ctx context.Context, userId string) (*models.User, error) {
+	user := *r.user
+	return &user, nil
+}
+
+func (r *fakeUserRepository) UpdateChannelVerification(ctx context.Context, userId string, channel string, verification *models.ChannelVerification) error {
+	if r.user.ChannelVerifications == nil {
+		r.user.ChannelVerifications = map[string]*models.ChannelVerification{}
+	}
+	r.user.ChannelVerifications[channel] = verification
+	return nil
+}
+
+func newTestUserService(user *models.User) (*userService, *fakeOutboxRepository) {
+
+	userRepository := &fakeUserRepository{user: user}
+	outboxRepository := &fakeOutboxRepository{}
+	auditRepository := &fakeAuditRepository{}
+	transactionRepository := fakeTransactionRepository{}
+
+	return &userService{
+		userRepository:          userRepository,
+		outboxRepository:        outboxRepository,
+		transactionRepository:   transactionRepository,
+		changes:                 newUserChanges(userRepository, outboxRepository, auditRepository, transactionRepository),
+		verificationCodeSecret:  "secret",
+		verificationCodeTTL:     10 * time.Minute,
+		verificationCooldown:    time.Minute,
+		verificationWindow:      24 * time.Hour,
+		maxVerificationAttempts: 5,
+	}, outboxRepository
+
+}
+
+func TestRequestChannelVerification(t *testing.T) {
+
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name     string
+		previous *models.ChannelVerification
+		err      error
+		attempts int
+	}{
+		{
+			name:     "first request",
+			attempts: 0,
+		},
+		{
+			name:     "resend within the cooldown",
+			previous: &models.ChannelVerification{Status: constants.VerificationPending.String(), Attempts: 1, RequestedAt: now.Add(-10 * time.Second)},
+			err:      ErrVerificationResendTooSoon,
+		},
+		{
+			name:     "resend keeps the attempts",
+			previous: &models.ChannelVerification{Status: constants.VerificationPending.String(), Attempts: 3, RequestedAt: now.Add(-5 * time.Minute)},
+			attempts: 3,
+		},
+		{
+			name:     "resend with no attempts left",
+			previous: &models.ChannelVerification{Status: constants.VerificationPending.String(), Attempts: 5, RequestedAt: now.Add(-5 * time.Minute)},
+			err:      ErrTooManyVerificationAttempts,
+		},
+		{
+			name:     "resend after the window",
+			previous: &models.ChannelVerification{Status: constants.VerificationPending.String(), Attempts: 5, RequestedAt: now.Add(-25 * time.Hour)},
+			attempts: 0,
+		},
+		{
+			name:     "new number once verified",
+			previous: &models.ChannelVerification{Status: constants.VerificationVerified.String(), Attempts: 2, RequestedAt: now.Add(-5 * time.Minute)},
+			attempts: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			user := &models.User{UserId: "user-1"}
+			if test.previous != nil {
+				user.ChannelVerifications = map[string]*models.ChannelVerification{constants.WhatsApp.String(): test.previous}
+			}
+			service, outboxRepository := newTestUserService(user)
+
+			verification, err := service.requestChannelVerification(&models.Actor{}, "user-1", constants.WhatsApp, "+911234567890")
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if test.err != nil {
+				if len(outboxRepository.messages) != 0 {
+					t.Errorf("queued %d messages for a refused request", len(outboxRepository.messages))
+				}
+				return
+			}
+			if verification.Attempts != test.attempts {
+				t.Errorf("got %d attempts, want %d", verification.Attempts, test.attempts)
+			}
+			if len(outboxRepository.messages) != 1 {
+				t.Errorf("queued %d messages, want 1", len(outboxRepository.messages))
+			}
+		})
+	}
+
+}
+
+func TestHashVerificationCodeIsKeyed(t *testing.T) {
+
+	hash := utils.HashVerificationCode("secret", "user-1", "whatsApp", "123456")
+
+	if hash == utils.HashVerificationCode("other", "user-1", "whatsApp", "123456") {
+		t.Error("hash does not depend on the secret")
+	}
+	if hash != utils.HashVerificationCode("secret", "user-1", "whatsApp", "123456") {
+		t.Error("hash is not stable")
+	}
+
+}
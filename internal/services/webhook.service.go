@@ -0,0 +1,576 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/exp/slog"
+)
+
+type WebhookService interface {
+	CreateWebhook(actor *models.Actor, userId string, request *models.WebhookRequest) (*models.WebhookSecretResponse, error)
+	GetWebhooks(userId string) ([]models.Webhook, error)
+	GetWebhook(userId string, id string) (*models.Webhook, error)
+	UpdateWebhook(actor *models.Actor, userId string, id string, request *models.WebhookPatchRequest) (*models.Webhook, error)
+	DeleteWebhook(actor *models.Actor, userId string, id string) error
+	RotateSecret(actor *models.Actor, userId string, id string, overlap *time.Duration) (*models.WebhookSecretResponse, error)
+	RequestVerification(actor *models.Actor, userId string, id string) (*models.Webhook, error)
+	TestWebhook(userId string, id string) (*models.WebhookDelivery, error)
+	GetDeliveries(userId string, id string, query *models.WebhookDeliveriesQuery) (*models.WebhookDeliveriesResponse, error)
+}
+
+var (
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	ErrWebhookLimitReached = errors.New("webhook limit reached")
+	ErrWebhookNotVerified  = errors.New("webhook is not verified")
+	ErrWebhookTestTooSoon  = errors.New("webhook was tested recently, wait before testing it again")
+	ErrWebhookDisabled     = errors.New("webhook is disabled")
+)
+
+type webhookService struct {
+	userRepository            repositories.UserRepository
+	webhookRepository         repositories.WebhookRepository
+	webhookDeliveryRepository repositories.WebhookDeliveryRepository
+	outboxRepository          repositories.OutboxRepository
+	transactionRepository     repositories.TransactionRepository
+	changes                   *userChanges
+	webhookClient             config.WebhookClient
+	maxWebhooks               int
+	secretOverlap             time.Duration
+	deliveryTimeout           time.Duration
+	maxDeliveryBodyLength     int
+	testInterval              time.Duration
+}
+
+func NewWebhookService(userRepository repositories.UserRepository, webhookRepository repositories.WebhookRepository, webhookDeliveryRepository repositories.WebhookDeliveryRepository, outboxRepository repositories.OutboxRepository, auditRepository repositories.AuditRepository, transactionRepository repositories.TransactionRepository, webhookClient config.WebhookClient) WebhookService {
+	return &webhookService{
+		userRepository:            userRepository,
+		webhookRepository:         webhookRepository,
+		webhookDeliveryRepository: webhookDeliveryRepository,
+		outboxRepository:          outboxRepository,
+		transactionRepository:     transactionRepository,
+		changes:                   newUserChanges(userRepository, outboxRepository, auditRepository, transactionRepository),
+		webhookClient:             webhookClient,
+		maxWebhooks:               config.GetEnvInt("WEBHOOK_LIMIT", 10),
+		secretOverlap:             config.GetEnvDuration("WEBHOOK_SECRET_OVERLAP", 24*time.Hour),
+		deliveryTimeout:           config.GetEnvDuration("WEBHOOK_DELIVERY_TIMEOUT", 10*time.Second),
+		maxDeliveryBodyLength:     config.GetEnvInt("WEBHOOK_DELIVERY_BODY_LENGTH", 1024),
+		testInterval:              config.GetEnvDuration("WEBHOOK_TEST_INTERVAL", time.Minute),
+	}
+}
+
+func (s *webhookService) CreateWebhook(actor *models.Actor, userId string, request *models.WebhookRequest) (*models.WebhookSecretResponse, error) {
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		slog.Error("Failed to generate webhook secret", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	webhook := &models.Webhook{
+		UserId:      userId,
+		URL:         request.URL,
+		Description: request.Description,
+		EventTypes:  request.EventTypes,
+		Enabled:     request.Enabled == nil || *request.Enabled,
+		Secrets: []models.WebhookSecret{
+			{Secret: secret, CreatedAt: time.Now().UTC()},
+		},
+		Verification: pendingWebhookVerification(),
+	}
+	if webhook.EventTypes == nil {
+		webhook.EventTypes = []string{}
+	}
+
+	err = s.transactionRepository.WithTransaction(func(ctx context.Context) error {
+		previous, err := s.userRepository.FindForUpdate(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		// writing the user first makes concurrent creates for the same user conflict, the one retried counts the other's webhook
+		user, err := s.userRepository.InsertNotificationInterface(ctx, userId, constants.Webhooks.String())
+		if err != nil {
+			return err
+		}
+
+		count, err := s.webhookRepository.CountByUserId(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		if count >= int64(s.maxWebhooks) {
+			return ErrWebhookLimitReached
+		}
+
+		err = s.webhookRepository.Insert(ctx, webhook)
+		if err != nil {
+			return err
+		}
+
+		changedFields := userEventFields(user, "notificationInterfaces")
+		changedFields[webhookEventField(webhook.Id)] = webhook
+		err = insertUserEvent(ctx, s.outboxRepository, actor.RequestId, constants.UserUpdated, userId, changedFields)
+		if err != nil {
+			return err
+		}
+
+		err = s.changes.auditValues(ctx, actor, userId, userEventFields(previous, "notificationInterfaces"), userEventFields(user, "notificationInterfaces"), "notificationInterfaces")
+		if err != nil {
+			return err
+		}
+
+		return s.auditWebhook(ctx, actor, userId, webhook.Id, nil, webhook)
+	})
+	if err != nil {
+		slog.Error("Failed to create webhook", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Created webhook", "userId", userId, "id", webhook.Id)
+	return &models.WebhookSecretResponse{Webhook: *webhook, Secret: secret}, nil
+
+}
+
+func (s *webhookService) GetWebhooks(userId string) ([]models.Webhook, error) {
+
+	webhooks, err := s.webhookRepository.FindByUserId(userId)
+	if err != nil {
+		slog.Error("Failed to get webhooks", "error", err, "userId", userId)
+		return nil, err
+	}
+
+	slog.Debug("Got webhooks", "userId", userId)
+	return webhooks, nil
+
+}
+
+func (s *webhookService) GetWebhook(userId string, id string) (*models.Webhook, error) {
+
+	webhookId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	webhook, err := s.webhookRepository.FindById(userId, webhookId)
+	if err != nil {
+		slog.Error("Failed to get webhook", "error", err, "userId", userId, "id", id)
+		return nil, webhookError(err)
+	}
+
+	slog.Debug("Got webhook", "userId", userId, "id", id)
+	return webhook, nil
+
+}
+
+func (s *webhookService) UpdateWebhook(actor *models.Actor, userId string, id string, request *models.WebhookPatchRequest) (*models.Webhook, error) {
+
+	webhookId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	fields := bson.M{}
+	if request.URL != nil {
+		fields["url"] = *request.URL
+		fields["verification"] = pendingWebhookVerification()
+	}
+	if request.Description != nil {
+		fields["description"] = *request.Description
+	}
+	if request.EventTypes != nil {
+		fields["eventTypes"] = *request.EventTypes
+	}
+	if request.Enabled != nil {
+		fields["enabled"] = *request.Enabled
+	}
+
+	var webhook *models.Webhook
+	err = s.transactionRepository.WithTransaction(func(ctx context.Context) error {
+		previous, err := s.webhookRepository.FindForUpdate(ctx, userId, webhookId)
+		if err != nil {
+			return err
+		}
+
+		webhook, err = s.webhookRepository.Update(ctx, userId, webhookId, fields)
+		if err != nil {
+			return err
+		}
+
+		err = insertUserEvent(ctx, s.outboxRepository, actor.RequestId, constants.UserUpdated, userId, map[string]interface{}{
+			webhookEventField(webhook.Id): webhook,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.auditWebhook(ctx, actor, userId, webhookId, previous, webhook)
+	})
+	if err != nil {
+		slog.Error("Failed to update webhook", "error", err, "userId", userId, "id", id)
+		return nil, webhookError(err)
+	}
+
+	slog.Debug("Updated webhook", "userId", userId, "id", id)
+	return webhook, nil
+
+}
+
+func (s *webhookService) DeleteWebhook(actor *models.Actor, userId string, id string) error {
+
+	webhookId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrWebhookNotFound
+	}
+
+	err = s.transactionRepository.WithTransaction(func(ctx context.Context) error {
+		previous, err := s.webhookRepository.FindForUpdate(ctx, userId, webhookId)
+		if err != nil {
+			return err
+		}
+
+		previousUser, err := s.userRepository.FindForUpdate(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		err = s.webhookRepository.Delete(ctx, userId, webhookId)
+		if err != nil {
+			return err
+		}
+
+		count, err := s.webhookRepository.CountByUserId(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		// the user is written either way, so deleting the last two webhooks at once cannot leave the channel enabled
+		var user *models.User
+		if count == 0 {
+			user, err = s.userRepository.RemoveNotificationInterface(ctx, userId, constants.Webhooks.String())
+		} else {
+			user, err = s.userRepository.UpdateFields(ctx, userId, bson.M{})
+		}
+		if err != nil {
+			return err
+		}
+
+		changedFields := map[string]interface{}{}
+		if count == 0 {
+			changedFields = userEventFields(user, "notificationInterfaces")
+		}
+		changedFields[webhookEventField(webhookId)] = nil
+		err = insertUserEvent(ctx, s.outboxRepository, actor.RequestId, constants.UserUpdated, userId, changedFields)
+		if err != nil {
+			return err
+		}
+
+		err = s.changes.auditValues(ctx, actor, userId, userEventFields(previousUser, "notificationInterfaces"), userEventFields(user, "notificationInterfaces"), "notificationInterfaces")
+		if err != nil {
+			return err
+		}
+
+		return s.auditWebhook(ctx, actor, userId, webhookId, previous, nil)
+	})
+	if err != nil {
+		slog.Error("Failed to delete webhook", "error", err, "userId", userId, "id", id)
+		return webhookError(err)
+	}
+
+	slog.Debug("Deleted webhook", "userId", userId, "id", id)
+	return nil
+
+}
+
+// RotateSecret issues a new signing secret, the current ones keep working for the overlap so receivers can switch over.
+// A nil overlap uses the configured default.
+func (s *webhookService) RotateSecret(actor *models.Actor, userId string, id string, overlap *time.Duration) (*models.WebhookSecretResponse, error) {
+
+	webhook, err := s.GetWebhook(userId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		slog.Error("Failed to generate webhook secret", "error", err, "userId", userId, "id", id)
+		return nil, err
+	}
+
+	if overlap == nil {
+		overlap = &s.secretOverlap
+	}
+
+	now := time.Now().UTC()
+	secrets := []models.WebhookSecret{}
+	for _, current := range webhook.Secrets {
+		if !current.ExpiresAt.IsZero() && !current.ExpiresAt.After(now) {
+			continue
+		}
+		if current.ExpiresAt.IsZero() || current.ExpiresAt.After(now.Add(*overlap)) {
+			current.ExpiresAt = now.Add(*overlap)
+		}
+		secrets = append(secrets, current)
+	}
+	secrets = append(secrets, models.WebhookSecret{Secret: secret, CreatedAt: now})
+
+	err = s.transactionRepository.WithTransaction(func(ctx context.Context) error {
+		var err error
+		webhook, err = s.webhookRepository.UpdateSecrets(ctx, userId, webhook.Id, secrets)
+		if err != nil {
+			return err
+		}
+
+		// only the tail of the new secret is kept, enough to tell which secret a receiver was given
+		return s.changes.audit(ctx, actor, userId, webhookEventField(webhook.Id)+".secret", nil, secret)
+	})
+	if err != nil {
+		slog.Error("Failed to rotate webhook secret", "error", err, "userId", userId, "id", id)
+		return nil, webhookError(err)
+	}
+
+	slog.Debug("Rotated webhook secret", "userId", userId, "id", id, "overlap", *overlap)
+	return &models.WebhookSecretResponse{Webhook: *webhook, Secret: secret}, nil
+
+}
+
+// RequestVerification queues the handshake again, for example after a failed verification once the receiver is fixed
+func (s *webhookService) RequestVerification(actor *models.Actor, userId string, id string) (*models.Webhook, error) {
+
+	webhookId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	var webhook *models.Webhook
+	err = s.transactionRepository.WithTransaction(func(ctx context.Context) error {
+		previous, err := s.webhookRepository.FindForUpdate(ctx, userId, webhookId)
+		if err != nil {
+			return err
+		}
+
+		webhook, err = s.webhookRepository.Update(ctx, userId, webhookId, bson.M{"verification": pendingWebhookVerification()})
+		if err != nil {
+			return err
+		}
+
+		err = insertUserEvent(ctx, s.outboxRepository, actor.RequestId, constants.UserUpdated, userId, map[string]interface{}{
+			webhookEventField(webhook.Id): webhook,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.changes.audit(ctx, actor, userId, webhookEventField(webhook.Id)+".verification", previous.Verification.Status, webhook.Verification.Status)
+	})
+	if err != nil {
+		slog.Error("Failed to request webhook verification", "error", err, "userId", userId, "id", id)
+		return nil, webhookError(err)
+	}
+
+	slog.Debug("Requested webhook verification", "userId", userId, "id", id)
+	return webhook, nil
+
+}
+
+// TestWebhook signs and posts a sample enhancement.completed payload and records the attempt in the delivery log.
+// A receiver that fails still returns the recorded delivery, the error is only for this service failing.
+// Only verified webhooks are tested, each at most once per testInterval, so the endpoint cannot be used to send
+// requests to arbitrary or unwilling receivers.
+func (s *webhookService) TestWebhook(userId string, id string) (*models.WebhookDelivery, error) {
+
+	webhook, err := s.GetWebhook(userId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !webhook.Enabled {
+		return nil, ErrWebhookDisabled
+	}
+
+	if webhook.Verification.Status != constants.WebhookVerificationVerified.String() {
+		return nil, ErrWebhookNotVerified
+	}
+
+	webhook, err = s.webhookRepository.ClaimTest(userId, webhook.Id, s.testInterval)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWebhookTestTooSoon
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	payload := models.WebhookPayload{
+		Id:        uuid.NewString(),
+		Type:      constants.EnhancementCompleted.String(),
+		CreatedAt: now,
+		Test:      true,
+		Data: map[string]interface{}{
+			"userId":           userId,
+			"requestId":        "test",
+			"enhancedVideoUrl": "https://example.com/videos/test/enhanced.mp4",
+		},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("Failed to marshal webhook test payload", "error", err, "userId", userId, "id", id)
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set(constants.WebhookEventHeader, payload.Type)
+	header.Set(constants.WebhookSignatureHeader, utils.SignWebhookPayload(activeWebhookSecrets(webhook, now), now, body))
+
+	delivery := &models.WebhookDelivery{
+		WebhookId: webhook.Id,
+		UserId:    userId,
+		EventId:   payload.Id,
+		EventType: payload.Type,
+		Test:      true,
+		URL:       webhook.URL,
+		CreatedAt: now,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.deliveryTimeout)
+	defer cancel()
+
+	start := time.Now()
+	response, err := s.webhookClient.Post(ctx, webhook.URL, header, body)
+	if err != nil {
+		delivery.LatencyMs = time.Since(start).Milliseconds()
+		delivery.Error = err.Error()
+	} else {
+		delivery.LatencyMs = response.Latency.Milliseconds()
+		delivery.StatusCode = response.StatusCode
+		delivery.ResponseBody = truncate(string(response.Body), s.maxDeliveryBodyLength)
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			delivery.Error = fmt.Sprintf("webhook responded with status %d", response.StatusCode)
+		}
+	}
+
+	err = s.webhookDeliveryRepository.Insert(delivery)
+	if err != nil {
+		slog.Error("Failed to record webhook test delivery", "error", err, "userId", userId, "id", id)
+		return nil, err
+	}
+
+	slog.Debug("Tested webhook", "userId", userId, "id", id, "statusCode", delivery.StatusCode, "error", delivery.Error)
+	return delivery, nil
+
+}
+
+func (s *webhookService) GetDeliveries(userId string, id string, query *models.WebhookDeliveriesQuery) (*models.WebhookDeliveriesResponse, error) {
+
+	webhookId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	// deliveries outlive a deleted webhook until they expire, but are only listed while it exists
+	_, err = s.webhookRepository.FindById(userId, webhookId)
+	if err != nil {
+		return nil, webhookError(err)
+	}
+
+	page, limit := query.Page, query.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	deliveries, total, err := s.webhookDeliveryRepository.FindByWebhookId(userId, webhookId, page, limit)
+	if err != nil {
+		slog.Error("Failed to get webhook deliveries", "error", err, "userId", userId, "id", id)
+		return nil, err
+	}
+
+	slog.Debug("Got webhook deliveries", "userId", userId, "id", id, "page", page)
+	return &models.WebhookDeliveriesResponse{
+		Deliveries: deliveries,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+	}, nil
+
+}
+
+// pendingWebhookVerification starts a new handshake, the verification job picks it up on its next run
+func pendingWebhookVerification() models.WebhookVerification {
+	return models.WebhookVerification{
+		Status:        constants.WebhookVerificationPending.String(),
+		NextAttemptAt: time.Now().UTC(),
+	}
+}
+
+func generateWebhookSecret() (string, error) {
+
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	return "whsec_" + token, nil
+
+}
+
+// auditWebhook records the settings of a single webhook that differ between previous and current, either is nil when
+// the webhook is created or deleted
+func (s *webhookService) auditWebhook(ctx context.Context, actor *models.Actor, userId string, id primitive.ObjectID, previous *models.Webhook, current *models.Webhook) error {
+
+	prefix := webhookEventField(id) + "."
+	values := func(webhook *models.Webhook) map[string]interface{} {
+		if webhook == nil {
+			return map[string]interface{}{}
+		}
+		return map[string]interface{}{
+			prefix + "url":         webhook.URL,
+			prefix + "description": webhook.Description,
+			prefix + "eventTypes":  webhook.EventTypes,
+			prefix + "enabled":     webhook.Enabled,
+		}
+	}
+
+	return s.changes.auditValues(ctx, actor, userId, values(previous), values(current), prefix+"url", prefix+"description", prefix+"eventTypes", prefix+"enabled")
+
+}
+
+// webhookEventField is the changed field naming a single webhook in user events, set to nil once it is deleted
+func webhookEventField(id primitive.ObjectID) string {
+	return "webhooks." + id.Hex()
+}
+
+func webhookError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+// truncate cuts s to at most maxLength bytes without splitting a multi-byte character
+func truncate(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxLength], "")
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type fakeWebhookRepository struct {
+	repositories.WebhookRepository
+	webhooks map[primitive.ObjectID]*models.Webhook
+}
+
+func (r *fakeWebhookRepository) Insert(ctx context.Context, webhook *models.Webhook) error {
+	webhook.Id = primitive.NewObjectID()
+	r.webhooks[webhook.Id] = webhook
+	return nil
+}
+
+func (r *fakeWebhookRepository) FindForUpdate(ctx context.Context, userId string, id primitive.ObjectID) (*models.Webhook, error) {
+	webhook, ok := r.webhooks[id]
+	if !ok || webhook.UserId != userId {
+		return nil, mongo.ErrNoDocuments
+	}
+	return webhook, nil
+}
+
+func (r *fakeWebhookRepository) CountByUserId(ctx context.Context, userId string) (int64, error) {
+	var count int64
+	for _, webhook := range r.webhooks {
+		if webhook.UserId == userId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeWebhookRepository) Delete(ctx context.Context, userId string, id primitive.ObjectID) error {
+	if _, err := r.FindForUpdate(ctx, userId, id); err != nil {
+		return err
+	}
+	delete(r.webhooks, id)
+	return nil
+}
+
+func (r *fakeWebhookRepository) DeleteByUserId(ctx context.Context, userId string) (int64, error) {
+	var deleted int64
+	for id, webhook := range r.webhooks {
+		if webhook.UserId == userId {
+			delete(r.webhooks, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *fakeWebhookRepository) FindById(userId string, id primitive.ObjectID) (*models.Webhook, error) {
+	return r.FindForUpdate(context.Background(), userId, id)
+}
+
+func (r *fakeWebhookRepository) ClaimTest(userId string, id primitive.ObjectID, interval time.Duration) (*models.Webhook, error) {
+	webhook, err := r.FindForUpdate(context.Background(), userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.Enabled || webhook.Verification.Status != constants.WebhookVerificationVerified.String() || time.Since(webhook.LastTestedAt) < interval {
+		return nil, mongo.ErrNoDocuments
+	}
+	webhook.LastTestedAt = time.Now()
+	return webhook, nil
+}
+
+type fakeWebhookUserRepository struct {
+	fakeUserRepository
+}
+
+func (r *fakeWebhookUserRepository) InsertNotificationInterface(ctx context.Context, userId string, notificationInterface string) (*models.User, error) {
+	for _, current := range r.user.NotificationInterfaces {
+		if current == notificationInterface {
+			return r.FindForUpdate(ctx, userId)
+		}
+	}
+	r.user.NotificationInterfaces = append(r.user.NotificationInterfaces, notificationInterface)
+	return r.FindForUpdate(ctx, userId)
+}
+
+func (r *fakeWebhookUserRepository) RemoveNotificationInterface(ctx context.Context, userId string, notificationInterface string) (*models.User, error) {
+	remaining := []string{}
+	for _, current := range r.user.NotificationInterfaces {
+		if current != notificationInterface {
+			remaining = append(remaining, current)
+		}
+	}
+	r.user.NotificationInterfaces = remaining
+	return r.FindForUpdate(ctx, userId)
+}
+
+func (r *fakeWebhookUserRepository) UpdateFields(ctx context.Context, userId string, fields bson.M) (*models.User, error) {
+	return r.FindForUpdate(ctx, userId)
+}
+
+type fakeWebhookDeliveryRepository struct {
+	repositories.WebhookDeliveryRepository
+	deliveries []*models.WebhookDelivery
+}
+
+func (r *fakeWebhookDeliveryRepository) Insert(delivery *models.WebhookDelivery) error {
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+func (r *fakeWebhookDeliveryRepository) DeleteByUserId(userId string) (int64, error) {
+	kept := []*models.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if delivery.UserId != userId {
+			kept = append(kept, delivery)
+		}
+	}
+	deleted := int64(len(r.deliveries) - len(kept))
+	r.deliveries = kept
+	return deleted, nil
+}
+
+type fakeWebhookClient struct {
+	requests int
+}
+
+func (c *fakeWebhookClient) Post(ctx context.Context, url string, header http.Header, body []byte) (*config.WebhookResponse, error) {
+	c.requests++
+	return &config.WebhookResponse{StatusCode: http.StatusOK}, nil
+}
+
+func newTestWebhookService(maxWebhooks int) (*webhookService, *fakeWebhookUserRepository) {
+
+	userRepository := &fakeWebhookUserRepository{fakeUserRepository{user: &models.User{UserId: "user-1", NotificationInterfaces: []string{constants.Email.String()}}}}
+	webhookRepository := &fakeWebhookRepository{webhooks: map[primitive.ObjectID]*models.Webhook{}}
+	outboxRepository := &fakeOutboxRepository{}
+	auditRepository := &fakeAuditRepository{}
+	transactionRepository := fakeTransactionRepository{}
+
+	return &webhookService{
+		userRepository:        userRepository,
+		webhookRepository:     webhookRepository,
+		outboxRepository:      outboxRepository,
+		transactionRepository: transactionRepository,
+		changes:               newUserChanges(userRepository, outboxRepository, auditRepository, transactionRepository),
+		maxWebhooks:           maxWebhooks,
+	}, userRepository
+
+}
+
+func TestCreateWebhookLimit(t *testing.T) {
+
+	service, _ := newTestWebhookService(2)
+	request := &models.WebhookRequest{URL: "https://example.com/hook"}
+
+	for i := 0; i < 2; i++ {
+		_, err := service.CreateWebhook(&models.Actor{}, "user-1", request)
+		if err != nil {
+			t.Fatalf("webhook %d: %v", i+1, err)
+		}
+	}
+
+	_, err := service.CreateWebhook(&models.Actor{}, "user-1", request)
+	if !errors.Is(err, ErrWebhookLimitReached) {
+		t.Errorf("got error %v, want %v", err, ErrWebhookLimitReached)
+	}
+
+}
+
+func TestDeleteLastWebhookDisablesChannel(t *testing.T) {
+
+	service, userRepository := newTestWebhookService(10)
+	request := &models.WebhookRequest{URL: "https://example.com/hook"}
+
+	first, err := service.CreateWebhook(&models.Actor{}, "user-1", request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := service.CreateWebhook(&models.Actor{}, "user-1", request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hasWebhooks := func() bool {
+		for _, notificationInterface := range userRepository.user.NotificationInterfaces {
+			if notificationInterface == constants.Webhooks.String() {
+				return true
+			}
+		}
+		return false
+	}
+
+	err = service.DeleteWebhook(&models.Actor{}, "user-1", first.Webhook.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasWebhooks() {
+		t.Error("webhooks disabled while a webhook is left")
+	}
+
+	err = service.DeleteWebhook(&models.Actor{}, "user-1", second.Webhook.Id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasWebhooks() {
+		t.Error("webhooks still enabled without any webhook")
+	}
+
+}
+
+func TestTestWebhook(t *testing.T) {
+
+	service, _ := newTestWebhookService(10)
+	webhookClient := &fakeWebhookClient{}
+	service.webhookClient = webhookClient
+	service.webhookDeliveryRepository = &fakeWebhookDeliveryRepository{}
+	service.testInterval = time.Minute
+
+	created, err := service.CreateWebhook(&models.Actor{}, "user-1", &models.WebhookRequest{URL: "https://example.com/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := created.Webhook.Id.Hex()
+
+	_, err = service.TestWebhook("user-1", id)
+	if !errors.Is(err, ErrWebhookNotVerified) {
+		t.Errorf("unverified webhook: got error %v, want %v", err, ErrWebhookNotVerified)
+	}
+
+	webhookRepository := service.webhookRepository.(*fakeWebhookRepository)
+	webhookRepository.webhooks[created.Webhook.Id].Verification.Status = constants.WebhookVerificationVerified.String()
+
+	_, err = service.TestWebhook("user-1", id)
+	if err != nil {
+		t.Fatalf("verified webhook: %v", err)
+	}
+
+	_, err = service.TestWebhook("user-1", id)
+	if !errors.Is(err, ErrWebhookTestTooSoon) {
+		t.Errorf("second test: got error %v, want %v", err, ErrWebhookTestTooSoon)
+	}
+
+	webhookRepository.webhooks[created.Webhook.Id].Enabled = false
+	webhookRepository.webhooks[created.Webhook.Id].LastTestedAt = time.Time{}
+
+	_, err = service.TestWebhook("user-1", id)
+	if !errors.Is(err, ErrWebhookDisabled) {
+		t.Errorf("disabled webhook: got error %v, want %v", err, ErrWebhookDisabled)
+	}
+
+	if webhookClient.requests != 1 {
+		t.Errorf("sent %d requests, want 1", webhookClient.requests)
+	}
+
+}
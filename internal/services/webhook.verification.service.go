@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/config"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/repositories"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/exp/slog"
+)
+
+type WebhookVerificationService interface {
+	VerifyNext() (bool, error)
+}
+
+var ErrWebhookChallengeMismatch = errors.New("webhook did not echo the challenge")
+
+type webhookVerificationService struct {
+	webhookRepository     repositories.WebhookRepository
+	outboxRepository      repositories.OutboxRepository
+	transactionRepository repositories.TransactionRepository
+	webhookClient         config.WebhookClient
+	lease                 time.Duration
+	timeout               time.Duration
+	maxAttempts           int
+	retryInterval         time.Duration
+}
+
+func NewWebhookVerificationService(webhookRepository repositories.WebhookRepository, outboxRepository repositories.OutboxRepository, transactionRepository repositories.TransactionRepository, webhookClient config.WebhookClient) WebhookVerificationService {
+	return &webhookVerificationService{
+		webhookRepository:     webhookRepository,
+		outboxRepository:      outboxRepository,
+		transactionRepository: transactionRepository,
+		webhookClient:         webhookClient,
+		lease:                 config.GetEnvDuration("WEBHOOK_VERIFICATION_LEASE", time.Minute),
+		timeout:               config.GetEnvDuration("WEBHOOK_VERIFICATION_TIMEOUT", 10*time.Second),
+		maxAttempts:           config.GetEnvInt("WEBHOOK_VERIFICATION_MAX_ATTEMPTS", 3),
+		retryInterval:         config.GetEnvDuration("WEBHOOK_VERIFICATION_RETRY_INTERVAL", time.Minute),
+	}
+}
+
+// VerifyNext runs the handshake for the next pending webhook, it returns false when there was nothing to verify.
+// A failed handshake is retried with backoff until WEBHOOK_VERIFICATION_MAX_ATTEMPTS, then the webhook is marked failed.
+func (s *webhookVerificationService) VerifyNext() (bool, error) {
+
+	webhook, err := s.webhookRepository.ClaimNextVerification(s.lease)
+	if err != nil || webhook == nil {
+		return false, err
+	}
+
+	verification := webhook.Verification
+	err = s.challenge(webhook)
+
+	now := time.Now().UTC()
+	switch {
+	case err == nil:
+		verification.Status = constants.WebhookVerificationVerified.String()
+		verification.LastError = ""
+		verification.NextAttemptAt = time.Time{}
+		verification.VerifiedAt = now
+	case verification.Attempts >= s.maxAttempts:
+		verification.Status = constants.WebhookVerificationFailed.String()
+		verification.LastError = err.Error()
+		verification.NextAttemptAt = time.Time{}
+	default:
+		verification.LastError = err.Error()
+		verification.NextAttemptAt = now.Add(s.backoff(verification.Attempts))
+	}
+
+	err = s.transactionRepository.WithTransaction(func(ctx context.Context) error {
+		updated, err := s.webhookRepository.UpdateVerification(ctx, webhook.Id, webhook.URL, verification)
+		if err != nil {
+			return err
+		}
+
+		if updated.Verification.Status == constants.WebhookVerificationPending.String() {
+			return nil
+		}
+
+		return insertUserEvent(ctx, s.outboxRepository, "", constants.UserUpdated, updated.UserId, map[string]interface{}{
+			webhookEventField(updated.Id): updated,
+		})
+	})
+
+	// the url was changed or the verification requested again meanwhile, so this outcome no longer applies
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		slog.Debug("Discarded stale webhook verification", "id", webhook.Id)
+		return true, nil
+	}
+
+	if err != nil {
+		slog.Error("Failed to record webhook verification", "error", err, "id", webhook.Id)
+		return true, err
+	}
+
+	slog.Info("Verified webhook", "id", webhook.Id, "userId", webhook.UserId, "status", verification.Status, "attempts", verification.Attempts, "lastError", verification.LastError)
+	return true, nil
+
+}
+
+// challenge posts a signed random challenge and expects the receiver to answer 2xx with {"challenge": "<the same value>"}
+func (s *webhookVerificationService) challenge(webhook *models.Webhook) error {
+
+	token, err := utils.GenerateToken(24)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(models.WebhookChallenge{
+		Type:      constants.WebhookVerificationEvent,
+		WebhookId: webhook.Id.Hex(),
+		Challenge: token,
+	})
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Set(constants.WebhookEventHeader, constants.WebhookVerificationEvent)
+	header.Set(constants.WebhookSignatureHeader, utils.SignWebhookPayload(activeWebhookSecrets(webhook, time.Now()), time.Now(), body))
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	response, err := s.webhookClient.Post(ctx, webhook.URL, header, body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+
+	var challengeResponse models.WebhookChallengeResponse
+	err = json.Unmarshal(response.Body, &challengeResponse)
+	if err != nil || subtle.ConstantTimeCompare([]byte(challengeResponse.Challenge), []byte(token)) != 1 {
+		return ErrWebhookChallengeMismatch
+	}
+
+	return nil
+
+}
+
+func (s *webhookVerificationService) backoff(attempts int) time.Duration {
+	return time.Duration(math.Pow(2, float64(attempts-1))) * s.retryInterval
+}
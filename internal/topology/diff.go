@@ -0,0 +1,152 @@
+package topology
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+)
+
+// Change is one difference between the topology and the broker, entities that already match are left out
+type Change struct {
+	Action  constants.TopologyChange
+	Kind    string
+	Name    string
+	Details []string
+}
+
+// Diff compares the topology with the current state of the broker. A conflict cannot be declared over,
+// the entity has to be deleted first, and unmanaged entities are reported but never touched.
+func Diff(desired *Topology, current *Topology) []Change {
+
+	changes := []Change{}
+
+	currentExchanges := map[string]Exchange{}
+	for _, exchange := range current.Exchanges {
+		currentExchanges[exchange.Name] = exchange
+	}
+	desiredExchanges := map[string]bool{}
+	for _, exchange := range desired.Exchanges {
+		desiredExchanges[exchange.Name] = true
+
+		existing, ok := currentExchanges[exchange.Name]
+		if !ok {
+			changes = append(changes, Change{Action: constants.TopologyCreate, Kind: "exchange", Name: exchange.Name})
+			continue
+		}
+
+		details := []string{}
+		details = compare(details, "type", existing.Type, exchange.Type)
+		details = compare(details, "durable", existing.Durable, exchange.Durable)
+		details = compare(details, "autoDelete", existing.AutoDelete, exchange.AutoDelete)
+		details = compare(details, "internal", existing.Internal, exchange.Internal)
+		details = compareArguments(details, arguments(existing.Arguments), exchange.arguments())
+		if len(details) > 0 {
+			changes = append(changes, Change{Action: constants.TopologyConflict, Kind: "exchange", Name: exchange.Name, Details: details})
+		}
+	}
+
+	currentQueues := map[string]Queue{}
+	for _, queue := range current.Queues {
+		currentQueues[queue.Name] = queue
+	}
+	desiredQueues := map[string]bool{}
+	for _, queue := range desired.Queues {
+		desiredQueues[queue.Name] = true
+
+		existing, ok := currentQueues[queue.Name]
+		if !ok {
+			changes = append(changes, Change{Action: constants.TopologyCreate, Kind: "queue", Name: queue.Name})
+			continue
+		}
+
+		existingArguments := arguments(existing.Arguments)
+		// a queue declared without a type is a classic one
+		if _, ok := existingArguments["x-queue-type"]; !ok && queue.Type == "classic" {
+			existingArguments["x-queue-type"] = "classic"
+		}
+
+		details := []string{}
+		details = compare(details, "durable", existing.Durable, queue.Durable)
+		details = compare(details, "autoDelete", existing.AutoDelete, queue.AutoDelete)
+		details = compareArguments(details, existingArguments, queue.arguments())
+		if len(details) > 0 {
+			changes = append(changes, Change{Action: constants.TopologyConflict, Kind: "queue", Name: queue.Name, Details: details})
+		}
+	}
+
+	currentBindings := map[string]bool{}
+	for _, binding := range current.Bindings {
+		currentBindings[binding.key()] = true
+	}
+	for _, binding := range desired.Bindings {
+		if !currentBindings[binding.key()] {
+			changes = append(changes, Change{Action: constants.TopologyCreate, Kind: "binding", Name: binding.String()})
+		}
+	}
+
+	for _, exchange := range current.Exchanges {
+		// the default exchange and the amq. ones come with every virtual host
+		if exchange.Name == "" || strings.HasPrefix(exchange.Name, "amq.") || desiredExchanges[exchange.Name] {
+			continue
+		}
+		changes = append(changes, Change{Action: constants.TopologyUnmanaged, Kind: "exchange", Name: exchange.Name})
+	}
+	for _, queue := range current.Queues {
+		if desiredQueues[queue.Name] {
+			continue
+		}
+		changes = append(changes, Change{Action: constants.TopologyUnmanaged, Kind: "queue", Name: queue.Name})
+	}
+
+	return changes
+
+}
+
+func (b Binding) key() string {
+	return fmt.Sprint(b.Exchange, "\x00", b.Queue, "\x00", b.RoutingKey, "\x00", map[string]interface{}(arguments(b.Arguments)))
+}
+
+func (b Binding) String() string {
+	return fmt.Sprintf("%s -> %s (%q)", b.Exchange, b.Queue, b.RoutingKey)
+}
+
+func compare(details []string, field string, current interface{}, desired interface{}) []string {
+
+	if fmt.Sprint(current) == fmt.Sprint(desired) {
+		return details
+	}
+
+	return append(details, fmt.Sprintf("%s: %v on the broker, %v in the topology", field, current, desired))
+
+}
+
+func compareArguments(details []string, current map[string]interface{}, desired map[string]interface{}) []string {
+
+	keys := []string{}
+	for key := range current {
+		keys = append(keys, key)
+	}
+	for key := range desired {
+		if _, ok := current[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		currentValue, ok := current[key]
+		if !ok {
+			currentValue = "unset"
+		}
+		desiredValue, ok := desired[key]
+		if !ok {
+			desiredValue = "unset"
+		}
+		details = compare(details, key, currentValue, desiredValue)
+	}
+
+	return details
+
+}
@@ -0,0 +1,109 @@
+package topology
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+)
+
+// newManagementStandIn answers the management API for the default virtual host with the given exchanges, queues and bindings
+func newManagementStandIn(t *testing.T, exchanges string, queues string, bindings string) ManagementClient {
+
+	resources := map[string]string{
+		"/api/exchanges/%2F": exchanges,
+		"/api/queues/%2F":    queues,
+		"/api/bindings/%2F":  bindings,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "guest" || password != "guest" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, ok := resources[r.URL.EscapedPath()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return NewManagementClient(server.URL, "guest", "guest", "/")
+
+}
+
+func TestDiffWithManagementAPI(t *testing.T) {
+
+	desired := &Topology{
+		Exchanges: []Exchange{{Name: "events", Type: "topic", Durable: true}},
+		Queues:    []Queue{{Name: "work", Type: "quorum", Durable: true, DeliveryLimit: 5}},
+		Bindings:  []Binding{{Exchange: "events", Queue: "work", RoutingKey: "user.created"}},
+	}
+
+	defaultExchanges := `{"name": "", "type": "direct", "durable": true, "arguments": {}}, {"name": "amq.topic", "type": "topic", "durable": true, "arguments": {}}`
+	defaultBinding := `{"source": "", "destination": "work", "destination_type": "queue", "routing_key": "work", "arguments": {}}`
+
+	tests := []struct {
+		name      string
+		exchanges string
+		queues    string
+		bindings  string
+		changes   []Change
+	}{
+		{
+			name:      "missing",
+			exchanges: `[` + defaultExchanges + `]`,
+			queues:    `[]`,
+			bindings:  `[]`,
+			changes: []Change{
+				{Action: constants.TopologyCreate, Kind: "exchange", Name: "events"},
+				{Action: constants.TopologyCreate, Kind: "queue", Name: "work"},
+				{Action: constants.TopologyCreate, Kind: "binding", Name: `events -> work ("user.created")`},
+			},
+		},
+		{
+			name:      "equal",
+			exchanges: `[` + defaultExchanges + `, {"name": "events", "type": "topic", "durable": true, "auto_delete": false, "internal": false, "arguments": {}}]`,
+			queues:    `[{"name": "work", "durable": true, "auto_delete": false, "arguments": {"x-queue-type": "quorum", "x-delivery-limit": 5}}]`,
+			bindings:  `[` + defaultBinding + `, {"source": "events", "destination": "work", "destination_type": "queue", "routing_key": "user.created", "arguments": {}}]`,
+			changes:   []Change{},
+		},
+		{
+			name:      "argument mismatch",
+			exchanges: `[` + defaultExchanges + `, {"name": "events", "type": "topic", "durable": true, "arguments": {"alternate-exchange": "events.unrouted"}}]`,
+			queues:    `[{"name": "work", "durable": true, "arguments": {}}, {"name": "legacy", "durable": true, "arguments": {}}]`,
+			bindings:  `[` + defaultBinding + `, {"source": "events", "destination": "work", "destination_type": "queue", "routing_key": "user.created", "arguments": {}}]`,
+			changes: []Change{
+				{Action: constants.TopologyConflict, Kind: "exchange", Name: "events", Details: []string{"alternate-exchange: events.unrouted on the broker, unset in the topology"}},
+				{Action: constants.TopologyConflict, Kind: "queue", Name: "work", Details: []string{"x-delivery-limit: unset on the broker, 5 in the topology", "x-queue-type: unset on the broker, quorum in the topology"}},
+				{Action: constants.TopologyUnmanaged, Kind: "queue", Name: "legacy"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newManagementStandIn(t, test.exchanges, test.queues, test.bindings)
+
+			current, err := client.Current(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			changes := Diff(desired, current)
+
+			got, _ := json.Marshal(changes)
+			want, _ := json.Marshal(test.changes)
+			if string(got) != string(want) {
+				t.Errorf("got changes %s, want %s", got, want)
+			}
+		})
+	}
+
+}
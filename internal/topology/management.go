@@ -0,0 +1,144 @@
+package topology
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// ManagementClient reads the exchanges, queues and bindings of a virtual host from the RabbitMQ management API
+type ManagementClient interface {
+	Current(ctx context.Context) (*Topology, error)
+}
+
+type managementClient struct {
+	baseURL  string
+	username string
+	password string
+	vhost    string
+	client   *http.Client
+}
+
+func NewManagementClient(baseURL string, username string, password string, vhost string) ManagementClient {
+	return &managementClient{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		username: username,
+		password: password,
+		vhost:    vhost,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type managementExchange struct {
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"`
+	Durable    bool                   `json:"durable"`
+	AutoDelete bool                   `json:"auto_delete"`
+	Internal   bool                   `json:"internal"`
+	Arguments  map[string]interface{} `json:"arguments"`
+}
+
+type managementQueue struct {
+	Name       string                 `json:"name"`
+	Durable    bool                   `json:"durable"`
+	AutoDelete bool                   `json:"auto_delete"`
+	Arguments  map[string]interface{} `json:"arguments"`
+}
+
+type managementBinding struct {
+	Source          string                 `json:"source"`
+	Destination     string                 `json:"destination"`
+	DestinationType string                 `json:"destination_type"`
+	RoutingKey      string                 `json:"routing_key"`
+	Arguments       map[string]interface{} `json:"arguments"`
+}
+
+// Current returns what the broker has declared, with the arguments as stored rather than in the shorthand fields
+func (m *managementClient) Current(ctx context.Context) (*Topology, error) {
+
+	var exchanges []managementExchange
+	err := m.get(ctx, "exchanges", &exchanges)
+	if err != nil {
+		return nil, err
+	}
+
+	var queues []managementQueue
+	err = m.get(ctx, "queues", &queues)
+	if err != nil {
+		return nil, err
+	}
+
+	var bindings []managementBinding
+	err = m.get(ctx, "bindings", &bindings)
+	if err != nil {
+		return nil, err
+	}
+
+	current := &Topology{}
+	for _, exchange := range exchanges {
+		current.Exchanges = append(current.Exchanges, Exchange{
+			Name:       exchange.Name,
+			Type:       exchange.Type,
+			Durable:    exchange.Durable,
+			AutoDelete: exchange.AutoDelete,
+			Internal:   exchange.Internal,
+			Arguments:  exchange.Arguments,
+		})
+	}
+	for _, queue := range queues {
+		current.Queues = append(current.Queues, Queue{
+			Name:       queue.Name,
+			Durable:    queue.Durable,
+			AutoDelete: queue.AutoDelete,
+			Arguments:  queue.Arguments,
+		})
+	}
+	for _, binding := range bindings {
+		// the default exchange binds every queue by its name, and exchange to exchange bindings are not managed here
+		if binding.Source == "" || binding.DestinationType != "queue" {
+			continue
+		}
+		current.Bindings = append(current.Bindings, Binding{
+			Exchange:   binding.Source,
+			Queue:      binding.Destination,
+			RoutingKey: binding.RoutingKey,
+			Arguments:  binding.Arguments,
+		})
+	}
+
+	return current, nil
+
+}
+
+func (m *managementClient) get(ctx context.Context, resource string, v interface{}) error {
+
+	endpoint := m.baseURL + "/api/" + resource + "/" + url.PathEscape(m.vhost)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(m.username, m.password)
+
+	res, err := m.client.Do(req)
+	if err != nil {
+		slog.Error("Failed to reach the RabbitMQ management API", "error", err, "resource", resource)
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		slog.Error("RabbitMQ management API refused the request", "status", res.StatusCode, "resource", resource)
+		return fmt.Errorf("management API returned %s for %s", res.Status, resource)
+	}
+
+	decoder := json.NewDecoder(res.Body)
+	decoder.UseNumber()
+	return decoder.Decode(v)
+
+}
@@ -0,0 +1,74 @@
+package topology
+
+import (
+	"fmt"
+
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"golang.org/x/exp/slog"
+)
+
+// Migrate deletes and declares again the exchanges and queues the broker has with other arguments than the topology,
+// as declaring them over cannot change them, and then declares the rest. A queue is only deleted while it is empty so that
+// no message is lost. The queue bindings the broker had for a recreated exchange or queue are restored, including the ones
+// other services made, bindings between exchanges are not.
+func (t *Topology) Migrate(ch *amqp.Channel, current *Topology) error {
+
+	for _, change := range Diff(t, current) {
+		if change.Action != constants.TopologyConflict {
+			continue
+		}
+
+		var err error
+		switch change.Kind {
+		case "exchange":
+			err = ch.ExchangeDelete(change.Name, false, false)
+		case "queue":
+			_, err = ch.QueueDelete(change.Name, false, true, false)
+		}
+		if err != nil {
+			slog.Error("Failed to delete to declare again, a queue has to be drained first", "error", err, "kind", change.Kind, "name", change.Name)
+			return fmt.Errorf("failed to delete %s %s: %w", change.Kind, change.Name, err)
+		}
+
+		err = t.declareOne(ch, change.Kind, change.Name)
+		if err != nil {
+			return err
+		}
+
+		for _, binding := range current.Bindings {
+			if (change.Kind == "exchange" && binding.Exchange == change.Name) || (change.Kind == "queue" && binding.Queue == change.Name) {
+				err = binding.declare(ch)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		slog.Info("Declared again", "kind", change.Kind, "name", change.Name, "details", change.Details)
+	}
+
+	return t.Declare(ch)
+
+}
+
+func (t *Topology) declareOne(ch *amqp.Channel, kind string, name string) error {
+
+	switch kind {
+	case "exchange":
+		for _, exchange := range t.Exchanges {
+			if exchange.Name == name {
+				return exchange.declare(ch)
+			}
+		}
+	case "queue":
+		for _, queue := range t.Queues {
+			if queue.Name == name {
+				return queue.declare(ch)
+			}
+		}
+	}
+
+	return nil
+
+}
@@ -0,0 +1,484 @@
+package topology
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"golang.org/x/exp/slog"
+)
+
+//go:embed topology.json
+var defaultTopology []byte
+
+var ErrInvalidTopology = errors.New("invalid topology")
+
+// Topology is the messaging layout of the service. Declaring it again is harmless as long as the broker agrees with it,
+// an entity declared with other arguments makes the broker close the channel instead.
+type Topology struct {
+	Exchanges []Exchange `json:"exchanges"`
+	Queues    []Queue    `json:"queues"`
+	Bindings  []Binding  `json:"bindings"`
+}
+
+type Exchange struct {
+	Name              string                 `json:"name"`
+	Type              string                 `json:"type"`
+	Durable           bool                   `json:"durable"`
+	AutoDelete        bool                   `json:"autoDelete"`
+	Internal          bool                   `json:"internal"`
+	AlternateExchange string                 `json:"alternateExchange,omitempty"`
+	Arguments         map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// Queue is a classic or quorum queue, the named fields are shorthands for the x- arguments RabbitMQ reads
+type Queue struct {
+	Name                 string                 `json:"name"`
+	Type                 string                 `json:"type,omitempty"`
+	Durable              bool                   `json:"durable"`
+	AutoDelete           bool                   `json:"autoDelete"`
+	MessageTTL           Duration               `json:"messageTtl,omitempty"`
+	DeadLetterExchange   string                 `json:"deadLetterExchange,omitempty"`
+	DeadLetterRoutingKey string                 `json:"deadLetterRoutingKey,omitempty"`
+	MaxLength            int64                  `json:"maxLength,omitempty"`
+	DeliveryLimit        int64                  `json:"deliveryLimit,omitempty"`
+	Arguments            map[string]interface{} `json:"arguments,omitempty"`
+	Retry                *Retry                 `json:"retry,omitempty"`
+	DeadLetters          bool                   `json:"deadLetters,omitempty"`
+}
+
+// Retry gives a queue a retry queue per delay, which hands a message back to the queue once its delay is over,
+// and a dead-letter exchange and queue of its own for the messages that ran out of attempts.
+// Consumers of the queue publish a message they failed to handle to RetryQueue with the attempts made so far.
+type Retry struct {
+	Delays []Duration `json:"delays"`
+}
+
+// MaxAttempts counts the first delivery along with one attempt per retry queue
+func (r *Retry) MaxAttempts() int {
+	return len(r.Delays) + 1
+}
+
+func RetryQueue(queue string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", queue, attempt)
+}
+
+func DeadLetterExchange(queue string) string {
+	return queue + ".dlx"
+}
+
+func DeadLetterQueue(queue string) string {
+	return queue + ".dead"
+}
+
+type Binding struct {
+	Exchange   string                 `json:"exchange"`
+	Queue      string                 `json:"queue"`
+	RoutingKey string                 `json:"routingKey"`
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// Duration reads a duration like "10m" from the topology file
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+
+	var value string
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+	return nil
+
+}
+
+// Load reads the topology from the file at path, or the one built into the service when path is empty.
+// ${NAME} and ${NAME:-default} are replaced by environment variables first, so names follow the rest of the configuration.
+func Load(path string) (*Topology, error) {
+
+	data := defaultTopology
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			slog.Error("Failed to read topology file", "error", err, "path", path)
+			return nil, err
+		}
+	}
+
+	expanded := os.Expand(string(data), func(name string) string {
+		name, defaultValue, _ := strings.Cut(name, ":-")
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+		return defaultValue
+	})
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(expanded)))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+
+	var topology Topology
+	err := decoder.Decode(&topology)
+	if err != nil {
+		slog.Error("Failed to parse topology file", "error", err, "path", path)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTopology, err)
+	}
+
+	err = topology.validate()
+	if err != nil {
+		slog.Error("Invalid topology", "error", err, "path", path)
+		return nil, err
+	}
+
+	topology.expandRetries()
+	return &topology, nil
+
+}
+
+func (t *Topology) validate() error {
+
+	exchangeTypes := map[string]bool{"direct": true, "fanout": true, "topic": true, "headers": true}
+
+	for _, exchange := range t.Exchanges {
+		if exchange.Name == "" || strings.HasPrefix(exchange.Name, "amq.") {
+			return fmt.Errorf("%w: exchange name %q is empty or reserved", ErrInvalidTopology, exchange.Name)
+		}
+		if !exchangeTypes[exchange.Type] {
+			return fmt.Errorf("%w: exchange %s has unknown type %q", ErrInvalidTopology, exchange.Name, exchange.Type)
+		}
+	}
+
+	for _, queue := range t.Queues {
+		if queue.Name == "" || strings.HasPrefix(queue.Name, "amq.") {
+			return fmt.Errorf("%w: queue name %q is empty or reserved", ErrInvalidTopology, queue.Name)
+		}
+		switch queue.Type {
+		case "", "classic":
+			if queue.DeliveryLimit != 0 {
+				return fmt.Errorf("%w: queue %s sets a delivery limit, which only quorum queues have", ErrInvalidTopology, queue.Name)
+			}
+		case "quorum":
+			if !queue.Durable || queue.AutoDelete {
+				return fmt.Errorf("%w: quorum queue %s has to be durable and not auto-deleted", ErrInvalidTopology, queue.Name)
+			}
+		default:
+			return fmt.Errorf("%w: queue %s has unknown type %q", ErrInvalidTopology, queue.Name, queue.Type)
+		}
+
+		if queue.Retry == nil {
+			continue
+		}
+		if queue.DeadLetterExchange != "" || queue.DeadLetterRoutingKey != "" {
+			return fmt.Errorf("%w: queue %s retries and so dead-letters to an exchange of its own", ErrInvalidTopology, queue.Name)
+		}
+		if len(queue.Retry.Delays) == 0 {
+			return fmt.Errorf("%w: queue %s retries without any delay", ErrInvalidTopology, queue.Name)
+		}
+		for i, delay := range queue.Retry.Delays {
+			if delay <= 0 || (i > 0 && delay <= queue.Retry.Delays[i-1]) {
+				return fmt.Errorf("%w: retry delays of queue %s have to be positive and increasing", ErrInvalidTopology, queue.Name)
+			}
+		}
+	}
+
+	for _, binding := range t.Bindings {
+		if binding.Exchange == "" || binding.Queue == "" {
+			return fmt.Errorf("%w: binding of %q to %q needs both an exchange and a queue", ErrInvalidTopology, binding.Queue, binding.Exchange)
+		}
+	}
+
+	return nil
+
+}
+
+// expandRetries adds the dead-letter exchange and queue and the retry queues of every queue that retries.
+// A retry queue dead-letters expired messages through the default exchange, straight back to its queue only.
+func (t *Topology) expandRetries() {
+
+	for i, n := 0, len(t.Queues); i < n; i++ {
+		queue := &t.Queues[i]
+		if queue.Retry == nil {
+			continue
+		}
+
+		queue.DeadLetterExchange = DeadLetterExchange(queue.Name)
+		t.Exchanges = append(t.Exchanges, Exchange{
+			Name:    DeadLetterExchange(queue.Name),
+			Type:    "fanout",
+			Durable: true,
+		})
+		t.Queues = append(t.Queues, Queue{
+			Name:        DeadLetterQueue(queue.Name),
+			Type:        queue.Type,
+			Durable:     true,
+			DeadLetters: true,
+		})
+		t.Bindings = append(t.Bindings, Binding{
+			Exchange: DeadLetterExchange(queue.Name),
+			Queue:    DeadLetterQueue(queue.Name),
+		})
+
+		for attempt, delay := range queue.Retry.Delays {
+			t.Queues = append(t.Queues, Queue{
+				Name:       RetryQueue(queue.Name, attempt+1),
+				Type:       queue.Type,
+				Durable:    true,
+				MessageTTL: delay,
+				Arguments: map[string]interface{}{
+					"x-dead-letter-exchange":    "",
+					"x-dead-letter-routing-key": queue.Name,
+				},
+			})
+		}
+	}
+
+}
+
+// RetryOf returns how the queue retries, nil when it does not
+func (t *Topology) RetryOf(queue string) *Retry {
+
+	for _, q := range t.Queues {
+		if q.Name == queue {
+			return q.Retry
+		}
+	}
+
+	return nil
+
+}
+
+// DeadLetterQueues lists the queues holding dead letters, whose messages the service collects to be inspected and replayed
+func (t *Topology) DeadLetterQueues() []string {
+
+	queues := []string{}
+	for _, queue := range t.Queues {
+		if queue.DeadLetters {
+			queues = append(queues, queue.Name)
+		}
+	}
+
+	return queues
+
+}
+
+// Declare declares the exchanges, then the queues and then the bindings between them
+func (t *Topology) Declare(ch *amqp.Channel) error {
+
+	for _, exchange := range t.Exchanges {
+		err := exchange.declare(ch)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, queue := range t.Queues {
+		err := queue.declare(ch)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, binding := range t.Bindings {
+		err := binding.declare(ch)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+// Ensure declares the exchanges and queues the broker does not have yet, leaving the ones it has as they are, and then the bindings.
+// The service runs it on every connect, so an entity the broker has with other arguments does not keep it from connecting,
+// it is for one-time-setup to recreate such an entity with Migrate.
+func (t *Topology) Ensure(conn *amqp.Connection) error {
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer func() { ch.Close() }()
+
+	// a failed check closes the channel, the next one is opened on demand
+	missing := func(check func() error, declare func(ch *amqp.Channel) error) error {
+		err := check()
+		if !isNotFound(err) {
+			return err
+		}
+		ch, err = conn.Channel()
+		if err != nil {
+			return err
+		}
+		return declare(ch)
+	}
+
+	for _, exchange := range t.Exchanges {
+		err = missing(func() error {
+			return ch.ExchangeDeclarePassive(exchange.Name, exchange.Type, exchange.Durable, exchange.AutoDelete, exchange.Internal, false, nil)
+		}, exchange.declare)
+		if err != nil {
+			return fmt.Errorf("failed to ensure exchange %s: %w", exchange.Name, err)
+		}
+	}
+
+	for _, queue := range t.Queues {
+		err = missing(func() error {
+			_, err := ch.QueueDeclarePassive(queue.Name, queue.Durable, queue.AutoDelete, false, false, nil)
+			return err
+		}, queue.declare)
+		if err != nil {
+			return fmt.Errorf("failed to ensure queue %s: %w", queue.Name, err)
+		}
+	}
+
+	for _, binding := range t.Bindings {
+		err = binding.declare(ch)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+func isNotFound(err error) bool {
+	var amqpErr *amqp.Error
+	return errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound
+}
+
+func (e Exchange) declare(ch *amqp.Channel) error {
+
+	err := ch.ExchangeDeclare(
+		e.Name,        // name
+		e.Type,        // type
+		e.Durable,     // durable
+		e.AutoDelete,  // auto-deleted
+		e.Internal,    // internal
+		false,         // no-wait
+		e.arguments(), // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", e.Name, err)
+	}
+
+	return nil
+
+}
+
+func (q Queue) declare(ch *amqp.Channel) error {
+
+	_, err := ch.QueueDeclare(
+		q.Name,        // name
+		q.Durable,     // durable
+		q.AutoDelete,  // delete when unused
+		false,         // exclusive
+		false,         // no-wait
+		q.arguments(), // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", q.Name, err)
+	}
+
+	return nil
+
+}
+
+func (b Binding) declare(ch *amqp.Channel) error {
+
+	err := ch.QueueBind(
+		b.Queue,                // queue name
+		b.RoutingKey,           // routing key
+		b.Exchange,             // exchange
+		false,                  // no-wait
+		arguments(b.Arguments), // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bind queue %s to exchange %s with %q: %w", b.Queue, b.Exchange, b.RoutingKey, err)
+	}
+
+	return nil
+
+}
+
+func (e Exchange) arguments() amqp.Table {
+
+	table := arguments(e.Arguments)
+	if e.AlternateExchange != "" {
+		table["alternate-exchange"] = e.AlternateExchange
+	}
+
+	return table
+
+}
+
+func (q Queue) arguments() amqp.Table {
+
+	table := arguments(q.Arguments)
+	if q.Type != "" {
+		table["x-queue-type"] = q.Type
+	}
+	if q.MessageTTL > 0 {
+		table["x-message-ttl"] = time.Duration(q.MessageTTL).Milliseconds()
+	}
+	if q.DeadLetterExchange != "" {
+		table["x-dead-letter-exchange"] = q.DeadLetterExchange
+	}
+	if q.DeadLetterRoutingKey != "" {
+		table["x-dead-letter-routing-key"] = q.DeadLetterRoutingKey
+	}
+	if q.MaxLength > 0 {
+		table["x-max-length"] = q.MaxLength
+	}
+	if q.DeliveryLimit > 0 {
+		table["x-delivery-limit"] = q.DeliveryLimit
+	}
+
+	return table
+
+}
+
+// arguments copies the arguments of the file into a table, with whole numbers as the integers RabbitMQ expects
+func arguments(values map[string]interface{}) amqp.Table {
+
+	table := amqp.Table{}
+	for key, value := range values {
+		table[key] = normalize(value)
+	}
+
+	return table
+
+}
+
+func normalize(value interface{}) interface{} {
+
+	number, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+
+	if integer, err := number.Int64(); err == nil {
+		return integer
+	}
+	if float, err := number.Float64(); err == nil {
+		return float
+	}
+
+	return number.String()
+
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// rolePriority orders the roles from the most to the least privileged, an actor is recorded with the first one it holds
+var rolePriority = []constants.Role{constants.RoleAdmin, constants.RoleSupport, constants.RoleService, constants.RoleUser}
+
+// GetActor describes the caller of the request for the audit trail, with an empty userId when it is not authorized yet
+func GetActor(c *gin.Context) *models.Actor {
+
+	actor := &models.Actor{
+		UserId:    c.GetString("X-User-ID"),
+		RequestId: GetRequestId(c),
+		IP:        c.ClientIP(),
+	}
+
+	roles := GetRoles(c)
+	for _, role := range rolePriority {
+		if containsRole(roles, role) {
+			actor.Role = role.String()
+			break
+		}
+	}
+
+	return actor
+
+}
+
+// GetServiceActor describes a request made by another service, authorized with the service API key
+func GetServiceActor(c *gin.Context, service string) *models.Actor {
+	return &models.Actor{
+		UserId:    service,
+		Role:      constants.RoleService.String(),
+		RequestId: GetRequestId(c),
+		IP:        c.ClientIP(),
+	}
+}
+
+// NewSystemActor describes the jobs and consumers of this service, which change users outside of any request
+func NewSystemActor(name string) *models.Actor {
+	return &models.Actor{
+		UserId: name,
+		Role:   constants.RoleService.String(),
+	}
+}
+
+func containsRole(roles []constants.Role, role constants.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
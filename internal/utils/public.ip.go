@@ -0,0 +1,52 @@
+package utils
+
+import "net"
+
+var nonPublicNetworks = mustParseCIDRs(
+	"0.0.0.0/8",       // "this" network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // documentation
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // documentation
+	"203.0.113.0/24",  // documentation
+	"240.0.0.0/4",     // reserved
+	"::/96",           // IPv4-compatible, deprecated and embeds any IPv4 address
+	"64:ff9b::/96",    // NAT64, can reach private IPv4 addresses
+	"2001::/32",       // Teredo, embeds IPv4 addresses
+	"2001:db8::/32",   // documentation
+	"2002::/16",       // 6to4, embeds any IPv4 address
+)
+
+// IsPublicIP reports whether ip is a globally routable unicast address,
+// rejecting loopback, private, link-local (which includes cloud metadata endpoints) and reserved ranges
+func IsPublicIP(ip net.IP) bool {
+
+	if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks
+
+}
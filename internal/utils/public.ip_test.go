@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"169.254.169.254", false}, // cloud metadata
+		{"fe80::1", false},
+		{"10.0.0.1", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"100.64.0.1", false}, // carrier-grade NAT
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:93.184.216.34", true},
+		{"::10.0.0.1", false},
+		{"2002:a00:1::1", false},                        // 6to4 of 10.0.0.1
+		{"2002:5db8:d822::1", false},                    // 6to4 of a public address, still refused
+		{"2001:0:4136:e378:8000:63bf:f5ff:fffe", false}, // Teredo of 10.0.0.1
+		{"fc00::1", false},
+		{"fd12:3456:789a::1", false},
+		{"64:ff9b::a00:1", false}, // NAT64 of 10.0.0.1
+		{"2001:db8::1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ip, func(t *testing.T) {
+			ip := net.ParseIP(test.ip)
+			if ip == nil {
+				t.Fatalf("%s is not an IP address", test.ip)
+			}
+			if IsPublicIP(ip) != test.public {
+				t.Errorf("got public %v, want %v", !test.public, test.public)
+			}
+		})
+	}
+
+	if IsPublicIP(nil) {
+		t.Error("nil is public")
+	}
+
+}
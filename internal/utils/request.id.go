@@ -0,0 +1,12 @@
+package utils
+
+import "github.com/gin-gonic/gin"
+
+func SetRequestId(c *gin.Context, requestId string) {
+	c.Set("X-Request-ID", requestId)
+}
+
+// GetRequestId returns the id the request is logged and audited with, empty outside of the RequestId middleware
+func GetRequestId(c *gin.Context) string {
+	return c.GetString("X-Request-ID")
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/gin-gonic/gin"
+)
+
+// RolesFromClaims reads the known roles out of the roles custom claim, every caller also holds the user role
+func RolesFromClaims(claims map[string]interface{}) []constants.Role {
+
+	roleSet := constants.GetRoleSet()
+	roles := []constants.Role{constants.RoleUser}
+
+	var claimed []interface{}
+	switch value := claims[constants.RolesClaim].(type) {
+	case []interface{}:
+		claimed = value
+	case string:
+		claimed = []interface{}{value}
+	}
+
+	for _, claim := range claimed {
+		role, ok := claim.(string)
+		if !ok {
+			continue
+		}
+		if _, ok := roleSet[constants.Role(role)]; ok && constants.Role(role) != constants.RoleUser {
+			roles = append(roles, constants.Role(role))
+		}
+	}
+
+	return roles
+
+}
+
+// GetRoles returns the roles of the caller the request was authorized for, none when it was not
+func GetRoles(c *gin.Context) []constants.Role {
+
+	identity, err := GetIdentity(c)
+	if err != nil {
+		return []constants.Role{}
+	}
+
+	return RolesFromClaims(identity.CustomClaims)
+
+}
+
+// HasAnyRole reports whether roles satisfy any of the required roles, admin satisfying all of them
+func HasAnyRole(roles []constants.Role, required []constants.Role) bool {
+
+	for _, role := range roles {
+		if role == constants.RoleAdmin {
+			return true
+		}
+		for _, requiredRole := range required {
+			if role == requiredRole {
+				return true
+			}
+		}
+	}
+
+	return false
+
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// GenerateToken returns size random bytes encoded as unpadded url-safe base64
+func GenerateToken(size int) (string, error) {
+
+	bytes := make([]byte, size)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+
+}
+
+// HashToken is what gets stored for bearer tokens handed out in links, so a leaked collection cannot be used to download
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
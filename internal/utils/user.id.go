@@ -3,6 +3,7 @@ package utils
 import (
 	"errors"
 
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/models"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/exp/slog"
 )
@@ -30,3 +31,28 @@ func GetUserId(c *gin.Context) (string, error) {
 
 	return userId, nil
 }
+
+func SetIdentity(c *gin.Context, identity *models.FirebaseIdentity) error {
+
+	if identity == nil {
+		slog.Error("Identity missing, cannot set identity")
+		return errors.New("identity missing, cannot set identity")
+	}
+
+	c.Set("X-User-Identity", identity)
+	return nil
+
+}
+
+// GetIdentity returns the claims of the Firebase token the request was authorized with
+func GetIdentity(c *gin.Context) (*models.FirebaseIdentity, error) {
+
+	identity, ok := c.Value("X-User-Identity").(*models.FirebaseIdentity)
+
+	if !ok || identity == nil {
+		slog.Error("Identity missing, cannot get identity")
+		return nil, errors.New("identity missing, cannot get identity")
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+)
+
+// GenerateVerificationCode returns a random numeric code with the given number of digits
+func GenerateVerificationCode(digits int) (string, error) {
+
+	var code strings.Builder
+	for i := 0; i < digits; i++ {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code.WriteString(digit.String())
+	}
+
+	return code.String(), nil
+
+}
+
+// HashVerificationCode binds the code to the user and channel it was issued for, so only the hash has to be stored.
+// It is keyed with a server secret, without it the few possible codes could be hashed one by one to find the stored one.
+func HashVerificationCode(secret string, userId string, channel string, code string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(userId + ":" + channel + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignWebhookPayload returns the signature header value "t=<unix>,v1=<hex>[,v1=<hex>...]",
+// with one HMAC-SHA256 of "<unix>.<body>" per active secret so receivers keep verifying during a rotation
+func SignWebhookPayload(secrets []string, timestamp time.Time, body []byte) string {
+
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+
+	var signature strings.Builder
+	signature.WriteString("t=" + unix)
+	for _, secret := range secrets {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(unix + "."))
+		mac.Write(body)
+		signature.WriteString(",v1=" + hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	return signature.String()
+
+}
@@ -1,9 +1,12 @@
 package validations
 
 import (
-	"net/http"
+	"net"
+	"net/url"
+	"strings"
 
 	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/constants"
+	"github.com/Video-Quality-Enhancement/VQE-User-API/internal/utils"
 	validator "github.com/go-playground/validator/v10"
 	"golang.org/x/exp/slog"
 )
@@ -20,28 +23,85 @@ func ValidateNotificationInterfaces(fl validator.FieldLevel) bool {
 	return true
 }
 
-func ValidateWebhooks(fl validator.FieldLevel) bool {
-	webhooks := fl.Field().Interface().([]string)
-	for _, webhook := range webhooks {
+func ValidateNotificationInterface(fl validator.FieldLevel) bool {
+	notificationInterface := fl.Field().String()
+	if _, ok := constants.GetNotificationInterfaceSet()[constants.NotificationInterface(notificationInterface)]; !ok {
+		slog.Error("Invalid notification interface", "notificationInterface", notificationInterface)
+		return false
+	}
+	return true
+}
 
-		// Create a new request
-		req, err := http.NewRequest("GET", webhook, nil)
-		if err != nil {
-			slog.Error("Couldn't create http request", "webhook", webhook)
-			return false
-		}
+func ValidateNotificationEventType(fl validator.FieldLevel) bool {
+	eventType := fl.Field().String()
+	if _, ok := constants.GetNotificationEventTypeSet()[constants.NotificationEventType(eventType)]; !ok {
+		slog.Error("Invalid notification event type", "eventType", eventType)
+		return false
+	}
+	return true
+}
 
-		// Send the request
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			slog.Error("Couldn't send the http request", "webhook", webhook)
+func ValidateNotificationPreferences(fl validator.FieldLevel) bool {
+	notificationEventTypeSet := constants.GetNotificationEventTypeSet()
+	notificationInterfaceSet := constants.GetNotificationInterfaceSet()
+	notificationPreferences := fl.Field().Interface().(map[string][]string)
+	for eventType, notificationInterfaces := range notificationPreferences {
+		if _, ok := notificationEventTypeSet[constants.NotificationEventType(eventType)]; !ok {
+			slog.Error("Invalid notification event type", "eventType", eventType)
 			return false
 		}
+		for _, notificationInterface := range notificationInterfaces {
+			if _, ok := notificationInterfaceSet[constants.NotificationInterface(notificationInterface)]; !ok {
+				slog.Error("Invalid notification interface", "eventType", eventType, "notificationInterface", notificationInterface)
+				return false
+			}
+		}
+	}
+	return true
+}
 
-		if resp.StatusCode != http.StatusOK {
-			slog.Error("Invalid webhook", "webhook", webhook)
+func ValidateFCMplatform(fl validator.FieldLevel) bool {
+	platform := fl.Field().String()
+	if _, ok := constants.GetFCMplatformSet()[constants.FCMplatform(platform)]; !ok {
+		slog.Error("Invalid FCM platform", "platform", platform)
+		return false
+	}
+	return true
+}
+
+func ValidateNotificationEventTypes(fl validator.FieldLevel) bool {
+	notificationEventTypeSet := constants.GetNotificationEventTypeSet()
+	eventTypes := fl.Field().Interface().([]string)
+	for _, eventType := range eventTypes {
+		if _, ok := notificationEventTypeSet[constants.NotificationEventType(eventType)]; !ok {
+			slog.Error("Invalid notification event type", "eventType", eventType)
 			return false
 		}
 	}
 	return true
 }
+
+// ValidateWebhook only checks the URL statically, reachability is proven later by the verification handshake
+// and the webhook client checks the resolved addresses again on every connection
+func ValidateWebhook(fl validator.FieldLevel) bool {
+	webhook := fl.Field().String()
+
+	webhookURL, err := url.Parse(webhook)
+	if err != nil || webhookURL.Scheme != "https" || webhookURL.User != nil {
+		slog.Error("Invalid webhook, not an https url", "webhook", webhook)
+		return false
+	}
+
+	host := strings.ToLower(webhookURL.Hostname())
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		slog.Error("Invalid webhook host", "webhook", webhook)
+		return false
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !utils.IsPublicIP(ip) {
+		slog.Error("Invalid webhook, not a public address", "webhook", webhook)
+		return false
+	}
+
+	return true
+}
@@ -8,6 +8,11 @@ import (
 func RegisterUserValidations() {
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("are-notification-interfaces-valid", ValidateNotificationInterfaces)
-		v.RegisterValidation("are-webhooks-valid", ValidateWebhooks)
+		v.RegisterValidation("is-notification-interface-valid", ValidateNotificationInterface)
+		v.RegisterValidation("is-notification-event-type-valid", ValidateNotificationEventType)
+		v.RegisterValidation("are-notification-preferences-valid", ValidateNotificationPreferences)
+		v.RegisterValidation("are-notification-event-types-valid", ValidateNotificationEventTypes)
+		v.RegisterValidation("is-webhook-valid", ValidateWebhook)
+		v.RegisterValidation("is-fcm-platform-valid", ValidateFCMplatform)
 	}
 }